@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +24,7 @@ var (
 	blocksFlagUntil   string
 	blocksFlagLimit   int
 	blocksFlagTag     string
+	blocksFlagKind    string
 )
 
 // blocksCmd represents the blocks command.
@@ -61,32 +64,113 @@ var (
 	blocksEditFlagNote  string
 	blocksEditFlagStart string
 	blocksEditFlagEnd   string
+	blocksEditFlagKind  string
 )
 
 // Blocks delete flags.
-var blocksDeleteFlagForce bool
+var (
+	blocksDeleteFlagForce     bool
+	blocksDeleteFlagPermanent bool
+)
+
+// Blocks trash flags.
+var (
+	blocksTrashFlagEmpty     bool
+	blocksTrashFlagOlderThan string
+)
 
 // blocksDeleteCmd represents the blocks delete command.
 var blocksDeleteCmd = &cobra.Command{
 	Use:   "delete BLOCK_ID",
 	Short: "Delete a time block",
-	Long: `Delete a time block permanently. By default, prompts for confirmation.
+	Long: `Delete a time block. Deleted blocks are moved to the trash and can be
+restored with "blocks restore". By default, prompts for confirmation.
 
 Examples:
   humantime blocks delete abc123
-  humantime blocks delete abc123 --force`,
+  humantime blocks delete abc123 --force
+  humantime blocks delete abc123 --permanent`,
 	Args: cobra.ExactArgs(1),
 	RunE: runBlocksDelete,
 }
 
+// blocksTrashCmd lists or empties the trash.
+var blocksTrashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List or empty deleted blocks",
+	Long: `List blocks in the trash, or permanently remove them.
+
+Examples:
+  humantime blocks trash
+  humantime blocks trash --empty
+  humantime blocks trash --empty --older-than 30d`,
+	Args: cobra.NoArgs,
+	RunE: runBlocksTrash,
+}
+
+// blocksRestoreCmd restores a block from the trash.
+var blocksRestoreCmd = &cobra.Command{
+	Use:   "restore BLOCK_ID",
+	Short: "Restore a deleted block from the trash",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBlocksRestore,
+}
+
+// Blocks consolidate flags.
+var blocksConsolidateFlagGap string
+
+// blocksConsolidateCmd merges adjacent blocks on the same project and task.
+var blocksConsolidateCmd = &cobra.Command{
+	Use:   "consolidate [on PROJECT[/TASK]] [TIMEFRAME]",
+	Short: "Merge adjacent blocks on the same project and task",
+	Long: `Merge runs of completed blocks that share a project and task and are
+separated by less than the gap, which defaults to the session-gap config
+setting. Merged-away blocks are moved to the trash.
+
+Examples:
+  humantime blocks consolidate today
+  humantime blocks consolidate on clientwork this week --gap 15m`,
+	RunE: runBlocksConsolidate,
+}
+
+// blocksSplitCmd splits a block in two at a given time.
+var blocksSplitCmd = &cobra.Command{
+	Use:   "split BLOCK_ID TIME",
+	Short: "Split a block in two at a given time",
+	Long: `Split a block in two at a given time. The second block keeps the
+project, task, note, tags and kind, and is linked to the first as its
+continuation.
+
+Examples:
+  humantime blocks split 0192f3a4 "today at 14:30"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runBlocksSplit,
+}
+
+// blocksNormalizeTagsCmd merges tags that differ only in case or whitespace.
+var blocksNormalizeTagsCmd = &cobra.Command{
+	Use:   "normalize-tags",
+	Short: "Merge tags that differ only in case or whitespace",
+	Long: `Trim, dedupe and lowercase the tags of every block, so that variants
+like "Billable" and " billable" are reported as one tag. Set the
+preserve-tag-case config option to keep the first spelling instead of
+lowercasing.
+
+Examples:
+  humantime blocks normalize-tags`,
+	Args: cobra.NoArgs,
+	RunE: runBlocksNormalizeTags,
+}
+
 func init() {
 	// List flags
 	blocksCmd.Flags().StringVarP(&blocksFlagProject, "project", "p", "", "Filter by project SID")
 	blocksCmd.Flags().StringVarP(&blocksFlagTask, "task", "t", "", "Filter by task SID")
 	blocksCmd.Flags().StringVar(&blocksFlagFrom, "from", "", "Start of time range")
 	blocksCmd.Flags().StringVar(&blocksFlagUntil, "until", "", "End of time range")
-	blocksCmd.Flags().IntVarP(&blocksFlagLimit, "limit", "l", 50, "Maximum blocks to show")
+	blocksCmd.Flags().IntVarP(&blocksFlagLimit, "limit", "l", 50, "Maximum blocks to show (0 shows all)")
 	blocksCmd.Flags().StringVar(&blocksFlagTag, "tag", "", "Filter by tag")
+	blocksCmd.Flags().StringVar(&blocksFlagKind, "kind", "", "Filter by kind")
 
 	// Dynamic completion for projects/tasks
 	blocksCmd.ValidArgsFunction = completeBlocksArgs
@@ -96,12 +180,25 @@ func init() {
 	blocksEditCmd.Flags().StringVarP(&blocksEditFlagNote, "note", "n", "", "Update note")
 	blocksEditCmd.Flags().StringVarP(&blocksEditFlagStart, "start", "s", "", "Update start timestamp")
 	blocksEditCmd.Flags().StringVarP(&blocksEditFlagEnd, "end", "e", "", "Update end timestamp")
+	blocksEditCmd.Flags().StringVar(&blocksEditFlagKind, "kind", "", "Update kind")
 
 	blocksCmd.AddCommand(blocksEditCmd)
 
 	// Delete flags (using long form only to avoid conflict with global -f flag)
 	blocksDeleteCmd.Flags().BoolVar(&blocksDeleteFlagForce, "force", false, "Skip confirmation prompt")
+	blocksDeleteCmd.Flags().BoolVar(&blocksDeleteFlagPermanent, "permanent", false, "Delete permanently instead of moving to trash")
+
+	// Trash flags
+	blocksTrashCmd.Flags().BoolVar(&blocksTrashFlagEmpty, "empty", false, "Permanently remove trashed blocks")
+	blocksTrashCmd.Flags().StringVar(&blocksTrashFlagOlderThan, "older-than", "", "With --empty, only remove blocks deleted longer ago than this (e.g. 30d, 12h)")
 	blocksCmd.AddCommand(blocksDeleteCmd)
+	blocksCmd.AddCommand(blocksTrashCmd)
+	blocksCmd.AddCommand(blocksRestoreCmd)
+
+	blocksConsolidateCmd.Flags().StringVar(&blocksConsolidateFlagGap, "gap", "", "Merge blocks separated by less than this (e.g. 5m, 1h; default: session-gap config)")
+	blocksCmd.AddCommand(blocksConsolidateCmd)
+	blocksCmd.AddCommand(blocksSplitCmd)
+	blocksCmd.AddCommand(blocksNormalizeTagsCmd)
 
 	rootCmd.AddCommand(blocksCmd)
 }
@@ -126,8 +223,20 @@ func runBlocks(cmd *cobra.Command, args []string) error {
 		ProjectSID: parsed.ProjectSID,
 		TaskSID:    parsed.TaskSID,
 		Tag:        blocksFlagTag,
+		Kind:       blocksFlagKind,
 		Limit:      blocksFlagLimit,
 	}
+	if blocksFlagLimit <= 0 {
+		filter.Limit = storage.NoLimit
+	} else if !cmd.Flags().Changed("limit") {
+		config, err := ctx.ConfigRepo.Get()
+		if err != nil {
+			return err
+		}
+		if config.DefaultListLimit != 0 {
+			filter.Limit = 0 // Let the configured default apply
+		}
+	}
 
 	// Apply time range from parsed timestamps
 	if parsed.HasStart {
@@ -202,6 +311,12 @@ func showSingleBlock(blockID string) error {
 	if block.Note != "" {
 		cli.Printf("  Note: %s\n", cli.Note(block.Note))
 	}
+	if block.Kind != "" {
+		cli.Printf("  Kind: %s\n", block.Kind)
+	}
+	if block.ContinuedFrom != "" {
+		cli.Printf("  Continues: %s\n", block.ContinuedFrom)
+	}
 	cli.Printf("  Started: %s\n", output.FormatTime(block.TimestampStart))
 	if !block.TimestampEnd.IsZero() {
 		cli.Printf("  Ended: %s\n", output.FormatTime(block.TimestampEnd))
@@ -241,7 +356,10 @@ func runBlocksEdit(cmd *cobra.Command, args []string) error {
 	updated := false
 
 	if blocksEditFlagNote != "" {
-		block.Note = blocksEditFlagNote
+		block.Note = ""
+		if err := appendBlockNote(block, blocksEditFlagNote); err != nil {
+			return err
+		}
 		updated = true
 	}
 
@@ -263,8 +381,15 @@ func runBlocksEdit(cmd *cobra.Command, args []string) error {
 		updated = true
 	}
 
+	if blocksEditFlagKind != "" {
+		if err := setBlockKind(block, blocksEditFlagKind); err != nil {
+			return err
+		}
+		updated = true
+	}
+
 	if !updated {
-		return fmt.Errorf("no updates specified (use --note, --start, or --end)")
+		return fmt.Errorf("no updates specified (use --note, --start, --end, or --kind)")
 	}
 
 	// Validate
@@ -425,6 +550,12 @@ func runBlocksDelete(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if blocksDeleteFlagPermanent {
+		if err := safetyBackup("a permanent delete"); err != nil {
+			return err
+		}
+	}
+
 	// Save undo state before deleting
 	if err := ctx.UndoRepo.SaveUndoDelete(block); err != nil {
 		// Non-fatal error
@@ -432,7 +563,11 @@ func runBlocksDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Delete the block
-	if err := ctx.BlockRepo.Delete(block.Key); err != nil {
+	deleteFunc := ctx.BlockRepo.Delete
+	if blocksDeleteFlagPermanent {
+		deleteFunc = ctx.BlockRepo.HardDelete
+	}
+	if err := deleteFunc(block.Key); err != nil {
 		return err
 	}
 
@@ -443,10 +578,236 @@ func runBlocksDelete(cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	ctx.CLIFormatter().Success("Block deleted")
+	if blocksDeleteFlagPermanent {
+		ctx.CLIFormatter().Success("Block deleted permanently")
+	} else {
+		ctx.CLIFormatter().Success("Block moved to trash (restore with 'humantime blocks restore')")
+	}
 	return nil
 }
 
+func runBlocksTrash(cmd *cobra.Command, args []string) error {
+	if blocksTrashFlagEmpty {
+		var olderThan time.Duration
+		if blocksTrashFlagOlderThan != "" {
+			d, err := parseAge(blocksTrashFlagOlderThan)
+			if err != nil {
+				return err
+			}
+			olderThan = d
+		}
+
+		if err := safetyBackup("emptying the trash"); err != nil {
+			return err
+		}
+		purged, err := ctx.BlockRepo.EmptyTrash(olderThan)
+		if err != nil {
+			return err
+		}
+		if ctx.IsJSON() {
+			return ctx.Formatter.JSON(map[string]interface{}{
+				"status": "emptied",
+				"purged": purged,
+			})
+		}
+		ctx.CLIFormatter().Success(fmt.Sprintf("Permanently removed %d block(s) from trash", purged))
+		return nil
+	}
+
+	trashed, err := ctx.BlockRepo.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		type trashOutput struct {
+			*output.BlockOutput
+			DeletedAt string `json:"deleted_at"`
+		}
+		outputs := make([]trashOutput, len(trashed))
+		for i, t := range trashed {
+			outputs[i] = trashOutput{
+				BlockOutput: output.NewBlockOutput(t.Block),
+				DeletedAt:   t.DeletedAt.Format(time.RFC3339),
+			}
+		}
+		return ctx.Formatter.JSON(map[string]interface{}{"trash": outputs})
+	}
+
+	cli := ctx.CLIFormatter()
+	if len(trashed) == 0 {
+		cli.Muted("Trash is empty")
+		return nil
+	}
+
+	cli.Title(fmt.Sprintf("Trash (%d)", len(trashed)))
+	cli.Println("")
+	for _, t := range trashed {
+		b := t.Block
+		shortID := strings.TrimPrefix(b.Key, "block:")
+		if len(shortID) > 8 {
+			shortID = shortID[len(shortID)-8:]
+		}
+		cli.Printf("%s  %-18s %8s  %s  (deleted %s)\n",
+			shortID,
+			cli.FormatProjectTask(b.ProjectSID, b.TaskSID),
+			output.FormatDuration(b.Duration()),
+			b.TimestampStart.Format("2006-01-02 15:04"),
+			t.DeletedAt.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runBlocksRestore(cmd *cobra.Command, args []string) error {
+	blockID := args[0]
+
+	trashed, err := ctx.BlockRepo.ListTrash()
+	if err != nil {
+		return err
+	}
+	var blockKey string
+	for _, t := range trashed {
+		if containsID(t.BlockKey(), blockID) {
+			blockKey = t.BlockKey()
+			break
+		}
+	}
+	if blockKey == "" {
+		return runtime.ErrBlockNotFound
+	}
+
+	block, err := ctx.BlockRepo.Restore(blockKey)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status": "restored",
+			"block":  output.NewBlockOutput(block),
+		})
+	}
+
+	cli := ctx.CLIFormatter()
+	cli.Success("Restored block for " + cli.FormatProjectTask(block.ProjectSID, block.TaskSID))
+	return nil
+}
+
+func runBlocksConsolidate(cmd *cobra.Command, args []string) error {
+	gap := storage.UseSessionGap
+	if blocksConsolidateFlagGap != "" {
+		result := parser.ParseDuration(blocksConsolidateFlagGap)
+		if !result.Valid || result.Duration < 0 {
+			return runtime.NewValidationError("gap", fmt.Sprintf("invalid gap %q (use e.g. 5m or 1h)", blocksConsolidateFlagGap))
+		}
+		gap = result.Duration
+	}
+
+	parsed := parser.Parse(args)
+	if err := parsed.Process(); err != nil {
+		return err
+	}
+
+	filter := storage.BlockFilter{
+		ProjectSID: parsed.ProjectSID,
+		TaskSID:    parsed.TaskSID,
+	}
+	if parsed.HasStart {
+		filter.StartAfter = parsed.TimestampStart
+	}
+	if parsed.HasEnd {
+		filter.EndBefore = parsed.TimestampEnd
+	}
+	if len(args) > 0 && !parsed.HasProject {
+		if periodInput := joinArgs(args); isPeriodPhrase(periodInput) {
+			timeRange := parser.GetPeriodRange(periodInput)
+			filter.StartAfter = timeRange.Start
+			filter.EndBefore = timeRange.End
+		}
+	}
+
+	merged, err := ctx.BlockRepo.Consolidate(filter, gap)
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status": "consolidated",
+			"merged": merged,
+		})
+	}
+	ctx.CLIFormatter().Success(fmt.Sprintf("Merged %d block(s)", merged))
+	return nil
+}
+
+func runBlocksNormalizeTags(cmd *cobra.Command, args []string) error {
+	changed, err := ctx.BlockRepo.NormalizeTags()
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status":  "normalized",
+			"changed": changed,
+		})
+	}
+	ctx.CLIFormatter().Success(fmt.Sprintf("Normalized tags on %d block(s)", changed))
+	return nil
+}
+
+func runBlocksSplit(cmd *cobra.Command, args []string) error {
+	block, err := findBlockByID(args[0])
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return runtime.ErrBlockNotFound
+	}
+
+	result := parser.ParseTimestamp(strings.Join(args[1:], " "))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	next, err := ctx.BlockRepo.Split(block.Key, result.Time)
+	if errors.Is(err, storage.ErrSplitOutsideBlock) {
+		return runtime.NewValidationError("time", "must fall inside the block")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.UndoRepo.SaveUndoSplit(next.Key); err != nil {
+		ctx.Debugf("Failed to save undo state: %v", err)
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status": "split",
+			"block":  output.NewBlockOutput(next),
+		})
+	}
+	ctx.CLIFormatter().Success("Split block at " + output.FormatTime(result.Time) + ", continued in " + next.Key)
+	return nil
+}
+
+// parseAge parses an age such as "30d", "12h" or "90m".
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	result := parser.ParseDuration(s)
+	if !result.Valid || result.Duration < 0 {
+		return 0, runtime.NewValidationError("older-than", fmt.Sprintf("invalid age %q (use e.g. 30d or 12h)", s))
+	}
+	return result.Duration, nil
+}
+
 // findBlockByID finds a block by full or partial ID.
 func findBlockByID(blockID string) (*model.Block, error) {
 	// Try full key first
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/storage"
+)
+
+// =============================================================================
+// Blocks Delete Tests
+// =============================================================================
+
+func TestRunBlocksDeletePermanentBacksUp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	setupDiskTestContext(t, dbPath)
+
+	config, err := ctx.ConfigRepo.Get()
+	require.NoError(t, err)
+	config.SafetyBackups = true
+	require.NoError(t, ctx.ConfigRepo.Save(config))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock(config.UserKey, "work", "", "", start)
+	block.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, ctx.BlockRepo.Create(block))
+
+	oldForce, oldPermanent := blocksDeleteFlagForce, blocksDeleteFlagPermanent
+	blocksDeleteFlagForce, blocksDeleteFlagPermanent = true, true
+	t.Cleanup(func() { blocksDeleteFlagForce, blocksDeleteFlagPermanent = oldForce, oldPermanent })
+
+	id := strings.TrimPrefix(block.Key, "block:")
+	require.NoError(t, runBlocksDelete(blocksDeleteCmd, []string{id}))
+
+	backups, err := storage.ListSafetyBackups(dbPath)
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	_, err = ctx.BlockRepo.Get(block.Key)
+	assert.True(t, storage.IsErrKeyNotFound(err))
+}
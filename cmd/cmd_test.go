@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/output"
+	"github.com/manav03panchal/humantime/internal/runtime"
+)
+
+// setupTestContext points the shared context at an in-memory database and
+// returns the buffer command output is written to.
+func setupTestContext(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	opts := runtime.DefaultOptions()
+	opts.InMemory = true
+	return openTestContext(t, opts)
+}
+
+// setupDiskTestContext is setupTestContext for a database stored at dbPath,
+// for commands that touch files next to it such as safety backups.
+func setupDiskTestContext(t *testing.T, dbPath string) *bytes.Buffer {
+	t.Helper()
+	opts := runtime.DefaultOptions()
+	opts.DBPath = dbPath
+	return openTestContext(t, opts)
+}
+
+func openTestContext(t *testing.T, opts runtime.Options) *bytes.Buffer {
+	t.Helper()
+	opts.ColorMode = output.ColorNever
+
+	c, err := runtime.New(opts)
+	require.NoError(t, err, "failed to open test context")
+	var out bytes.Buffer
+	c.Formatter.Writer = &out
+
+	previous := ctx
+	ctx = c
+	t.Cleanup(func() {
+		ctx = previous
+		c.Close()
+	})
+	return &out
+}
@@ -0,0 +1,575 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/parser"
+	"github.com/manav03panchal/humantime/internal/runtime"
+)
+
+// configSetting describes a user-editable configuration key.
+type configSetting struct {
+	Name  string
+	Help  string
+	Get   func(c *model.Config) string
+	Set   func(c *model.Config, value string) error
+	Reset func(c *model.Config)
+}
+
+// configSettings lists the keys accepted by "config set".
+var configSettings = []configSetting{
+	{
+		Name: "min-track-unit",
+		Help: "Round stopped blocks up to a multiple of this duration (0 disables)",
+		Get: func(c *model.Config) string {
+			return formatConfigDuration(c.MinTrackUnit)
+		},
+		Set: func(c *model.Config, value string) error {
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return err
+			}
+			c.MinTrackUnit = d
+			return nil
+		},
+		Reset: func(c *model.Config) { c.MinTrackUnit = 0 },
+	},
+	{
+		Name: "max-note-length",
+		Help: fmt.Sprintf("Maximum note length in characters (default %d)", model.DefaultMaxNoteLength),
+		Get: func(c *model.Config) string {
+			return strconv.Itoa(c.NoteLimit())
+		},
+		Set: func(c *model.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid note length %q", value))
+			}
+			c.MaxNoteLength = n
+			return nil
+		},
+		Reset: func(c *model.Config) { c.MaxNoteLength = 0 },
+	},
+	{
+		Name: "truncate-notes",
+		Help: "Truncate notes over the limit instead of rejecting them (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.TruncateNotes)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.TruncateNotes = b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.TruncateNotes = false },
+	},
+	{
+		Name: "auto-create-projects",
+		Help: "Create unknown projects when tracking starts (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.AutoCreateProjects())
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.NoAutoCreateProjects = !b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.NoAutoCreateProjects = false },
+	},
+	{
+		Name: "normalize-project-names",
+		Help: "Title-case the display names of auto-created projects, e.g. my-project as My Project (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(!c.RawProjectNames)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.RawProjectNames = !b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.RawProjectNames = false },
+	},
+	{
+		Name: "daily-cap",
+		Help: "Count at most this much time per day in day totals (0 disables)",
+		Get: func(c *model.Config) string {
+			return formatConfigDuration(c.DailyCap)
+		},
+		Set: func(c *model.Config, value string) error {
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return err
+			}
+			c.DailyCap = d
+			return nil
+		},
+		Reset: func(c *model.Config) { c.DailyCap = 0 },
+	},
+	{
+		Name: "session-gap",
+		Help: "Shortest pause separating two sessions, e.g. when consolidating (default 5m)",
+		Get: func(c *model.Config) string {
+			return formatConfigDuration(c.SessionThreshold())
+		},
+		Set: func(c *model.Config, value string) error {
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return err
+			}
+			c.SessionGap = d
+			return nil
+		},
+		Reset: func(c *model.Config) { c.SessionGap = 0 },
+	},
+	{
+		Name: "idle-after",
+		Help: "Offer to track gaps at least this long on resume (0 disables)",
+		Get: func(c *model.Config) string {
+			return formatConfigDuration(c.IdleAfter)
+		},
+		Set: func(c *model.Config, value string) error {
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return err
+			}
+			c.IdleAfter = d
+			return nil
+		},
+		Reset: func(c *model.Config) { c.IdleAfter = 0 },
+	},
+	{
+		Name: "error-on-no-active-stop",
+		Help: "Fail when stop is run with nothing being tracked (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.ErrorOnNoActiveStop)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.ErrorOnNoActiveStop = b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.ErrorOnNoActiveStop = false },
+	},
+	{
+		Name: "resume-snap",
+		Help: "Snap resumed block starts to a minute: " + strings.Join(model.ResumeSnapModes, ", ") + " (default exact)",
+		Get: func(c *model.Config) string {
+			if c.ResumeSnap == "" {
+				return model.ResumeSnapExact
+			}
+			return c.ResumeSnap
+		},
+		Set: func(c *model.Config, value string) error {
+			mode := strings.ToLower(value)
+			if !slices.Contains(model.ResumeSnapModes, mode) {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid snap mode %q, must be one of %s",
+					value, strings.Join(model.ResumeSnapModes, ", ")))
+			}
+			if mode == model.ResumeSnapExact {
+				mode = ""
+			}
+			c.ResumeSnap = mode
+			return nil
+		},
+		Reset: func(c *model.Config) { c.ResumeSnap = "" },
+	},
+	{
+		Name: "heartbeat-interval",
+		Help: "Expected interval between heartbeats; older timers are offered to be closed (0 disables)",
+		Get: func(c *model.Config) string {
+			return formatConfigDuration(c.HeartbeatInterval)
+		},
+		Set: func(c *model.Config, value string) error {
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return err
+			}
+			c.HeartbeatInterval = d
+			return nil
+		},
+		Reset: func(c *model.Config) { c.HeartbeatInterval = 0 },
+	},
+	{
+		Name: "safety-backups",
+		Help: "Back up the database before forced imports, permanent deletes and emptying the trash (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.SafetyBackups)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.SafetyBackups = b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.SafetyBackups = false },
+	},
+	{
+		Name: "safety-backup-retention",
+		Help: fmt.Sprintf("Number of safety backups to keep (default %d)", model.DefaultSafetyBackupRetention),
+		Get: func(c *model.Config) string {
+			return strconv.Itoa(c.BackupRetention())
+		},
+		Set: func(c *model.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid retention %q", value))
+			}
+			c.SafetyBackupRetention = n
+			return nil
+		},
+		Reset: func(c *model.Config) { c.SafetyBackupRetention = 0 },
+	},
+	{
+		Name: "default-list-limit",
+		Help: "Blocks listed when no --limit is given (0 keeps each command's own default)",
+		Get: func(c *model.Config) string {
+			return strconv.Itoa(c.DefaultListLimit)
+		},
+		Set: func(c *model.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid list limit %q", value))
+			}
+			c.DefaultListLimit = n
+			return nil
+		},
+		Reset: func(c *model.Config) { c.DefaultListLimit = 0 },
+	},
+	{
+		Name: "block-kinds",
+		Help: "Comma-separated kinds blocks can be classified as with --kind",
+		Get: func(c *model.Config) string {
+			return strings.Join(c.Kinds(), ",")
+		},
+		Set: func(c *model.Config, value string) error {
+			var kinds []string
+			for _, kind := range strings.Split(value, ",") {
+				kind = strings.ToLower(strings.TrimSpace(kind))
+				if kind == "" {
+					continue
+				}
+				if !parser.ValidateSID(kind) {
+					return runtime.NewValidationError("config", fmt.Sprintf("invalid kind %q", kind))
+				}
+				kinds = append(kinds, kind)
+			}
+			if len(kinds) == 0 {
+				return runtime.NewValidationError("config", "block-kinds must contain at least one kind")
+			}
+			c.BlockKinds = kinds
+			return nil
+		},
+		Reset: func(c *model.Config) { c.BlockKinds = nil },
+	},
+	{
+		Name: "tag-hashtags",
+		Help: "Turn #hashtags in notes into tags (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.TagHashtags)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.TagHashtags = b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.TagHashtags = false },
+	},
+	{
+		Name: "preserve-tag-case",
+		Help: "Keep tag spelling when normalizing tags instead of lowercasing (true/false)",
+		Get: func(c *model.Config) string {
+			return strconv.FormatBool(c.PreserveTagCase)
+		},
+		Set: func(c *model.Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return runtime.NewValidationError("config", fmt.Sprintf("invalid boolean %q", value))
+			}
+			c.PreserveTagCase = b
+			return nil
+		},
+		Reset: func(c *model.Config) { c.PreserveTagCase = false },
+	},
+	{
+		Name: "auto-tag-rules",
+		Help: "Semicolon-separated REGEX=TAG rules tagging blocks by note",
+		Get: func(c *model.Config) string {
+			rules := make([]string, len(c.AutoTagRules))
+			for i, rule := range c.AutoTagRules {
+				rules[i] = rule.Pattern + "=" + rule.Tag
+			}
+			return strings.Join(rules, ";")
+		},
+		Set: func(c *model.Config, value string) error {
+			var rules []model.AutoTagRule
+			for _, rule := range strings.Split(value, ";") {
+				rule = strings.TrimSpace(rule)
+				if rule == "" {
+					continue
+				}
+				// The tag follows the last '=', so patterns may contain '='
+				i := strings.LastIndex(rule, "=")
+				if i <= 0 {
+					return runtime.NewValidationError("config", fmt.Sprintf("invalid rule %q (use REGEX=TAG)", rule))
+				}
+				pattern, tag := rule[:i], strings.TrimSpace(rule[i+1:])
+				if _, err := regexp.Compile(pattern); err != nil {
+					return runtime.NewValidationError("config", fmt.Sprintf("invalid pattern %q: %v", pattern, err))
+				}
+				if tag == "" || strings.ContainsAny(tag, " \t,") {
+					return runtime.NewValidationError("config", fmt.Sprintf("invalid tag %q", tag))
+				}
+				rules = append(rules, model.AutoTagRule{Pattern: pattern, Tag: tag})
+			}
+			c.AutoTagRules = rules
+			return nil
+		},
+		Reset: func(c *model.Config) { c.AutoTagRules = nil },
+	},
+	{
+		Name: "project-palette",
+		Help: "Comma-separated hex colors assigned to new projects in turn",
+		Get: func(c *model.Config) string {
+			return strings.Join(c.Palette(), ",")
+		},
+		Set: func(c *model.Config, value string) error {
+			var palette []string
+			for _, color := range strings.Split(value, ",") {
+				color = strings.TrimSpace(color)
+				if color == "" {
+					continue
+				}
+				if !model.ValidateColor(color) {
+					return runtime.NewValidationError("config", fmt.Sprintf("invalid hex color %q", color))
+				}
+				palette = append(palette, color)
+			}
+			if len(palette) == 0 {
+				return runtime.NewValidationError("config", "palette must contain at least one color")
+			}
+			c.ProjectPalette = palette
+			return nil
+		},
+		Reset: func(c *model.Config) { c.ProjectPalette = nil },
+	},
+}
+
+// configCmd represents the config command.
+var configCmd = &cobra.Command{
+	Use:     "config",
+	Aliases: []string{"cfg", "settings"},
+	Short:   "Show or change settings",
+	Long: `Show current settings, or change one with "config set".
+
+Examples:
+  ht config
+  ht config set min-track-unit 6m
+  ht config unset min-track-unit
+  ht config export -o settings.json
+  ht config import settings.json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+// configSetCmd sets a configuration value.
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Change a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+// configUnsetCmd restores a configuration value to its default.
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Restore a setting to its default",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+// configExportCmd writes the settings to a portable file.
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export settings to a portable file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigExport,
+}
+
+// configImportCmd replaces the settings with those from a file.
+var configImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import settings from a file (time data is not touched)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigImport,
+}
+
+// Config export flags.
+var configFlagOutput string
+
+func init() {
+	configExportCmd.Flags().StringVarP(&configFlagOutput, "output", "o", "", "Output file (stdout if omitted)")
+
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configExportCmd)
+	configCmd.AddCommand(configImportCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		values := make(map[string]string, len(configSettings))
+		for _, s := range configSettings {
+			values[s.Name] = s.Get(config)
+		}
+		return ctx.Formatter.JSON(values)
+	}
+
+	cli := ctx.CLIFormatter()
+	cli.Title("Settings")
+	for _, s := range configSettings {
+		cli.Printf("  %-18s %s\n", s.Name, s.Get(config))
+		cli.Muted("  " + strings.Repeat(" ", 19) + s.Help)
+	}
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	setting, err := findConfigSetting(args[0])
+	if err != nil {
+		return err
+	}
+
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	if err := setting.Set(config, args[1]); err != nil {
+		return err
+	}
+	if err := ctx.ConfigRepo.Save(config); err != nil {
+		return err
+	}
+
+	return printConfigValue(setting, config)
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	setting, err := findConfigSetting(args[0])
+	if err != nil {
+		return err
+	}
+
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	setting.Reset(config)
+	if err := ctx.ConfigRepo.Save(config); err != nil {
+		return err
+	}
+
+	return printConfigValue(setting, config)
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) error {
+	data, err := ctx.ConfigRepo.ExportConfig()
+	if err != nil {
+		return err
+	}
+
+	if configFlagOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(configFlagOutput, data, 0600); err != nil {
+		return err
+	}
+	if !ctx.IsJSON() {
+		ctx.CLIFormatter().Success("Settings exported: " + configFlagOutput)
+	}
+	return nil
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if _, err := ctx.ConfigRepo.ImportConfig(data); err != nil {
+		return err
+	}
+
+	return runConfigShow(cmd, nil)
+}
+
+func printConfigValue(setting *configSetting, config *model.Config) error {
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]string{setting.Name: setting.Get(config)})
+	}
+	ctx.CLIFormatter().Success(fmt.Sprintf("%s = %s", setting.Name, setting.Get(config)))
+	return nil
+}
+
+func findConfigSetting(name string) (*configSetting, error) {
+	for i := range configSettings {
+		if configSettings[i].Name == name {
+			return &configSettings[i], nil
+		}
+	}
+	names := make([]string, len(configSettings))
+	for i, s := range configSettings {
+		names[i] = s.Name
+	}
+	return nil, runtime.NewValidationError("config", fmt.Sprintf("unknown setting %q (valid: %s)", name, strings.Join(names, ", ")))
+}
+
+func parseConfigDuration(value string) (time.Duration, error) {
+	if value == "0" || value == "off" {
+		return 0, nil
+	}
+	result := parser.ParseDuration(value)
+	if !result.Valid || result.Duration < 0 {
+		return 0, runtime.NewValidationError("config", fmt.Sprintf("invalid duration %q", value))
+	}
+	return result.Duration, nil
+}
+
+func formatConfigDuration(d time.Duration) string {
+	if d == 0 {
+		return "off"
+	}
+	return d.String()
+}
@@ -1,28 +1,37 @@
 package cmd
 
 import (
-	"encoding/csv"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"time"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/manav03panchal/humantime/internal/model"
-	"github.com/manav03panchal/humantime/internal/output"
 	"github.com/manav03panchal/humantime/internal/parser"
+	"github.com/manav03panchal/humantime/internal/runtime"
 	"github.com/manav03panchal/humantime/internal/storage"
 )
 
 // Export command flags.
 var (
-	exportFlagProject string
-	exportFlagFrom    string
-	exportFlagUntil   string
-	exportFlagFormat  string
-	exportFlagBackup  bool
-	exportFlagOutput  string
+	exportFlagProject  string
+	exportFlagFrom     string
+	exportFlagUntil    string
+	exportFlagFormat   string
+	exportFlagBackup   bool
+	exportFlagOutput   string
+	exportFlagAgg      bool
+	exportFlagByDay    bool
+	exportFlagUnit     string
+	exportFlagRedact   []string
+	exportFlagManifest bool
+	exportFlagTimeFmt  string
+	exportFlagActive   bool
+	exportFlagGzip     bool
 )
 
 // exportCmd represents the export command.
@@ -37,7 +46,12 @@ Examples:
   ht export clientwork
   ht export --from "last month"
   ht export --format csv -o report.csv
-  ht export --backup -o backup.json`,
+  ht export --format timeclock -o time.timeclock
+  ht export --backup -o backup.json
+  ht export --backup --manifest -o backup.json
+  ht export --backup --gzip -o backup.json
+  ht export --active
+  ht export --aggregate --by-day --format csv`,
 	RunE: runExport,
 }
 
@@ -45,9 +59,17 @@ func init() {
 	exportCmd.Flags().StringVarP(&exportFlagProject, "project", "p", "", "Filter by project SID")
 	exportCmd.Flags().StringVar(&exportFlagFrom, "from", "", "Start of time range")
 	exportCmd.Flags().StringVar(&exportFlagUntil, "until", "", "End of time range")
-	exportCmd.Flags().StringVarP(&exportFlagFormat, "format", "F", "json", "Output format: json, csv")
+	exportCmd.Flags().StringVarP(&exportFlagFormat, "format", "F", "json", "Output format: json, csv, timeclock")
 	exportCmd.Flags().BoolVarP(&exportFlagBackup, "backup", "b", false, "Full database backup")
+	exportCmd.Flags().BoolVar(&exportFlagManifest, "manifest", false, "Write a checksum manifest alongside the backup (with --backup)")
 	exportCmd.Flags().StringVarP(&exportFlagOutput, "output", "o", "", "Output file (stdout if omitted)")
+	exportCmd.Flags().BoolVar(&exportFlagAgg, "aggregate", false, "Export per-project totals only (no individual blocks)")
+	exportCmd.Flags().BoolVar(&exportFlagByDay, "by-day", false, "Include per-day totals in aggregated export")
+	exportCmd.Flags().StringArrayVar(&exportFlagRedact, "redact", nil, "Replace note text matching these patterns with [redacted] (repeatable)")
+	exportCmd.Flags().StringVar(&exportFlagTimeFmt, "time-format", "", "Go time layout for CSV start/end columns (e.g. \"2006-01-02 15:04\")")
+	exportCmd.Flags().BoolVar(&exportFlagGzip, "gzip", false, "Compress the output with gzip (implied by an output file ending in .gz)")
+	exportCmd.Flags().BoolVar(&exportFlagActive, "active", false, "Export only the block being tracked now (empty when idle)")
+	exportCmd.Flags().StringVar(&exportFlagUnit, "duration-unit", "", "Block duration unit: seconds, hours, both (default depends on format)")
 
 	exportCmd.ValidArgsFunction = completeBlocksArgs
 	exportCmd.RegisterFlagCompletionFunc("project", completeProjects)
@@ -56,9 +78,11 @@ func init() {
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
+	outputPath, compress := exportCompression()
+
 	// Handle backup mode
 	if exportFlagBackup {
-		return runBackup()
+		return runBackup(outputPath, compress)
 	}
 
 	// Parse arguments
@@ -71,6 +95,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 	// Build filter
 	filter := storage.BlockFilter{
 		ProjectSID: parsed.ProjectSID,
+		Limit:      storage.NoLimit,
 	}
 
 	if parsed.HasStart {
@@ -81,173 +106,164 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get blocks
-	blocks, err := ctx.BlockRepo.ListFiltered(filter)
+	var blocks []*model.Block
+	var err error
+	if exportFlagActive {
+		blocks, err = ctx.ActiveBlockRepo.ActiveBlocks(ctx.BlockRepo)
+	} else {
+		blocks, err = ctx.BlockRepo.ListFiltered(filter)
+	}
 	if err != nil {
 		return err
 	}
 
 	// Determine output destination
-	var writer *os.File
-	if exportFlagOutput != "" {
-		f, err := os.Create(exportFlagOutput)
+	var writer io.Writer = os.Stdout
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
 		if err != nil {
 			return err
 		}
 		defer f.Close()
 		writer = f
-	} else {
-		writer = os.Stdout
 	}
 
-	// Export based on format
-	switch exportFlagFormat {
-	case "csv":
-		return exportCSV(writer, blocks)
-	default:
-		return exportJSON(writer, blocks)
+	if !compress {
+		return writeExport(writer, blocks)
 	}
+	zw := gzip.NewWriter(writer)
+	if err := writeExport(zw, blocks); err != nil {
+		return err
+	}
+	return zw.Close()
 }
 
-func exportJSON(w *os.File, blocks []*model.Block) error {
-	data := struct {
-		Version    string                `json:"version"`
-		ExportedAt string                `json:"exported_at"`
-		Blocks     []*output.BlockOutput `json:"blocks"`
-		Count      int                   `json:"count"`
-	}{
-		Version:    "2",
-		ExportedAt: time.Now().Format(time.RFC3339),
-		Blocks:     make([]*output.BlockOutput, len(blocks)),
-		Count:      len(blocks),
+// exportCompression returns the output file name and whether the export
+// should be gzipped. With --gzip, the name gains a .gz suffix if it lacks
+// one.
+func exportCompression() (string, bool) {
+	if strings.HasSuffix(exportFlagOutput, ".gz") {
+		return exportFlagOutput, true
 	}
-
-	for i, b := range blocks {
-		data.Blocks[i] = convertBlockToOutput(b)
+	if exportFlagGzip && exportFlagOutput != "" {
+		return exportFlagOutput + ".gz", true
 	}
-
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+	return exportFlagOutput, exportFlagGzip
 }
 
-func exportCSV(w *os.File, blocks []*model.Block) error {
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
-
-	// Write header
-	if err := writer.Write([]string{
-		"date", "project", "start", "end", "duration_hours", "note", "tags",
-	}); err != nil {
-		return err
+// writeExport writes blocks to writer in the requested format.
+func writeExport(writer io.Writer, blocks []*model.Block) error {
+	// Export totals only when aggregating
+	if exportFlagAgg {
+		config, err := ctx.ConfigRepo.Get()
+		if err != nil {
+			return err
+		}
+		loc, err := reportLocation(exportFlagProject)
+		if err != nil {
+			return err
+		}
+		opts := storage.AggregateExportOptions{ByDay: exportFlagByDay, Location: loc, DailyCap: config.DailyCap}
+		if exportFlagFormat == "csv" {
+			return storage.ExportAggregateCSV(writer, blocks, opts)
+		}
+		return storage.ExportAggregateJSON(writer, blocks, opts)
 	}
 
-	// Write rows
-	for _, b := range blocks {
-		endStr := ""
-		if !b.TimestampEnd.IsZero() {
-			endStr = b.TimestampEnd.Format("15:04")
-		}
+	opts := storage.ExportOptions{
+		DurationUnit: storage.DurationUnit(exportFlagUnit),
+		Redact:       exportFlagRedact,
+		TimeLayout:   exportFlagTimeFmt,
+	}
+	switch opts.DurationUnit {
+	case storage.DurationUnitDefault, storage.DurationUnitSeconds, storage.DurationUnitHours, storage.DurationUnitBoth:
+	default:
+		return runtime.NewValidationError("duration-unit", "must be one of seconds, hours, both")
+	}
 
-		duration := b.Duration().Hours()
-		tags := ""
-		if len(b.Tags) > 0 {
-			for i, t := range b.Tags {
-				if i > 0 {
-					tags += ","
-				}
-				tags += t
+	// Report progress on stderr for large exports to a file
+	if exportFlagOutput != "" && !ctx.IsJSON() && len(blocks) > storage.DefaultProgressInterval {
+		opts.Progress = func(written, total int) {
+			fmt.Fprintf(os.Stderr, "\rExported %d/%d blocks", written, total)
+			if written == total {
+				fmt.Fprintln(os.Stderr)
 			}
 		}
-
-		if err := writer.Write([]string{
-			b.TimestampStart.Format("2006-01-02"),
-			b.ProjectSID,
-			b.TimestampStart.Format("15:04"),
-			endStr,
-			strconv.FormatFloat(duration, 'f', 2, 64),
-			b.Note,
-			tags,
-		}); err != nil {
-			return err
-		}
 	}
 
-	return nil
+	// Export based on format
+	switch exportFlagFormat {
+	case "csv":
+		return storage.ExportBlocksCSV(writer, blocks, opts)
+	case "timeclock":
+		return storage.ExportBlocksTimeclock(writer, blocks, opts)
+	default:
+		return storage.ExportBlocksJSON(writer, blocks, opts)
+	}
 }
 
-func runBackup() error {
-	// Get all data
-	projects, err := ctx.ProjectRepo.List()
+func runBackup(outputPath string, compress bool) error {
+	backup, err := storage.NewBackup(ctx.DB)
 	if err != nil {
 		return err
 	}
 
-	blocks, err := ctx.BlockRepo.List()
+	data, err := backup.Encode()
 	if err != nil {
 		return err
 	}
 
-	activeBlock, err := ctx.ActiveBlockRepo.Get()
-	if err != nil {
-		return err
+	if exportFlagManifest && outputPath == "" {
+		return fmt.Errorf("--manifest requires --output")
 	}
 
-	// Build backup
-	backup := struct {
-		Version     string             `json:"version"`
-		ExportedAt  string             `json:"exported_at"`
-		Projects    []*model.Project   `json:"projects"`
-		Blocks      []*model.Block     `json:"blocks"`
-		ActiveBlock *model.ActiveBlock `json:"active_block"`
-	}{
-		Version:     "2",
-		ExportedAt:  time.Now().Format(time.RFC3339),
-		Projects:    projects,
-		Blocks:      blocks,
-		ActiveBlock: activeBlock,
+	// The manifest describes the uncompressed backup
+	var manifest *storage.BackupManifest
+	if exportFlagManifest {
+		manifest, err = storage.NewBackupManifest(data)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Determine output destination
-	var writer *os.File
-	if exportFlagOutput != "" {
-		f, err := os.Create(exportFlagOutput)
+	if compress {
+		data, err = storage.Compress(data)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		writer = f
-	} else {
-		writer = os.Stdout
 	}
 
-	encoder := json.NewEncoder(writer)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(backup); err != nil {
+	// Write to stdout if no output file
+	if outputPath == "" {
+		_, err := os.Stdout.Write(data)
 		return err
 	}
 
-	// Print summary if writing to file
-	if exportFlagOutput != "" && !ctx.IsJSON() {
-		cli := ctx.CLIFormatter()
-		cli.Success("Backup created: " + exportFlagOutput)
-		cli.Printf("  Projects: %d\n", len(projects))
-		cli.Printf("  Blocks: %d\n", len(blocks))
+	if err := os.WriteFile(outputPath, data, 0600); err != nil {
+		return err
 	}
 
-	return nil
-}
-
-func convertBlockToOutput(b *model.Block) *output.BlockOutput {
-	out := &output.BlockOutput{
-		Key:             b.Key,
-		ProjectSID:      b.ProjectSID,
-		Note:            b.Note,
-		TimestampStart:  b.TimestampStart.Format(time.RFC3339),
-		DurationSeconds: b.DurationSeconds(),
-		IsActive:        b.IsActive(),
+	manifestPath := outputPath + storage.BackupManifestSuffix
+	if manifest != nil {
+		manifestData, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(manifestPath, append(manifestData, '\n'), 0600); err != nil {
+			return err
+		}
 	}
-	if !b.TimestampEnd.IsZero() {
-		out.TimestampEnd = b.TimestampEnd.Format(time.RFC3339)
+
+	// Print summary
+	if !ctx.IsJSON() {
+		cli := ctx.CLIFormatter()
+		cli.Success("Backup created: " + outputPath)
+		cli.Printf("  Projects: %d\n", len(backup.Projects))
+		cli.Printf("  Blocks: %d\n", len(backup.Blocks))
+		if exportFlagManifest {
+			cli.Printf("  Manifest: %s\n", manifestPath)
+		}
 	}
-	return out
+
+	return nil
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Export Compression Tests
+// =============================================================================
+
+func TestRunExportGzipTwice(t *testing.T) {
+	setupTestContext(t)
+	dir := t.TempDir()
+
+	oldOutput, oldGzip := exportFlagOutput, exportFlagGzip
+	exportFlagOutput, exportFlagGzip = filepath.Join(dir, "blocks.json"), true
+	t.Cleanup(func() { exportFlagOutput, exportFlagGzip = oldOutput, oldGzip })
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, runExport(exportCmd, nil))
+	}
+
+	assert.Equal(t, filepath.Join(dir, "blocks.json"), exportFlagOutput)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "blocks.json.gz", entries[0].Name())
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/output"
+)
+
+// heartbeatCmd represents the heartbeat command.
+var heartbeatCmd = &cobra.Command{
+	Use:   "heartbeat",
+	Short: "Record that the active timer is still running",
+	Long: `Record the current time as the active block's heartbeat.
+
+Run this regularly, from cron, a shell prompt hook or an editor plugin, and set
+heartbeat-interval to match. If the machine crashes while tracking, the status
+command then offers to close the block at its last heartbeat instead of
+counting the time since.
+
+Examples:
+  ht heartbeat
+  ht config set heartbeat-interval 5m`,
+	Args: cobra.NoArgs,
+	RunE: runHeartbeat,
+}
+
+func init() {
+	rootCmd.AddCommand(heartbeatCmd)
+}
+
+func runHeartbeat(cmd *cobra.Command, args []string) error {
+	block, err := ctx.ActiveBlockRepo.GetActiveBlock(ctx.BlockRepo)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		// Nothing to keep alive; stay quiet so hooks can run it blindly
+		ctx.Debugf("No active block for heartbeat")
+		return nil
+	}
+
+	now := time.Now()
+	if err := ctx.BlockRepo.Heartbeat(block.Key, now); err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status":    "heartbeat",
+			"block_key": block.Key,
+			"heartbeat": now,
+		})
+	}
+	return nil
+}
+
+// offerStaleHeartbeatClose asks whether to close an active block whose
+// heartbeat is stale at its last heartbeat. Returns true if it was closed.
+func offerStaleHeartbeatClose(block *model.Block) (bool, error) {
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if !block.StaleHeartbeat(now, config.HeartbeatInterval) {
+		return false, nil
+	}
+
+	confirmed, err := promptConfirmation(fmt.Sprintf(
+		"Tracking on %s last checked in %s ago, at %s. Close it then? (y/N): ",
+		block.ProjectSID, output.FormatDuration(now.Sub(block.Heartbeat)),
+		block.Heartbeat.Format("2006-01-02 15:04")))
+	if err != nil || !confirmed {
+		return false, err
+	}
+
+	block.TimestampEnd = block.Heartbeat
+	if err := ctx.BlockRepo.Stop(block); err != nil {
+		return false, err
+	}
+	if err := ctx.UndoRepo.SaveUndoStop(block); err != nil {
+		ctx.Debugf("Failed to save undo state: %v", err)
+	}
+	if err := ctx.ActiveBlockRepo.ClearActive(); err != nil {
+		return false, err
+	}
+
+	ctx.CLIFormatter().PrintTrackingStopped(block)
+	return true, nil
+}
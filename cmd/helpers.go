@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/runtime"
+	"github.com/manav03panchal/humantime/internal/storage"
+)
+
+// safetyBackup backs up the database before a destructive operation, if
+// safety backups are enabled.
+func safetyBackup(operation string) error {
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	if !config.SafetyBackups {
+		return nil
+	}
+
+	path, err := ctx.DB.SafetyBackup(config.BackupRetention())
+	if err != nil {
+		return fmt.Errorf("safety backup before %s failed: %w", operation, err)
+	}
+	if path != "" {
+		ctx.Debugf("Backed up database to %s before %s", path, operation)
+	}
+	return nil
+}
+
+// ensureProject returns the project, creating it unless auto-create is
+// turned off in the config. The bool reports whether it was created.
+func ensureProject(sid string) (*model.Project, bool, error) {
+	project, created, err := ctx.ProjectRepo.Ensure(sid, "")
+	if storage.IsErrKeyNotFound(err) {
+		return nil, false, fmt.Errorf("%w: %s (auto-create-projects is off; create it with 'ht project new %s')",
+			runtime.ErrProjectNotFound, sid, sid)
+	}
+	return project, created, err
+}
+
+// appendBlockNote appends a note to the block, enforcing the configured note
+// length limit, and adds the tags the auto-tag rules derive from it.
+func appendBlockNote(block *model.Block, note string) error {
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	if err := block.AppendNote(note, config.NoteLimit(), config.TruncateNotes); err != nil {
+		return err
+	}
+	for _, tag := range config.AutoTags(note) {
+		block.AddTag(tag)
+	}
+	return nil
+}
+
+// setBlockKind sets the block's kind after checking it against the
+// configured kinds.
+func setBlockKind(block *model.Block, kind string) error {
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	if !config.ValidKind(kind) {
+		return runtime.NewValidationError("kind", fmt.Sprintf("unknown kind %q (valid: %s)", kind, strings.Join(config.Kinds(), ", ")))
+	}
+	block.Kind = strings.ToLower(kind)
+	return nil
+}
+
+// reportLocation returns the time zone a report draws day and week
+// boundaries in: the project's own zone when the report covers a single
+// project that has one, and local time otherwise.
+func reportLocation(projectSID string) (*time.Location, error) {
+	if projectSID == "" {
+		return time.Local, nil
+	}
+	project, err := ctx.ProjectRepo.Get(projectSID)
+	if storage.IsErrKeyNotFound(err) {
+		return time.Local, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project.Location(time.Local), nil
+}
@@ -1,21 +1,36 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/manav03panchal/humantime/internal/model"
 	"github.com/manav03panchal/humantime/internal/output"
+	"github.com/manav03panchal/humantime/internal/parser"
+	"github.com/manav03panchal/humantime/internal/runtime"
+	"github.com/manav03panchal/humantime/internal/storage"
 )
 
 // Import command flags.
 var (
-	importFlagDryRun bool
-	importFlagForce  bool
+	importFlagDryRun    bool
+	importFlagCSV       bool
+	importFlagCSVMap    string
+	importFlagForce     bool
+	importFlagGitRepo   string
+	importFlagGitAuthor string
+	importFlagManifest  string
+	importFlagPreserve  bool
+	importFlagReplace   bool
+	importFlagOverlap   string
+	importFlagTimesheet string
 )
 
 // importCmd represents the import command.
@@ -28,7 +43,23 @@ var importCmd = &cobra.Command{
 Examples:
   ht import backup.json
   ht import backup.json --dry-run
-  ht import backup.json --force`,
+  ht import backup.json --force
+  ht import backup.json --manifest backup.json.manifest.json
+  ht import backup.json --preserve-keys
+  ht import backup.json --replace
+  ht import backup.json --overlap skip
+  ht import backup.json.gz
+
+Import git history as work sessions:
+  git log --format='%an%x09%aI%x09%s' > commits.txt
+  ht import commits.txt --git-repo myrepo --git-author "Jane Doe"
+
+Import CSV, renaming columns to project, task, start, end, date, note or tags:
+  ht import hours.csv
+  ht import hours.csv --csv-map "client=project,description=note"
+
+Import a day's timesheet, one "HH:MM-HH:MM project[/task] [note]" per line:
+  ht import monday.txt --timesheet "last monday"`,
 	Args: cobra.ExactArgs(1),
 	RunE: runImport,
 }
@@ -36,19 +67,19 @@ Examples:
 func init() {
 	importCmd.Flags().BoolVar(&importFlagDryRun, "dry-run", false, "Preview import without making changes")
 	importCmd.Flags().BoolVar(&importFlagForce, "force", false, "Overwrite existing data on conflicts")
+	importCmd.Flags().StringVar(&importFlagManifest, "manifest", "", "Verify FILE against this checksum manifest before importing")
+	importCmd.Flags().StringVar(&importFlagOverlap, "overlap", "allow", "Blocks overlapping existing ones: allow, skip or error")
+	importCmd.Flags().BoolVar(&importFlagReplace, "replace", false, "Replace all blocks with those of a humantime backup (asks first unless --force)")
+	importCmd.Flags().BoolVar(&importFlagPreserve, "preserve-keys", false, "Keep the original block keys of a humantime backup where they are free")
+	importCmd.Flags().StringVar(&importFlagGitRepo, "git-repo", "", "Treat FILE as git log output for this repository")
+	importCmd.Flags().StringVar(&importFlagTimesheet, "timesheet", "", "Treat FILE as a timesheet for this day (e.g. today, yesterday)")
+	importCmd.Flags().BoolVar(&importFlagCSV, "csv", false, "Treat FILE as CSV (implied by a .csv extension)")
+	importCmd.Flags().StringVar(&importFlagCSVMap, "csv-map", "", "Map CSV columns to fields, e.g. \"description=note,client=project\"")
+	importCmd.Flags().StringVar(&importFlagGitAuthor, "git-author", "", "Only import commits by this author (with --git-repo)")
 
 	rootCmd.AddCommand(importCmd)
 }
 
-// HumantimeBackup represents a Humantime backup (v2).
-type HumantimeBackup struct {
-	Version     string             `json:"version"`
-	ExportedAt  string             `json:"exported_at"`
-	Projects    []*model.Project   `json:"projects"`
-	Blocks      []*model.Block     `json:"blocks"`
-	ActiveBlock *model.ActiveBlock `json:"active_block"`
-}
-
 // ZeitEntry represents a Zeit v1 time entry.
 type ZeitEntry struct {
 	ID      string `json:"id"`
@@ -64,20 +95,51 @@ type ZeitExport struct {
 	Entries []ZeitEntry `json:"entries"`
 }
 
+// importOverlap is the parsed --overlap policy.
+var importOverlap storage.OverlapPolicy
+
 func runImport(cmd *cobra.Command, args []string) error {
 	filename := args[0]
 
+	policy, err := storage.ParseOverlapPolicy(importFlagOverlap)
+	if err != nil {
+		return runtime.NewValidationError("overlap", err.Error())
+	}
+	importOverlap = policy
+
 	// Read file
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Detect format
-	format := detectImportFormat(data)
+	// Accept gzipped exports transparently
+	data, err = storage.Decompress(data)
+	if err != nil {
+		return err
+	}
 
 	cli := ctx.CLIFormatter()
 
+	if importFlagManifest != "" {
+		if err := verifyImportManifest(data, importFlagManifest); err != nil {
+			return err
+		}
+	}
+
+	if importFlagGitRepo != "" {
+		return importGitLog(data, cli)
+	}
+	if importFlagTimesheet != "" {
+		return importTimesheet(data, cli)
+	}
+	if importFlagCSV || importFlagCSVMap != "" || strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return importCSV(data, cli)
+	}
+
+	// Detect format
+	format := detectImportFormat(data)
+
 	switch format {
 	case "humantime":
 		return importHumantime(data, cli)
@@ -88,9 +150,24 @@ func runImport(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// verifyImportManifest checks backup data against a manifest file.
+func verifyImportManifest(data []byte, path string) error {
+	manifestData, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest storage.BackupManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	return storage.VerifyBackup(data, &manifest)
+}
+
 func detectImportFormat(data []byte) string {
 	// Try to detect format by parsing
-	var backup HumantimeBackup
+	var backup storage.Backup
 	if err := json.Unmarshal(data, &backup); err == nil {
 		if backup.Version != "" && (backup.Projects != nil || backup.Blocks != nil) {
 			return "humantime"
@@ -117,7 +194,7 @@ func detectImportFormat(data []byte) string {
 }
 
 func importHumantime(data []byte, cli *output.CLIFormatter) error {
-	var backup HumantimeBackup
+	var backup storage.Backup
 	if err := json.Unmarshal(data, &backup); err != nil {
 		return fmt.Errorf("failed to parse backup: %w", err)
 	}
@@ -127,14 +204,46 @@ func importHumantime(data []byte, cli *output.CLIFormatter) error {
 		Projects   int
 		Blocks     int
 		Duplicates int
+		Future     int
+		Rekeyed    int
+		Overlaps   int
 	}{}
 
 	if importFlagDryRun {
 		cli.Title("Dry Run - Import Preview")
 	} else {
+		if importFlagReplace && !importFlagForce {
+			cli.Printf("This deletes every block and replaces them with the %d block(s) in the backup.\n", len(backup.Blocks))
+			confirmed, err := promptConfirmation("Replace all blocks? (y/N): ")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				cli.Muted("Cancelled")
+				return nil
+			}
+		}
+
+		// A forced or replacing import overwrites existing data
+		if importFlagReplace {
+			if err := safetyBackup("replacing import"); err != nil {
+				return err
+			}
+		} else if importFlagForce {
+			if err := safetyBackup("forced import"); err != nil {
+				return err
+			}
+		}
 		cli.Title("Importing Humantime Backup")
 	}
 
+	// Fail before writing anything if a block overlaps
+	if importOverlap == storage.OverlapError && !importFlagReplace && !importFlagDryRun {
+		if _, _, err := ctx.BlockRepo.FilterImportOverlaps(backup.Blocks, importOverlap); err != nil {
+			return err
+		}
+	}
+
 	// Import projects
 	for _, p := range backup.Projects {
 		if importFlagDryRun {
@@ -161,33 +270,106 @@ func importHumantime(data []byte, cli *output.CLIFormatter) error {
 	}
 
 	// Import blocks
+	now := time.Now()
+	if importFlagReplace && !importFlagDryRun {
+		for _, b := range backup.Blocks {
+			if b.IsFuture(now) {
+				stats.Future++
+			}
+		}
+		// The user confirmed above, or passed --force
+		if err := ctx.BlockRepo.ReplaceAll(backup.Blocks, storage.ReplaceOptions{Confirmed: true}); err != nil {
+			return fmt.Errorf("failed to replace blocks: %w", err)
+		}
+		stats.Blocks = len(backup.Blocks)
+		backup.Blocks = nil
+	}
+	// Blocks stored under a new key, by their key in the backup, and the
+	// imported blocks, so ContinuedFrom can follow the new keys
+	newKeys := make(map[string]string)
+	var imported []*model.Block
 	for _, b := range backup.Blocks {
+		if b.IsFuture(now) {
+			stats.Future++
+		}
+
 		if importFlagDryRun {
 			stats.Blocks++
 			continue
 		}
 
 		// Check for duplicate by key
-		_, err := ctx.BlockRepo.Get(b.Key)
-		if err == nil && !importFlagForce {
+		exists, err := ctx.BlockRepo.Exists(b.Key)
+		if err != nil {
+			return fmt.Errorf("failed to check block %s: %w", b.Key, err)
+		}
+
+		// With preserved keys, a different block under the same key is a
+		// collision rather than a duplicate
+		collision := false
+		if exists && importFlagPreserve && !importFlagForce {
+			existing, err := ctx.BlockRepo.Get(b.Key)
+			if err != nil {
+				return fmt.Errorf("failed to check block %s: %w", b.Key, err)
+			}
+			collision = !sameImportedBlock(existing, b)
+		}
+		if exists && !collision && !importFlagForce {
 			stats.Duplicates++
 			continue
 		}
 
-		if err == nil {
+		skip, err := ctx.BlockRepo.CheckImportOverlap(b, importOverlap)
+		if err != nil {
+			return err
+		}
+		if skip {
+			stats.Overlaps++
+			continue
+		}
+
+		originalKey := b.Key
+		if key, ok := newKeys[b.ContinuedFrom]; ok {
+			b.ContinuedFrom = key
+		}
+		switch {
+		case exists && !collision:
 			// Update existing
 			if err := ctx.BlockRepo.Update(b); err != nil {
 				return fmt.Errorf("failed to update block %s: %w", b.Key, err)
 			}
-		} else {
+		case importFlagPreserve:
+			rekeyed, err := ctx.BlockRepo.CreatePreservingKey(b)
+			if err != nil {
+				return fmt.Errorf("failed to create block %s: %w", originalKey, err)
+			}
+			if rekeyed {
+				ctx.Debugf("block %s imported as %s", originalKey, b.Key)
+				stats.Rekeyed++
+			}
+		default:
 			// Create new
 			if err := ctx.BlockRepo.Create(b); err != nil {
-				return fmt.Errorf("failed to create block %s: %w", b.Key, err)
+				return fmt.Errorf("failed to create block %s: %w", originalKey, err)
 			}
 		}
+		if originalKey != "" && b.Key != originalKey {
+			newKeys[originalKey] = b.Key
+		}
+		imported = append(imported, b)
 		stats.Blocks++
 	}
 
+	// Relink blocks imported before the block they continue
+	for _, b := range imported {
+		if key, ok := newKeys[b.ContinuedFrom]; ok {
+			b.ContinuedFrom = key
+			if err := ctx.BlockRepo.Update(b); err != nil {
+				return fmt.Errorf("failed to relink block %s: %w", b.Key, err)
+			}
+		}
+	}
+
 	// Print summary
 	cli.Println("")
 	if importFlagDryRun {
@@ -200,10 +382,211 @@ func importHumantime(data []byte, cli *output.CLIFormatter) error {
 	if stats.Duplicates > 0 {
 		cli.Printf("  Skipped (duplicates): %d\n", stats.Duplicates)
 	}
+	if stats.Overlaps > 0 {
+		cli.Printf("  Skipped (overlapping): %d\n", stats.Overlaps)
+	}
+	if stats.Rekeyed > 0 {
+		cli.Printf("  New keys (key already in use): %d\n", stats.Rekeyed)
+	}
+	printFutureWarning(cli, stats.Future)
 
 	return nil
 }
 
+// sameImportedBlock reports whether an imported block is the stored block
+// with the same key, rather than a different block that happens to share it.
+func sameImportedBlock(stored, imported *model.Block) bool {
+	return stored.ProjectSID == imported.ProjectSID && stored.TimestampStart.Equal(imported.TimestampStart)
+}
+
+// printFutureWarning warns about imported blocks that start in the future,
+// which usually indicates clock skew on the exporting machine.
+func printFutureWarning(cli *output.CLIFormatter, count int) {
+	if count > 0 {
+		cli.Warning(fmt.Sprintf("%d block(s) start in the future; the source clock may be skewed", count))
+	}
+}
+
+func importGitLog(data []byte, cli *output.CLIFormatter) error {
+	commits, err := storage.ParseGitLog(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse git log: %w", err)
+	}
+
+	projectSID := parser.NormalizeSID(importFlagGitRepo)
+	if projectSID == "" {
+		return runtime.NewValidationError("git-repo", "cannot derive a project from the repository name")
+	}
+
+	blocks, err := storage.GitCommitsToBlocks(commits, projectSID, storage.GitLogOptions{
+		Author: importFlagGitAuthor,
+	})
+	if err != nil {
+		return err
+	}
+
+	if importFlagDryRun {
+		cli.Title("Dry Run - Git Import Preview")
+		cli.Printf("Would import:\n")
+		cli.Printf("  Commits: %d\n", len(commits))
+		cli.Printf("  Blocks: %d\n", len(blocks))
+		return nil
+	}
+
+	cli.Title("Importing Git History")
+
+	blocks, skipped, err := ctx.BlockRepo.FilterImportOverlaps(blocks, importOverlap)
+	if err != nil {
+		return err
+	}
+	if len(blocks) > 0 {
+		if _, _, err := ensureProject(projectSID); err != nil {
+			return err
+		}
+		if err := ctx.BlockRepo.CreateBatch(blocks); err != nil {
+			return fmt.Errorf("failed to import blocks: %w", err)
+		}
+	}
+
+	cli.Println("")
+	cli.Success("Git import complete")
+	cli.Printf("  Commits: %d\n", len(commits))
+	cli.Printf("  Blocks: %d\n", len(blocks))
+	printOverlapSkips(cli, skipped)
+
+	return nil
+}
+
+// printOverlapSkips reports blocks left out for overlapping existing ones.
+func printOverlapSkips(cli *output.CLIFormatter, count int) {
+	if count > 0 {
+		cli.Printf("  Skipped (overlapping): %d\n", count)
+	}
+}
+
+func importTimesheet(data []byte, cli *output.CLIFormatter) error {
+	day := parser.ParseTimestamp(importFlagTimesheet)
+	if day.Error != nil {
+		return day.Error
+	}
+
+	blocks, err := parser.ParseTimesheet(string(data), day.Time, time.Local)
+	if err != nil {
+		return fmt.Errorf("failed to parse timesheet: %w", err)
+	}
+
+	if importFlagDryRun {
+		cli.Title("Dry Run - Timesheet Import Preview")
+		for _, b := range blocks {
+			cli.Printf("  %s - %s  %s\n",
+				b.TimestampStart.Format("15:04"),
+				b.TimestampEnd.Format("15:04"),
+				cli.FormatProjectTask(b.ProjectSID, b.TaskSID))
+		}
+		cli.Printf("Would import %d block(s)\n", len(blocks))
+		return nil
+	}
+
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if err := b.ValidateWithNoteLimit(config.NoteLimit()); err != nil {
+			return err
+		}
+	}
+	blocks, skipped, err := ctx.BlockRepo.FilterImportOverlaps(blocks, importOverlap)
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if _, _, err := ensureProject(b.ProjectSID); err != nil {
+			return err
+		}
+	}
+	if err := ctx.BlockRepo.CreateBatch(blocks); err != nil {
+		return fmt.Errorf("failed to import blocks: %w", err)
+	}
+
+	cli.Success(fmt.Sprintf("Imported %d block(s) for %s", len(blocks), day.Time.Format("2006-01-02")))
+	printOverlapSkips(cli, skipped)
+	return nil
+}
+
+func importCSV(data []byte, cli *output.CLIFormatter) error {
+	headerMap, err := parseCSVHeaderMap(importFlagCSVMap)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := storage.ParseCSVBlocks(bytes.NewReader(data), storage.CSVImportOptions{
+		HeaderMap: headerMap,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	for _, b := range blocks {
+		b.ProjectSID = parser.NormalizeSID(b.ProjectSID)
+		b.TaskSID = parser.NormalizeSID(b.TaskSID)
+	}
+
+	if importFlagDryRun {
+		cli.Title("Dry Run - CSV Import Preview")
+		for _, b := range blocks {
+			cli.Printf("  %s - %s  %s\n",
+				b.TimestampStart.Format("2006-01-02 15:04"),
+				b.TimestampEnd.Format("15:04"),
+				cli.FormatProjectTask(b.ProjectSID, b.TaskSID))
+		}
+		cli.Printf("Would import %d block(s)\n", len(blocks))
+		return nil
+	}
+
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if err := b.ValidateWithNoteLimit(config.NoteLimit()); err != nil {
+			return err
+		}
+	}
+	blocks, skipped, err := ctx.BlockRepo.FilterImportOverlaps(blocks, importOverlap)
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if _, _, err := ensureProject(b.ProjectSID); err != nil {
+			return err
+		}
+	}
+	if err := ctx.BlockRepo.CreateBatch(blocks); err != nil {
+		return fmt.Errorf("failed to import blocks: %w", err)
+	}
+
+	cli.Success(fmt.Sprintf("Imported %d block(s) from CSV", len(blocks)))
+	printOverlapSkips(cli, skipped)
+	return nil
+}
+
+// parseCSVHeaderMap parses "SOURCE=FIELD" pairs separated by commas.
+func parseCSVHeaderMap(value string) (map[string]string, error) {
+	headerMap := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		source, field, ok := strings.Cut(pair, "=")
+		source, field = strings.TrimSpace(source), strings.TrimSpace(field)
+		if !ok || source == "" || field == "" {
+			return nil, runtime.NewValidationError("csv-map", fmt.Sprintf("invalid mapping %q (use SOURCE=FIELD)", pair))
+		}
+		headerMap[source] = field
+	}
+	return headerMap, nil
+}
+
 func importZeit(data []byte, cli *output.CLIFormatter) error {
 	// Try parsing as object with entries array
 	var zeit ZeitExport
@@ -221,6 +604,7 @@ func importZeit(data []byte, cli *output.CLIFormatter) error {
 		Blocks   int
 		Skipped  int
 		Errors   int
+		Future   int
 	}{}
 
 	if importFlagDryRun {
@@ -229,9 +613,9 @@ func importZeit(data []byte, cli *output.CLIFormatter) error {
 		cli.Title("Importing Zeit Data")
 	}
 
-	// Track created projects
-	createdProjects := make(map[string]bool)
-
+	// Build every block first, so an overlap error leaves nothing written
+	var blocks []*model.Block
+	now := time.Now()
 	for _, entry := range zeit.Entries {
 		// Parse timestamps
 		begin, err := time.Parse(time.RFC3339, entry.Begin)
@@ -249,29 +633,15 @@ func importZeit(data []byte, cli *output.CLIFormatter) error {
 			}
 		}
 
-		// Create project if needed
+		if begin.After(now) {
+			stats.Future++
+		}
+
 		projectSID := entry.Project
 		if projectSID == "" {
 			projectSID = "imported"
 		}
 
-		if !createdProjects[projectSID] && !importFlagDryRun {
-			_, created, err := ctx.ProjectRepo.GetOrCreate(projectSID, projectSID)
-			if err != nil {
-				return fmt.Errorf("failed to create project %s: %w", projectSID, err)
-			}
-			if created {
-				stats.Projects++
-			}
-			createdProjects[projectSID] = true
-		}
-
-		// Create block
-		if importFlagDryRun {
-			stats.Blocks++
-			continue
-		}
-
 		// Combine task and notes into the note field
 		note := entry.Notes
 		if entry.Task != "" && note != "" {
@@ -284,12 +654,42 @@ func importZeit(data []byte, cli *output.CLIFormatter) error {
 		if !end.IsZero() {
 			block.TimestampEnd = end
 		}
+		blocks = append(blocks, block)
+	}
 
-		if err := ctx.BlockRepo.Create(block); err != nil {
-			stats.Errors++
-			continue
+	if importFlagDryRun {
+		stats.Blocks = len(blocks)
+	} else {
+		var err error
+		blocks, stats.Skipped, err = ctx.BlockRepo.FilterImportOverlaps(blocks, importOverlap)
+		if err != nil {
+			return err
+		}
+
+		// Ensure every project before writing blocks, as a missing one
+		// fails when auto-create is off
+		ensured := make(map[string]bool)
+		for _, block := range blocks {
+			if ensured[block.ProjectSID] {
+				continue
+			}
+			_, created, err := ensureProject(block.ProjectSID)
+			if err != nil {
+				return err
+			}
+			if created {
+				stats.Projects++
+			}
+			ensured[block.ProjectSID] = true
+		}
+
+		for _, block := range blocks {
+			if err := ctx.BlockRepo.Create(block); err != nil {
+				stats.Errors++
+				continue
+			}
+			stats.Blocks++
 		}
-		stats.Blocks++
 	}
 
 	// Print summary
@@ -301,9 +701,11 @@ func importZeit(data []byte, cli *output.CLIFormatter) error {
 	}
 	cli.Printf("  Projects: %d\n", stats.Projects)
 	cli.Printf("  Blocks: %d\n", stats.Blocks)
+	printOverlapSkips(cli, stats.Skipped)
 	if stats.Errors > 0 {
 		cli.Printf("  Errors: %d\n", stats.Errors)
 	}
+	printFutureWarning(cli, stats.Future)
 
 	return nil
 }
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/manav03panchal/humantime/internal/errors"
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/runtime"
+	"github.com/manav03panchal/humantime/internal/storage"
+)
+
+// resetImportFlags sets the import flags to their defaults for one test.
+func resetImportFlags(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		importFlagDryRun = false
+		importFlagForce = false
+		importFlagPreserve = false
+		importFlagReplace = false
+		importOverlap = storage.OverlapAllow
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// storeImportBlock stores a closed block on project from start for an hour.
+func storeImportBlock(t *testing.T, project string, start time.Time) *model.Block {
+	t.Helper()
+	b := model.NewBlock("", project, "", "", start)
+	b.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, ctx.BlockRepo.Create(b))
+	return b
+}
+
+// =============================================================================
+// Overlap Policy Tests
+// =============================================================================
+
+func TestImportZeitOverlapErrorWritesNothing(t *testing.T) {
+	setupTestContext(t)
+	resetImportFlags(t)
+	importOverlap = storage.OverlapError
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	storeImportBlock(t, "existing", start)
+
+	data, err := json.Marshal([]ZeitEntry{
+		{Begin: start.Add(-2 * time.Hour).Format(time.RFC3339), End: start.Add(-time.Hour).Format(time.RFC3339), Project: "clear"},
+		{Begin: start.Add(30 * time.Minute).Format(time.RFC3339), End: start.Add(2 * time.Hour).Format(time.RFC3339), Project: "late"},
+	})
+	require.NoError(t, err)
+
+	err = importZeit(data, ctx.CLIFormatter())
+	assert.ErrorIs(t, err, storage.ErrImportOverlap)
+
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	assert.Len(t, blocks, 1)
+	projects, err := ctx.ProjectRepo.List()
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestImportHumantimeOverlapErrorBeforeProjects(t *testing.T) {
+	setupTestContext(t)
+	resetImportFlags(t)
+	importFlagForce = true
+	importOverlap = storage.OverlapError
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	storeImportBlock(t, "existing", start)
+	require.NoError(t, ctx.ProjectRepo.Create(model.NewProject("existing", "Existing", "")))
+
+	overlapping := model.NewBlock("", "existing", "", "", start.Add(30*time.Minute))
+	overlapping.TimestampEnd = start.Add(2 * time.Hour)
+	data, err := json.Marshal(storage.Backup{
+		Projects: []*model.Project{model.NewProject("existing", "Renamed", "")},
+		Blocks:   []*model.Block{overlapping},
+	})
+	require.NoError(t, err)
+
+	err = importHumantime(data, ctx.CLIFormatter())
+	assert.ErrorIs(t, err, storage.ErrImportOverlap)
+
+	project, err := ctx.ProjectRepo.Get("existing")
+	require.NoError(t, err)
+	assert.Equal(t, "Existing", project.DisplayName)
+}
+
+// =============================================================================
+// Project Creation Tests
+// =============================================================================
+
+func TestImportZeitEnsuresProjects(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	data, err := json.Marshal([]ZeitEntry{
+		{Begin: start.Format(time.RFC3339), End: start.Add(time.Hour).Format(time.RFC3339), Project: "client-work"},
+	})
+	require.NoError(t, err)
+
+	t.Run("normalizes_display_name", func(t *testing.T) {
+		setupTestContext(t)
+		resetImportFlags(t)
+
+		require.NoError(t, importZeit(data, ctx.CLIFormatter()))
+		project, err := ctx.ProjectRepo.Get("client-work")
+		require.NoError(t, err)
+		assert.Equal(t, "Client Work", project.DisplayName)
+	})
+
+	t.Run("respects_auto_create_off", func(t *testing.T) {
+		setupTestContext(t)
+		resetImportFlags(t)
+		config, err := ctx.ConfigRepo.Get()
+		require.NoError(t, err)
+		config.NoAutoCreateProjects = true
+		require.NoError(t, ctx.ConfigRepo.Save(config))
+
+		err = importZeit(data, ctx.CLIFormatter())
+		assert.ErrorIs(t, err, runtime.ErrProjectNotFound)
+		blocks, err := ctx.BlockRepo.List()
+		require.NoError(t, err)
+		assert.Empty(t, blocks)
+	})
+}
+
+// =============================================================================
+// Preserved Key Tests
+// =============================================================================
+
+func TestImportHumantimeRekeyedChain(t *testing.T) {
+	setupTestContext(t)
+	resetImportFlags(t)
+	importFlagPreserve = true
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	taken := storeImportBlock(t, "stored", start.Add(-24*time.Hour))
+
+	// The first segment collides with the stored block's key; the second,
+	// listed first, continues it
+	first := model.NewBlock("", "work", "", "part one", start)
+	first.Key = taken.Key
+	first.TimestampEnd = start.Add(time.Hour)
+	second := model.NewBlock("", "work", "", "part two", start.Add(time.Hour))
+	second.Key = "block:0192f3a4-0000-7000-8000-000000000002"
+	second.TimestampEnd = start.Add(2 * time.Hour)
+	second.ContinuedFrom = first.Key
+	third := model.NewBlock("", "work", "", "part three", start.Add(2*time.Hour))
+	third.Key = "block:0192f3a4-0000-7000-8000-000000000003"
+	third.TimestampEnd = start.Add(3 * time.Hour)
+	third.ContinuedFrom = second.Key
+
+	data, err := json.Marshal(storage.Backup{Blocks: []*model.Block{second, first, third}})
+	require.NoError(t, err)
+	require.NoError(t, importHumantime(data, ctx.CLIFormatter()))
+
+	notes := make(map[string]*model.Block)
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	for _, b := range blocks {
+		notes[b.Note] = b
+	}
+	require.Contains(t, notes, "part one")
+	assert.NotEqual(t, taken.Key, notes["part one"].Key)
+	assert.Equal(t, notes["part one"].Key, notes["part two"].ContinuedFrom)
+	assert.Equal(t, second.Key, notes["part three"].ContinuedFrom)
+}
+
+func TestImportHumantimeNoteLimit(t *testing.T) {
+	setupTestContext(t)
+	resetImportFlags(t)
+	config, err := ctx.ConfigRepo.Get()
+	require.NoError(t, err)
+	config.MaxNoteLength = 10
+	require.NoError(t, ctx.ConfigRepo.Save(config))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	long := model.NewBlock("", "work", "", "a note over ten characters", start)
+	long.Key = "block:0192f3a4-0000-7000-8000-000000000010"
+	long.TimestampEnd = start.Add(time.Hour)
+
+	data, err := json.Marshal(storage.Backup{Blocks: []*model.Block{long}})
+	require.NoError(t, err)
+	err = importHumantime(data, ctx.CLIFormatter())
+	assert.ErrorIs(t, err, errs.ErrNoteTooLong)
+
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	assert.Empty(t, blocks)
+}
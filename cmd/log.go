@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/manav03panchal/humantime/internal/model"
 	"github.com/manav03panchal/humantime/internal/output"
 	"github.com/manav03panchal/humantime/internal/parser"
+	"github.com/manav03panchal/humantime/internal/runtime"
+	"github.com/manav03panchal/humantime/internal/storage"
 )
 
 // Log command flags.
@@ -17,6 +20,8 @@ var (
 	logFlagProject string
 	logFlagNote    string
 	logFlagTag     string
+	logFlagKind    string
+	logFlagStart   string
 )
 
 // logCmd represents the log command.
@@ -25,7 +30,8 @@ var logCmd = &cobra.Command{
 	Aliases: []string{"l", "add"},
 	Short:   "Log a completed time block",
 	Long: `Log a completed time block with a specified duration.
-Creates a block ending now (or at the specified time) with the given duration.
+Creates a block ending now (or at the specified time) with the given duration,
+or starting at --start.
 
 Duration formats:
   2h, 2hr, 2 hours     - 2 hours
@@ -37,7 +43,8 @@ Examples:
   ht log clientwork 30m "fixed login issue"
   ht log clientwork 2h --tag billable
   ht log clientwork 3h yesterday
-  ht log clientwork 2h "morning work" yesterday`,
+  ht log clientwork 2h "morning work" yesterday
+  ht log clientwork 2h --start 9am`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runLog,
 }
@@ -46,6 +53,8 @@ func init() {
 	logCmd.Flags().StringVarP(&logFlagProject, "project", "p", "", "Project SID (alternative to positional)")
 	logCmd.Flags().StringVarP(&logFlagNote, "note", "n", "", "Note for the block")
 	logCmd.Flags().StringVar(&logFlagTag, "tag", "", "Comma-separated tags (e.g., billable,urgent)")
+	logCmd.Flags().StringVar(&logFlagKind, "kind", "", "Block kind (e.g., meeting, deep-work, break)")
+	logCmd.Flags().StringVarP(&logFlagStart, "start", "s", "", "Start time; the block runs for DURATION from here")
 
 	logCmd.RegisterFlagCompletionFunc("project", completeProjects)
 
@@ -124,33 +133,51 @@ func runLog(cmd *cobra.Command, args []string) error {
 		note = logFlagNote
 	}
 
-	// Calculate start and end times
-	if endTime.IsZero() {
-		endTime = time.Now()
+	// Calculate the start; the block runs for the duration from it
+	var startTime time.Time
+	if logFlagStart != "" {
+		result := parser.ParseTimestamp(logFlagStart)
+		if result.Error != nil {
+			return result.Error
+		}
+		startTime = result.Time
+	} else {
+		if endTime.IsZero() {
+			endTime = time.Now()
+		}
+		startTime = endTime.Add(-durationResult.Duration)
 	}
-	startTime := endTime.Add(-durationResult.Duration)
 
-	// Ensure project exists (auto-create if needed)
-	_, _, err := ctx.ProjectRepo.GetOrCreate(projectSID, projectSID)
+	// Ensure project exists (auto-create if allowed)
+	_, _, err := ensureProject(projectSID)
 	if err != nil {
 		return err
 	}
 
 	// Create the block
-	block := model.NewBlock("", projectSID, "", note, startTime)
-	block.TimestampEnd = endTime
+	block := model.NewBlock("", projectSID, "", "", startTime)
+	if err := appendBlockNote(block, note); err != nil {
+		return err
+	}
 
 	// Add tags if specified
 	if logFlagTag != "" {
-		tags := strings.Split(logFlagTag, ",")
-		for i, tag := range tags {
-			tags[i] = strings.TrimSpace(tag)
+		for _, tag := range strings.Split(logFlagTag, ",") {
+			block.AddTag(strings.TrimSpace(tag))
+		}
+	}
+
+	if logFlagKind != "" {
+		if err := setBlockKind(block, logFlagKind); err != nil {
+			return err
 		}
-		block.Tags = tags
 	}
 
 	// Save the block
-	if err := ctx.BlockRepo.Create(block); err != nil {
+	if err := ctx.BlockRepo.CreateFromDuration(block, durationResult.Duration); err != nil {
+		if errors.Is(err, storage.ErrNonPositiveDuration) {
+			return fmt.Errorf("%w: %v", runtime.ErrInvalidDuration, err)
+		}
 		return err
 	}
 
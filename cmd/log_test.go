@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/runtime"
+)
+
+// =============================================================================
+// Log Tests
+// =============================================================================
+
+// setLogStart sets --start for the duration of the test.
+func setLogStart(t *testing.T, start string) {
+	old := logFlagStart
+	logFlagStart = start
+	t.Cleanup(func() { logFlagStart = old })
+}
+
+func TestRunLogStart(t *testing.T) {
+	setupTestContext(t)
+	setLogStart(t, "2025-03-10 09:00")
+
+	require.NoError(t, runLog(logCmd, []string{"work", "90m"}))
+
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, 9, blocks[0].TimestampStart.Hour())
+	assert.Equal(t, 90*time.Minute, blocks[0].Duration())
+}
+
+func TestRunLogNonPositiveDuration(t *testing.T) {
+	for name, start := range map[string]string{"ending_now": "", "with_start": "2025-03-10 09:00"} {
+		t.Run(name, func(t *testing.T) {
+			setupTestContext(t)
+			setLogStart(t, start)
+
+			err := runLog(logCmd, []string{"work", "-1h"})
+			assert.ErrorIs(t, err, runtime.ErrInvalidDuration)
+
+			blocks, err := ctx.BlockRepo.List()
+			require.NoError(t, err)
+			assert.Empty(t, blocks)
+		})
+	}
+}
@@ -25,17 +25,24 @@ Examples:
   ht projects
   ht project clientwork
   ht project new "Client Work"
-  ht project archive clientwork`,
+  ht project archive clientwork
+  ht project prune --dry-run`,
 	RunE: runProjectList,
 }
 
 // Project subcommand flags.
 var (
-	projectCreateFlagSID   string
-	projectCreateFlagColor string
-	projectEditFlagName    string
-	projectEditFlagColor   string
-	projectDeleteFlagForce bool
+	projectCreateFlagSID    string
+	projectCreateFlagColor  string
+	projectCreateFlagNote   string
+	projectCreateFlagTZ     string
+	projectEditFlagName     string
+	projectEditFlagColor    string
+	projectEditFlagNote     string
+	projectEditFlagTZ       string
+	projectDeleteFlagForce  bool
+	projectPruneFlagDryRun  bool
+	projectDupFlagThreshold float64
 )
 
 // projectCreateCmd creates a new project.
@@ -66,18 +73,56 @@ Examples:
 	RunE: runProjectArchive,
 }
 
+// projectPruneCmd deletes projects without any blocks.
+var projectPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete projects that have no time blocks",
+	Long: `Delete projects that have no time blocks, such as ones auto-created from a typo.
+Projects with blocks in the trash are kept.
+
+Examples:
+  ht project prune --dry-run
+  ht project prune`,
+	Args: cobra.NoArgs,
+	RunE: runProjectPrune,
+}
+
+// projectDuplicatesCmd suggests projects that may be duplicates.
+var projectDuplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Suggest projects that look like duplicates",
+	Long: `Suggest groups of projects whose names are nearly the same, such as
+"myproject" and "my-project". Nothing is changed.
+
+Examples:
+  ht project duplicates
+  ht project duplicates --threshold 0.9`,
+	Args: cobra.NoArgs,
+	RunE: runProjectDuplicates,
+}
+
 func init() {
 	// Create flags
 	projectCreateCmd.Flags().StringVarP(&projectCreateFlagSID, "sid", "s", "", "Custom SID (auto-generated if omitted)")
 	projectCreateCmd.Flags().StringVarP(&projectCreateFlagColor, "color", "c", "", "Hex color (#RRGGBB)")
+	projectCreateCmd.Flags().StringVar(&projectCreateFlagNote, "note-template", "", "Note used when starting without one")
+	projectCreateCmd.Flags().StringVar(&projectCreateFlagTZ, "timezone", "", "Time zone for this project's reports (e.g. Europe/Berlin)")
 
 	// Edit flags
 	projectEditCmd.Flags().StringVarP(&projectEditFlagName, "name", "n", "", "Update display name")
 	projectEditCmd.Flags().StringVarP(&projectEditFlagColor, "color", "c", "", "Update color")
+	projectEditCmd.Flags().StringVar(&projectEditFlagNote, "note-template", "", "Update note template (empty clears)")
+	projectEditCmd.Flags().StringVar(&projectEditFlagTZ, "timezone", "", "Update report time zone (empty clears)")
 
 	// Archive flags
 	projectDeleteCmd.Flags().BoolVar(&projectDeleteFlagForce, "force", false, "Skip confirmation prompt")
 
+	// Prune flags
+	projectPruneCmd.Flags().BoolVar(&projectPruneFlagDryRun, "dry-run", false, "List empty projects without deleting them")
+
+	// Duplicates flags
+	projectDuplicatesCmd.Flags().Float64Var(&projectDupFlagThreshold, "threshold", 0.8, "Minimum name similarity, from 0 to 1")
+
 	// Dynamic completion for projects
 	projectCmd.ValidArgsFunction = completeProjectArgs
 	projectEditCmd.ValidArgsFunction = completeProjectArgs
@@ -86,6 +131,8 @@ func init() {
 	projectCmd.AddCommand(projectCreateCmd)
 	projectCmd.AddCommand(projectEditCmd)
 	projectCmd.AddCommand(projectDeleteCmd)
+	projectCmd.AddCommand(projectPruneCmd)
+	projectCmd.AddCommand(projectDuplicatesCmd)
 	rootCmd.AddCommand(projectCmd)
 }
 
@@ -162,6 +209,9 @@ func showProject(sid string) error {
 	if project.Color != "" {
 		cli.Printf("  Color: %s\n", project.Color)
 	}
+	if project.Timezone != "" {
+		cli.Printf("  Timezone: %s\n", project.Timezone)
+	}
 	cli.Printf("  Total Time: %s\n", cli.Duration(output.FormatDuration(secondsToDuration(totalDuration))))
 	cli.Printf("  Blocks: %d\n", len(blocks))
 	cli.Println("")
@@ -206,6 +256,11 @@ func runProjectCreate(cmd *cobra.Command, args []string) error {
 		return runtime.ErrInvalidColor
 	}
 
+	// Validate time zone
+	if err := model.ValidateTimezone(projectCreateFlagTZ); err != nil {
+		return runtime.NewValidationError("timezone", err.Error())
+	}
+
 	// Check if project exists
 	exists, err := ctx.ProjectRepo.Exists(sid)
 	if err != nil {
@@ -217,6 +272,8 @@ func runProjectCreate(cmd *cobra.Command, args []string) error {
 
 	// Create project
 	project := model.NewProject(sid, displayName, projectCreateFlagColor)
+	project.NoteTemplate = projectCreateFlagNote
+	project.Timezone = projectCreateFlagTZ
 	if err := ctx.ProjectRepo.Create(project); err != nil {
 		return err
 	}
@@ -263,8 +320,21 @@ func runProjectEdit(cmd *cobra.Command, args []string) error {
 		updated = true
 	}
 
+	if cmd.Flags().Changed("note-template") {
+		project.NoteTemplate = projectEditFlagNote
+		updated = true
+	}
+
+	if cmd.Flags().Changed("timezone") {
+		if err := model.ValidateTimezone(projectEditFlagTZ); err != nil {
+			return runtime.NewValidationError("timezone", err.Error())
+		}
+		project.Timezone = projectEditFlagTZ
+		updated = true
+	}
+
 	if !updated {
-		return fmt.Errorf("no updates specified (use --name or --color)")
+		return fmt.Errorf("no updates specified (use --name, --color, --note-template or --timezone)")
 	}
 
 	// Save
@@ -282,6 +352,12 @@ func runProjectEdit(cmd *cobra.Command, args []string) error {
 	if project.Color != "" {
 		cli.Printf("  Color: %s\n", project.Color)
 	}
+	if project.NoteTemplate != "" {
+		cli.Printf("  Note Template: %s\n", project.NoteTemplate)
+	}
+	if project.Timezone != "" {
+		cli.Printf("  Timezone: %s\n", project.Timezone)
+	}
 
 	return nil
 }
@@ -345,6 +421,77 @@ func runProjectArchive(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runProjectPrune(cmd *cobra.Command, args []string) error {
+	var sids []string
+	var err error
+	if projectPruneFlagDryRun {
+		sids, err = storage.FindEmptyProjects(ctx.ProjectRepo, ctx.BlockRepo)
+	} else {
+		sids, err = storage.PruneEmptyProjects(ctx.ProjectRepo, ctx.BlockRepo)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		if sids == nil {
+			sids = []string{}
+		}
+		return ctx.Formatter.JSON(map[string]any{
+			"dry_run":  projectPruneFlagDryRun,
+			"projects": sids,
+		})
+	}
+
+	cli := ctx.CLIFormatter()
+	if len(sids) == 0 {
+		cli.Muted("No empty projects.")
+		return nil
+	}
+
+	if projectPruneFlagDryRun {
+		cli.Printf("Would delete %d empty project(s):\n", len(sids))
+	} else {
+		cli.Success(fmt.Sprintf("Deleted %d empty project(s):", len(sids)))
+	}
+	for _, sid := range sids {
+		cli.Printf("  %s\n", sid)
+	}
+	return nil
+}
+
+func runProjectDuplicates(cmd *cobra.Command, args []string) error {
+	if projectDupFlagThreshold <= 0 || projectDupFlagThreshold > 1 {
+		return runtime.NewValidationError("threshold", "must be greater than 0 and at most 1")
+	}
+
+	projects, err := ctx.ProjectRepo.List()
+	if err != nil {
+		return err
+	}
+	groups := storage.SuggestProjectMerges(projects, projectDupFlagThreshold)
+
+	if ctx.IsJSON() {
+		if groups == nil {
+			groups = [][]string{}
+		}
+		return ctx.Formatter.JSON(map[string]any{
+			"groups": groups,
+		})
+	}
+
+	cli := ctx.CLIFormatter()
+	if len(groups) == 0 {
+		cli.Muted("No likely duplicates.")
+		return nil
+	}
+	cli.Printf("Possible duplicates (%d group(s)):\n", len(groups))
+	for _, group := range groups {
+		cli.Printf("  %s\n", strings.Join(group, ", "))
+	}
+	return nil
+}
+
 func printProjectsCLI(projects []*model.Project, durations map[string]int64) error {
 	cli := ctx.CLIFormatter()
 
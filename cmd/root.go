@@ -73,6 +73,12 @@ Examples:
 			return nil
 		}
 
+		// Scripts reading JSON get the error envelope from Execute instead
+		// of usage text
+		if flagFormat == "json" {
+			cmd.SilenceUsage = true
+		}
+
 		// Parse format flag
 		var format output.Format
 		switch flagFormat {
@@ -132,13 +138,36 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return ctx.JSONFormatter().PrintStatus(block)
 	}
 
+	if block != nil {
+		closed, err := offerStaleHeartbeatClose(block)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return nil
+		}
+	}
+
 	ctx.CLIFormatter().PrintStatus(block)
 	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// With JSON output, a failure is also reported as a JSON error envelope.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if err != nil && flagFormat == "json" {
+		printJSONError(err)
+	}
+	return err
+}
+
+// printJSONError writes the JSON error envelope for err to standard output.
+func printJSONError(err error) {
+	formatter := output.NewFormatter()
+	formatter.Format = output.FormatJSON
+	resp := output.NewErrorResponse(runtime.ErrorCode(err), err, runtime.GetSuggestion(err))
+	_ = output.NewJSONFormatter(formatter).PrintErrorResponse(resp)
 }
 
 func init() {
@@ -173,7 +202,8 @@ var versionCmd = &cobra.Command{
 // Die prints an error and exits.
 func Die(err error) {
 	if ctx != nil && ctx.IsJSON() {
-		ctx.JSONFormatter().PrintError("error", err.Error(), runtime.GetSuggestion(err))
+		resp := output.NewErrorResponse(runtime.ErrorCode(err), err, runtime.GetSuggestion(err))
+		ctx.JSONFormatter().PrintErrorResponse(resp)
 	} else {
 		os.Stderr.WriteString("Error: " + runtime.FormatError(err) + "\n")
 	}
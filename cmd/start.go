@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/output"
 	"github.com/manav03panchal/humantime/internal/parser"
 	"github.com/manav03panchal/humantime/internal/runtime"
+	"github.com/manav03panchal/humantime/internal/storage"
 )
 
 // Start command flags.
@@ -18,6 +22,7 @@ var (
 	startFlagStart   string
 	startFlagEnd     string
 	startFlagTag     string
+	startFlagKind    string
 )
 
 // startCmd represents the start command.
@@ -40,9 +45,35 @@ Examples:
 var resumeCmd = &cobra.Command{
 	Use:   "resume",
 	Short: "Resume tracking on the last project/task",
-	RunE:  runResume,
+	Long: `Resume tracking on the last project/task.
+
+If idle-after is configured and the break was at least that long, resume asks
+whether to count the break toward the resumed block.
+
+Examples:
+  ht start resume
+  ht start resume --idle claim
+  ht start resume --idle skip`,
+	RunE: runResume,
 }
 
+// continueCmd represents the start continue command.
+var continueCmd = &cobra.Command{
+	Use:   "continue",
+	Short: "End the current block now and keep tracking in a new one",
+	Long: `End the active block now and start a new block on the same project and
+task, with the same note and tags. The new block is linked to the one it
+continues, so both show up as one session.
+
+Examples:
+  ht start continue`,
+	Args: cobra.NoArgs,
+	RunE: runContinue,
+}
+
+// Resume command flags.
+var resumeFlagIdle string
+
 func init() {
 	// Start flags
 	startCmd.Flags().StringVarP(&startFlagProject, "project", "p", "", "Project SID")
@@ -50,13 +81,18 @@ func init() {
 	startCmd.Flags().StringVarP(&startFlagStart, "start", "s", "", "Start timestamp")
 	startCmd.Flags().StringVarP(&startFlagEnd, "end", "e", "", "End timestamp (creates completed block)")
 	startCmd.Flags().StringVar(&startFlagTag, "tag", "", "Comma-separated tags (e.g., billable,urgent)")
+	startCmd.Flags().StringVar(&startFlagKind, "kind", "", "Block kind (e.g., meeting, deep-work, break)")
 
 	// Dynamic completion for projects
 	startCmd.ValidArgsFunction = completeStartArgs
 	startCmd.RegisterFlagCompletionFunc("project", completeProjects)
 
-	// Add resume as subcommand
+	// Resume flags
+	resumeCmd.Flags().StringVar(&resumeFlagIdle, "idle", "ask", "Idle gap handling: ask, claim, skip")
+
+	// Add resume and continue as subcommands
 	startCmd.AddCommand(resumeCmd)
+	startCmd.AddCommand(continueCmd)
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
@@ -86,43 +122,41 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return runtime.ErrEndBeforeStart
 	}
 
-	// Ensure project exists (auto-create if needed)
-	_, _, err := ctx.ProjectRepo.GetOrCreate(parsed.ProjectSID, parsed.ProjectSID)
-	if err != nil {
-		return err
-	}
-
-	// End any active tracking first
-	var previousBlock *model.Block
-	activeBlock, err := ctx.ActiveBlockRepo.GetActiveBlock(ctx.BlockRepo)
-	if err != nil {
-		return err
-	}
-	if activeBlock != nil {
-		// End the current block
-		activeBlock.TimestampEnd = parsed.TimestampStart
-		if err := ctx.BlockRepo.Update(activeBlock); err != nil {
-			return err
-		}
-		previousBlock = activeBlock
-	}
-
-	// Create new block
+	// Create new block, seeding the note from the project's template
 	block := model.NewBlock(
 		"",
 		parsed.ProjectSID,
 		"",
-		parsed.Note,
+		"",
 		parsed.TimestampStart,
 	)
+	note := parsed.Note
+	if project, err := ctx.ProjectRepo.Get(parsed.ProjectSID); err == nil {
+		note = project.StartNote(note)
+	} else if !storage.IsErrKeyNotFound(err) {
+		return err
+	}
+	if err := appendBlockNote(block, note); err != nil {
+		return err
+	}
 
 	// Add tags if specified
 	if startFlagTag != "" {
-		tags := strings.Split(startFlagTag, ",")
-		for i, tag := range tags {
-			tags[i] = strings.TrimSpace(tag)
+		for _, tag := range strings.Split(startFlagTag, ",") {
+			block.AddTag(strings.TrimSpace(tag))
 		}
-		block.Tags = tags
+	}
+
+	if startFlagKind != "" {
+		if err := setBlockKind(block, startFlagKind); err != nil {
+			return err
+		}
+	}
+
+	// Ensure project exists (auto-create if allowed)
+	_, _, err := ensureProject(parsed.ProjectSID)
+	if err != nil {
+		return err
 	}
 
 	// If end time specified, create completed block
@@ -130,10 +164,15 @@ func runStart(cmd *cobra.Command, args []string) error {
 		block.TimestampEnd = parsed.TimestampEnd
 	}
 
-	// Save the block
-	if err := ctx.BlockRepo.Create(block); err != nil {
+	// Save the block, ending any active tracking in the same transaction
+	var previousBlock *model.Block
+	stopped, err := ctx.BlockRepo.StartExclusive(block)
+	if err != nil {
 		return err
 	}
+	if len(stopped) > 0 {
+		previousBlock = stopped[len(stopped)-1]
+	}
 
 	// Save undo state
 	if err := ctx.UndoRepo.SaveUndoStart(block.Key); err != nil {
@@ -141,18 +180,6 @@ func runStart(cmd *cobra.Command, args []string) error {
 		ctx.Debugf("Failed to save undo state: %v", err)
 	}
 
-	// Update active tracking (only if no end time)
-	if parsed.TimestampEnd.IsZero() {
-		if err := ctx.ActiveBlockRepo.SetActive(block.Key); err != nil {
-			return err
-		}
-	} else {
-		// Clear active tracking since this is a completed block
-		if err := ctx.ActiveBlockRepo.ClearActive(); err != nil {
-			return err
-		}
-	}
-
 	// Output result
 	if ctx.IsJSON() {
 		return ctx.JSONFormatter().PrintStart(block, previousBlock)
@@ -176,35 +203,114 @@ func runResume(cmd *cobra.Command, args []string) error {
 		return runtime.NewValidationError("resume", "no previous tracking to resume")
 	}
 
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return err
+	}
+
 	// Start tracking on the same project
 	block := model.NewBlock(
 		"",
 		previousBlock.ProjectSID,
 		"",
 		"", // No note for resumed blocks
-		time.Now(),
+		config.SnapResume(time.Now()),
 	)
+	block.ContinuedFrom = previousBlock.Key
 
-	if err := ctx.BlockRepo.Create(block); err != nil {
+	gap, err := resumeIdleGap(previousBlock, block.TimestampStart)
+	if err != nil {
 		return err
 	}
+	storage.ReallocateIdleGap(block, gap)
+
+	// Save the block, ending any active tracking in the same transaction
+	var stoppedBlock *model.Block
+	stopped, err := ctx.BlockRepo.StartExclusive(block)
+	if err != nil {
+		return err
+	}
+	if len(stopped) > 0 {
+		stoppedBlock = stopped[len(stopped)-1]
+	}
 
 	// Save undo state
 	if err := ctx.UndoRepo.SaveUndoStart(block.Key); err != nil {
 		ctx.Debugf("Failed to save undo state: %v", err)
 	}
 
-	if err := ctx.ActiveBlockRepo.SetActive(block.Key); err != nil {
+	// Output result
+	if ctx.IsJSON() {
+		return ctx.JSONFormatter().PrintStart(block, stoppedBlock)
+	}
+
+	cli := ctx.CLIFormatter()
+	if stoppedBlock != nil {
+		cli.Muted("Stopped previous tracking: " + stoppedBlock.ProjectSID)
+	}
+	cli.Printf("Resumed tracking on %s\n", cli.ProjectName(block.ProjectSID))
+	cli.Printf("  Started: %s\n", block.TimestampStart.Format("2006-01-02 15:04:05"))
+	if gap > 0 {
+		cli.Muted("Included " + output.FormatDuration(gap) + " of idle time")
+	}
+	return nil
+}
+
+func runContinue(cmd *cobra.Command, args []string) error {
+	block, err := ctx.BlockRepo.Continue(time.Now())
+	if errors.Is(err, storage.ErrNoActiveBlock) {
+		return runtime.ErrNoActiveTracking
+	}
+	if errors.Is(err, storage.ErrSplitOutsideBlock) {
+		return runtime.NewValidationError("continue", "the active block has not started yet")
+	}
+	if err != nil {
 		return err
 	}
 
-	// Output result
+	if err := ctx.UndoRepo.SaveUndoSplit(block.Key); err != nil {
+		ctx.Debugf("Failed to save undo state: %v", err)
+	}
+
 	if ctx.IsJSON() {
 		return ctx.JSONFormatter().PrintStart(block, nil)
 	}
 
 	cli := ctx.CLIFormatter()
-	cli.Printf("Resumed tracking on %s\n", cli.ProjectName(block.ProjectSID))
+	cli.Printf("Continued tracking on %s\n", cli.ProjectName(block.ProjectSID))
 	cli.Printf("  Started: %s\n", block.TimestampStart.Format("2006-01-02 15:04:05"))
 	return nil
 }
+
+// resumeIdleGap returns how much idle time before resumeAt to attribute to
+// the resumed block, based on the idle-after setting and the --idle flag.
+func resumeIdleGap(previous *model.Block, resumeAt time.Time) (time.Duration, error) {
+	switch resumeFlagIdle {
+	case "ask", "claim", "skip":
+	default:
+		return 0, runtime.NewValidationError("idle", "must be one of ask, claim, skip")
+	}
+
+	config, err := ctx.ConfigRepo.Get()
+	if err != nil {
+		return 0, err
+	}
+	gap := storage.IdleGap(previous, resumeAt, config.IdleAfter)
+	if gap == 0 || resumeFlagIdle == "skip" {
+		return 0, nil
+	}
+	if resumeFlagIdle == "claim" {
+		return gap, nil
+	}
+
+	// Ask, but never block JSON output on a prompt
+	if ctx.IsJSON() {
+		return 0, nil
+	}
+	confirmed, err := promptConfirmation(fmt.Sprintf("You were away for %s. Count it toward %s? (y/N): ",
+		output.FormatDuration(gap), previous.ProjectSID))
+	if err != nil || !confirmed {
+		return 0, nil
+	}
+	return gap, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// =============================================================================
+// Resume Tests
+// =============================================================================
+
+func TestRunResumeWhileTracking(t *testing.T) {
+	out := setupTestContext(t)
+	resumeFlagIdle = "skip"
+
+	start := time.Now().Add(-2 * time.Hour)
+	first := model.NewBlock("", "alpha", "", "", start)
+	_, err := ctx.BlockRepo.StartExclusive(first)
+	require.NoError(t, err)
+	second := model.NewBlock("", "beta", "", "", start.Add(time.Hour))
+	_, err = ctx.BlockRepo.StartExclusive(second)
+	require.NoError(t, err)
+
+	require.NoError(t, runResume(resumeCmd, nil))
+
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	var running []*model.Block
+	for _, b := range blocks {
+		if b.IsActive() {
+			running = append(running, b)
+		}
+	}
+	require.Len(t, running, 1, "only the resumed block may be running")
+	assert.Equal(t, "alpha", running[0].ProjectSID)
+	assert.Equal(t, first.Key, running[0].ContinuedFrom)
+
+	active, err := ctx.ActiveBlockRepo.GetActiveBlock(ctx.BlockRepo)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, running[0].Key, active.Key)
+
+	stopped, err := ctx.BlockRepo.Get(second.Key)
+	require.NoError(t, err)
+	assert.False(t, stopped.IsActive())
+	assert.Contains(t, out.String(), "Stopped previous tracking: beta")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/manav03panchal/humantime/internal/model"
 	"github.com/manav03panchal/humantime/internal/output"
 	"github.com/manav03panchal/humantime/internal/parser"
+	"github.com/manav03panchal/humantime/internal/runtime"
 	"github.com/manav03panchal/humantime/internal/storage"
 )
 
@@ -21,6 +23,10 @@ var (
 	statsFlagUntil   string
 	statsFlagGroup   string
 	statsFlagTag     string
+	statsFlagNote    string
+	statsFlagWeekly  bool
+	statsFlagRound   string
+	statsFlagRoundBy string
 )
 
 // statsCmd represents the stats command.
@@ -35,7 +41,10 @@ Examples:
   humantime stats today
   humantime stats this week
   humantime stats on clientwork from last month
-  humantime stats on clientwork/bugfix this quarter`,
+  humantime stats on clientwork/bugfix this quarter
+  humantime stats this week --note-group 'TICKET-\d+'
+  humantime stats last week --weekly-summary
+  humantime stats this week --round 15m --round-mode up`,
 	RunE: runStats,
 }
 
@@ -46,6 +55,10 @@ func init() {
 	statsCmd.Flags().StringVar(&statsFlagUntil, "until", "", "End of time range")
 	statsCmd.Flags().StringVarP(&statsFlagGroup, "group", "g", "auto", "Grouping: day, week, month, auto")
 	statsCmd.Flags().StringVar(&statsFlagTag, "tag", "", "Filter by tag")
+	statsCmd.Flags().StringVar(&statsFlagNote, "note-group", "", "Also total by a regex match in notes (first capture group if any)")
+	statsCmd.Flags().BoolVar(&statsFlagWeekly, "weekly-summary", false, "Summarize the whole week by day and project (plain text for pasting into email, or JSON)")
+	statsCmd.Flags().StringVar(&statsFlagRound, "round", "", "Round each project's total to a multiple of this duration (e.g. 15m)")
+	statsCmd.Flags().StringVar(&statsFlagRoundBy, "round-mode", "", "Rounding mode for --round: nearest, up, down (default nearest)")
 
 	// Dynamic completion for projects/tasks
 	statsCmd.ValidArgsFunction = completeBlocksArgs
@@ -59,6 +72,12 @@ func runStats(cmd *cobra.Command, args []string) error {
 	parsed := parser.Parse(args)
 	parsed.Merge(statsFlagProject, statsFlagTask, "", statsFlagFrom, statsFlagUntil)
 
+	// A single project's report follows its time zone
+	loc, err := reportLocation(parsed.ProjectSID)
+	if err != nil {
+		return err
+	}
+
 	// Default to today if no time range specified
 	var timeRange parser.TimeRange
 	if len(args) > 0 && !parsed.HasProject {
@@ -81,12 +100,18 @@ func runStats(cmd *cobra.Command, args []string) error {
 			}
 		} else {
 			// Default to today
-			now := time.Now()
-			timeRange.Start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			now := time.Now().In(loc)
+			timeRange.Start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 			timeRange.End = timeRange.Start.AddDate(0, 0, 1)
 		}
 	}
 
+	// A weekly summary covers the whole week the range starts in
+	if statsFlagWeekly {
+		week := storage.WeekRange(timeRange.Start, loc)
+		timeRange.Start, timeRange.End = week.Start, week.End
+	}
+
 	// Build filter
 	filter := storage.BlockFilter{
 		ProjectSID: parsed.ProjectSID,
@@ -94,6 +119,7 @@ func runStats(cmd *cobra.Command, args []string) error {
 		Tag:        statsFlagTag,
 		StartAfter: timeRange.Start,
 		EndBefore:  timeRange.End,
+		Limit:      storage.NoLimit,
 	}
 
 	// Get blocks
@@ -102,14 +128,76 @@ func runStats(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if statsFlagWeekly {
+		report := storage.WeeklySummary(blocks, timeRange.Start, time.Now(), loc)
+		if ctx.IsJSON() {
+			return printWeeklySummaryJSON(report)
+		}
+		return storage.RenderWeeklySummary(report, cmd.OutOrStdout())
+	}
+
 	// Calculate aggregates
-	projectAggs := storage.AggregateByProject(blocks)
+	rounding, err := statsRounding()
+	if err != nil {
+		return err
+	}
+	projectAggs := storage.RoundProjects(storage.AggregateByProject(blocks), rounding)
+	var noteGroups []storage.NoteGroupAggregate
+	if statsFlagNote != "" {
+		pattern, err := regexp.Compile(statsFlagNote)
+		if err != nil {
+			return runtime.NewValidationError("note-group", fmt.Sprintf("invalid pattern: %v", err))
+		}
+		noteGroups = storage.AggregateByNotePattern(blocks, pattern)
+	}
 
 	if ctx.IsJSON() {
-		return printStatsJSON(blocks, projectAggs, timeRange)
+		return printStatsJSON(blocks, projectAggs, noteGroups, timeRange)
 	}
 
-	return printStatsCLI(blocks, projectAggs, timeRange)
+	if err := printStatsCLI(blocks, projectAggs, timeRange); err != nil {
+		return err
+	}
+	if len(noteGroups) > 0 {
+		printNoteGroupsCLI(noteGroups)
+	}
+	return nil
+}
+
+// statsRounding returns the rounding asked for with --round and --round-mode.
+func statsRounding() (storage.Rounding, error) {
+	mode, err := storage.ParseRoundingMode(statsFlagRoundBy)
+	if err != nil {
+		return storage.Rounding{}, runtime.NewValidationError("round-mode", err.Error())
+	}
+	if statsFlagRound == "" {
+		return storage.Rounding{Mode: mode}, nil
+	}
+	increment := parser.ParseDuration(statsFlagRound)
+	if !increment.Valid || increment.Duration <= 0 {
+		return storage.Rounding{}, runtime.NewValidationError("round", fmt.Sprintf("invalid duration %q", statsFlagRound))
+	}
+	return storage.Rounding{Increment: increment.Duration, Mode: mode}, nil
+}
+
+// printNoteGroupsCLI prints the totals grouped by note pattern.
+func printNoteGroupsCLI(groups []storage.NoteGroupAggregate) {
+	cli := ctx.CLIFormatter()
+
+	width := 12
+	for _, g := range groups {
+		if len(g.Group) > width {
+			width = len(g.Group)
+		}
+	}
+
+	cli.Println("")
+	cli.Println("By Note Pattern:")
+	cli.Println("")
+	for _, g := range groups {
+		cli.Printf("  %-*s  %8s  %d block(s)\n", width, g.Group,
+			cli.Duration(output.FormatDuration(g.Duration)), g.BlockCount)
+	}
 }
 
 func printStatsCLI(blocks []*model.Block, projectAggs []storage.ProjectAggregate, timeRange parser.TimeRange) error {
@@ -170,7 +258,7 @@ func printStatsCLI(blocks []*model.Block, projectAggs []storage.ProjectAggregate
 	return nil
 }
 
-func printStatsJSON(blocks []*model.Block, projectAggs []storage.ProjectAggregate, timeRange parser.TimeRange) error {
+func printStatsJSON(blocks []*model.Block, projectAggs []storage.ProjectAggregate, noteGroups []storage.NoteGroupAggregate, timeRange parser.TimeRange) error {
 	// Calculate total duration
 	var totalDuration time.Duration
 	for _, agg := range projectAggs {
@@ -199,6 +287,53 @@ func printStatsJSON(blocks []*model.Block, projectAggs []storage.ProjectAggregat
 		}
 	}
 
+	for _, g := range noteGroups {
+		resp.Summary.ByNoteGroup = append(resp.Summary.ByNoteGroup, &output.NoteGroupOutput{
+			Group:           g.Group,
+			DurationSeconds: int64(g.Duration.Seconds()),
+			BlockCount:      g.BlockCount,
+		})
+	}
+
+	return ctx.Formatter.JSON(resp)
+}
+
+// printWeeklySummaryJSON prints a weekly summary in the stats response
+// shape, with one group per day.
+func printWeeklySummaryJSON(report storage.WeeklyReport) error {
+	resp := output.StatsResponse{
+		Period: &output.PeriodOutput{
+			Start:    report.Week.Start.Format(time.RFC3339),
+			End:      report.Week.End.Format(time.RFC3339),
+			Grouping: "day",
+		},
+		Summary: &output.SummaryOutput{
+			TotalDurationSeconds: int64(report.Total.Seconds()),
+			ByProject:            make([]*output.ProjectSummaryOutput, len(report.Projects)),
+		},
+		Groups: make([]*output.GroupOutput, len(report.Days)),
+	}
+
+	for i, p := range report.Projects {
+		var percentage float64
+		if report.Total > 0 {
+			percentage = float64(p.Duration) / float64(report.Total) * 100
+		}
+		resp.Summary.ByProject[i] = &output.ProjectSummaryOutput{
+			ProjectSID:      p.ProjectSID,
+			DurationSeconds: int64(p.Duration.Seconds()),
+			Percentage:      percentage,
+		}
+	}
+	for i, day := range report.Days {
+		resp.Groups[i] = &output.GroupOutput{
+			Label:           day.Date.Format("2006-01-02"),
+			Start:           day.Date.Format(time.RFC3339),
+			End:             day.Date.AddDate(0, 0, 1).Format(time.RFC3339),
+			DurationSeconds: int64(day.Duration.Seconds()),
+		}
+	}
+
 	return ctx.Formatter.JSON(resp)
 }
 
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/manav03panchal/humantime/internal/output"
+	"github.com/manav03panchal/humantime/internal/runtime"
+)
+
+// =============================================================================
+// Project Time Zone Tests
+// =============================================================================
+
+func TestReportLocation(t *testing.T) {
+	setupTestContext(t)
+	tokyo := model.NewProject("tokyo", "Tokyo", "")
+	tokyo.Timezone = "Asia/Tokyo"
+	require.NoError(t, ctx.ProjectRepo.Create(tokyo))
+	require.NoError(t, ctx.ProjectRepo.Create(model.NewProject("plain", "Plain", "")))
+
+	for sid, want := range map[string]string{
+		"tokyo":   "Asia/Tokyo",
+		"plain":   time.Local.String(),
+		"missing": time.Local.String(),
+		"":        time.Local.String(),
+	} {
+		loc, err := reportLocation(sid)
+		require.NoError(t, err)
+		assert.Equal(t, want, loc.String(), sid)
+	}
+}
+
+func TestRunStatsWeeklySummaryProjectZone(t *testing.T) {
+	setupTestContext(t)
+	tokyo := model.NewProject("tokyo", "Tokyo", "")
+	tokyo.Timezone = "Asia/Tokyo"
+	require.NoError(t, ctx.ProjectRepo.Create(tokyo))
+
+	// Sunday night in UTC is Monday morning in Tokyo
+	start := time.Date(2025, 3, 9, 23, 30, 0, 0, time.UTC)
+	b := model.NewBlock("", "tokyo", "", "", start)
+	b.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, ctx.BlockRepo.Create(b))
+
+	statsFlagProject, statsFlagWeekly = "tokyo", true
+	t.Cleanup(func() { statsFlagProject, statsFlagWeekly = "", false })
+	var out bytes.Buffer
+	statsCmd.SetOut(&out)
+	t.Cleanup(func() { statsCmd.SetOut(nil) })
+
+	require.NoError(t, runStats(statsCmd, []string{"2025-03-12"}))
+	assert.Contains(t, out.String(), "Week of Mon 10 Mar 2025")
+	assert.Regexp(t, `Mon 10 Mar\s+1h 00m`, out.String())
+}
+
+// =============================================================================
+// Rounding Tests
+// =============================================================================
+
+func TestRunStatsRounding(t *testing.T) {
+	t.Run("rounds_project_totals", func(t *testing.T) {
+		out := setupTestContext(t)
+		start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+		b := model.NewBlock("", "work", "", "", start)
+		b.TimestampEnd = start.Add(10 * time.Minute)
+		require.NoError(t, ctx.BlockRepo.Create(b))
+
+		statsFlagRound, statsFlagRoundBy = "15m", "up"
+		t.Cleanup(func() { statsFlagRound, statsFlagRoundBy = "", "" })
+
+		require.NoError(t, runStats(statsCmd, []string{"from", "2025-03-10"}))
+		assert.Contains(t, out.String(), output.FormatDuration(15*time.Minute))
+		assert.NotContains(t, out.String(), output.FormatDuration(10*time.Minute))
+	})
+
+	t.Run("rejects_unknown_mode", func(t *testing.T) {
+		setupTestContext(t)
+		statsFlagRound, statsFlagRoundBy = "15m", "ceiling"
+		t.Cleanup(func() { statsFlagRound, statsFlagRoundBy = "", "" })
+
+		var validationErr *runtime.ValidationError
+		assert.ErrorAs(t, runStats(statsCmd, nil), &validationErr)
+	})
+}
+
+func TestRunStatsWeeklySummaryJSON(t *testing.T) {
+	out := setupTestContext(t)
+	ctx.Formatter.Format = output.FormatJSON
+
+	start := time.Date(2025, 3, 11, 9, 0, 0, 0, time.Local)
+	b := model.NewBlock("", "work", "", "", start)
+	b.TimestampEnd = start.Add(90 * time.Minute)
+	require.NoError(t, ctx.BlockRepo.Create(b))
+
+	statsFlagWeekly = true
+	t.Cleanup(func() { statsFlagWeekly = false })
+
+	require.NoError(t, runStats(statsCmd, []string{"2025-03-12"}))
+
+	var resp output.StatsResponse
+	require.NoError(t, json.Unmarshal(out.Bytes(), &resp))
+	assert.Equal(t, int64(5400), resp.Summary.TotalDurationSeconds)
+	require.Len(t, resp.Summary.ByProject, 1)
+	assert.Equal(t, "work", resp.Summary.ByProject[0].ProjectSID)
+	require.Len(t, resp.Groups, 7)
+	assert.Equal(t, "2025-03-10", resp.Groups[0].Label)
+	assert.Equal(t, int64(5400), resp.Groups[1].DurationSeconds)
+}
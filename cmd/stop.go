@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -43,6 +44,13 @@ func runStop(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	if block == nil {
+		config, err := ctx.ConfigRepo.Get()
+		if err != nil {
+			return err
+		}
+		if config.ErrorOnNoActiveStop {
+			return runtime.ErrNoActiveTracking
+		}
 		if ctx.IsJSON() {
 			return ctx.JSONFormatter().PrintError("no_active_tracking", "No active tracking to stop", "")
 		}
@@ -76,15 +84,14 @@ func runStop(cmd *cobra.Command, args []string) error {
 
 	// Update note if provided
 	if parsed.HasNote {
-		if block.Note != "" {
-			block.Note += " - " + parsed.Note
-		} else {
-			block.Note = parsed.Note
+		if err := appendBlockNote(block, parsed.Note); err != nil {
+			return err
 		}
 	}
 
-	// Save the block
-	if err := ctx.BlockRepo.Update(block); err != nil {
+	// Save the block (may round the end time up to the minimum tracking unit)
+	requestedEnd := block.TimestampEnd
+	if err := ctx.BlockRepo.Stop(block); err != nil {
 		return err
 	}
 
@@ -104,6 +111,10 @@ func runStop(cmd *cobra.Command, args []string) error {
 		return ctx.JSONFormatter().PrintStop(block)
 	}
 
-	ctx.CLIFormatter().PrintTrackingStopped(block)
+	cli := ctx.CLIFormatter()
+	cli.PrintTrackingStopped(block)
+	if block.TimestampEnd.After(requestedEnd) {
+		cli.Muted(fmt.Sprintf("End rounded up by %s to the minimum tracking unit", block.TimestampEnd.Sub(requestedEnd).Round(time.Second)))
+	}
 	return nil
 }
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/runtime"
+)
+
+// =============================================================================
+// Stop Without Active Tracking Tests
+// =============================================================================
+
+func TestRunStopWithoutActiveTracking(t *testing.T) {
+	t.Run("no_op_by_default", func(t *testing.T) {
+		out := setupTestContext(t)
+
+		require.NoError(t, runStop(stopCmd, nil))
+		assert.Contains(t, out.String(), "No active tracking")
+	})
+
+	t.Run("error_when_configured", func(t *testing.T) {
+		setupTestContext(t)
+		config, err := ctx.ConfigRepo.Get()
+		require.NoError(t, err)
+		config.ErrorOnNoActiveStop = true
+		require.NoError(t, ctx.ConfigRepo.Save(config))
+
+		err = runStop(stopCmd, nil)
+		assert.ErrorIs(t, err, runtime.ErrNoActiveTracking)
+	})
+}
@@ -1,19 +1,21 @@
 package cmd
 
 import (
+	"errors"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/manav03panchal/humantime/internal/model"
 	"github.com/manav03panchal/humantime/internal/output"
+	"github.com/manav03panchal/humantime/internal/storage"
 )
 
 // undoCmd represents the undo command.
 var undoCmd = &cobra.Command{
 	Use:   "undo",
 	Short: "Undo the last action",
-	Long: `Undo the last undoable action (start, stop, or delete).
+	Long: `Undo the last undoable action (start, stop, delete, or split).
 
 Examples:
   humantime start on project
@@ -26,7 +28,11 @@ Examples:
 
   humantime blocks delete abc123 --force
   humantime undo
-  # Restores the deleted block`,
+  # Restores the deleted block
+
+  humantime continue
+  humantime undo
+  # Rejoins the split block`,
 	RunE: runUndo,
 }
 
@@ -60,6 +66,8 @@ func runUndo(cmd *cobra.Command, args []string) error {
 		return undoStop(state, cli)
 	case model.UndoActionDelete:
 		return undoDelete(state, cli)
+	case model.UndoActionSplit:
+		return undoSplit(state, cli)
 	default:
 		ctx.CLIFormatter().Muted("Nothing to undo")
 		return nil
@@ -79,8 +87,8 @@ func undoStart(state *model.UndoState, cli *output.CLIFormatter) error {
 		return nil
 	}
 
-	// Delete the block
-	if err := ctx.BlockRepo.Delete(block.Key); err != nil {
+	// Delete the block (permanently; it was only just created)
+	if err := ctx.BlockRepo.HardDelete(block.Key); err != nil {
 		return err
 	}
 
@@ -180,11 +188,15 @@ func undoDelete(state *model.UndoState, cli *output.CLIFormatter) error {
 		return nil
 	}
 
-	// Restore the block from snapshot
-	block := state.BlockSnapshot
-
-	// We need to use a special restore that preserves the original key
-	if err := ctx.DB.Set(block); err != nil {
+	// Restore the block from the trash, falling back to the snapshot if it
+	// has already been purged
+	block, err := ctx.BlockRepo.Restore(state.BlockSnapshot.Key)
+	if storage.IsErrKeyNotFound(err) {
+		block = state.BlockSnapshot
+		// Update stores the block under its original key
+		err = ctx.BlockRepo.Update(block)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -208,3 +220,38 @@ func undoDelete(state *model.UndoState, cli *output.CLIFormatter) error {
 	cli.Success("Undid delete: restored block for " + cli.FormatProjectTask(block.ProjectSID, block.TaskSID) + " (" + durationStr + " on " + dateStr + ")")
 	return nil
 }
+
+// undoSplit undoes a split or continue by merging the new block back into
+// the block it was split from.
+func undoSplit(state *model.UndoState, cli *output.CLIFormatter) error {
+	block, err := ctx.BlockRepo.Merge(state.BlockKey)
+	if storage.IsErrKeyNotFound(err) || errors.Is(err, storage.ErrNotContinuation) {
+		// Either half may have been deleted or edited since
+		if err := ctx.UndoRepo.Clear(); err != nil {
+			return err
+		}
+		cli.Muted("Nothing to undo (block no longer exists)")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Clear undo state
+	if err := ctx.UndoRepo.Clear(); err != nil {
+		return err
+	}
+
+	if ctx.IsJSON() {
+		return ctx.Formatter.JSON(map[string]interface{}{
+			"status":    "undone",
+			"action":    "split",
+			"project":   block.ProjectSID,
+			"task":      block.TaskSID,
+			"block_key": block.Key,
+		})
+	}
+
+	cli.Success("Undid split: rejoined block for " + cli.FormatProjectTask(block.ProjectSID, block.TaskSID))
+	return nil
+}
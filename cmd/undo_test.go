@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// =============================================================================
+// Undo Split Tests
+// =============================================================================
+
+func TestUndoContinue(t *testing.T) {
+	out := setupTestContext(t)
+
+	block := model.NewBlock("", "work", "", "", time.Now().Add(-time.Hour))
+	_, err := ctx.BlockRepo.StartExclusive(block)
+	require.NoError(t, err)
+
+	require.NoError(t, runContinue(continueCmd, nil))
+	require.NoError(t, runUndo(undoCmd, nil))
+	assert.Contains(t, out.String(), "Undid split")
+
+	blocks, err := ctx.BlockRepo.List()
+	require.NoError(t, err)
+	require.Len(t, blocks, 1, "the continuation is merged back")
+	assert.Equal(t, block.Key, blocks[0].Key)
+	assert.True(t, blocks[0].IsActive(), "the original block is running again")
+
+	active, err := ctx.ActiveBlockRepo.GetActiveBlock(ctx.BlockRepo)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, block.Key, active.Key)
+}
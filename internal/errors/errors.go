@@ -31,6 +31,7 @@ var (
 	ErrLockHeld          = errors.New("database locked by another process")
 	ErrTimeout           = errors.New("operation timed out")
 	ErrPermissionDenied  = errors.New("permission denied")
+	ErrNoteTooLong       = errors.New("note too long")
 )
 
 // UserError represents an error that the user can fix.
@@ -1,10 +1,15 @@
 package model
 
+// MaxRecentBlocks is the maximum number of recently-active block keys retained.
+const MaxRecentBlocks = 10
+
 // ActiveBlock is a singleton that tracks the currently active time block.
 type ActiveBlock struct {
-	Key              string `json:"key"`
-	ActiveBlockKey   string `json:"active_block_key,omitempty"`
-	PreviousBlockKey string `json:"previous_block_key,omitempty"`
+	Key              string   `json:"key"`
+	ActiveBlockKey   string   `json:"active_block_key,omitempty"`
+	PreviousBlockKey string   `json:"previous_block_key,omitempty"`
+	RecentBlockKeys  []string `json:"recent_block_keys,omitempty"` // Most recent first
+	Revision         int64    `json:"revision"`                    // Incremented on every write
 }
 
 // SetKey sets the database key for this active block record.
@@ -30,11 +35,13 @@ func NewActiveBlock() *ActiveBlock {
 }
 
 // SetActive sets the active block key and moves current to previous.
+// The key is also pushed onto the recent blocks list.
 func (a *ActiveBlock) SetActive(blockKey string) {
 	if a.ActiveBlockKey != "" {
 		a.PreviousBlockKey = a.ActiveBlockKey
 	}
 	a.ActiveBlockKey = blockKey
+	a.pushRecent(blockKey)
 }
 
 // ClearActive clears the active block and saves it as previous.
@@ -44,3 +51,23 @@ func (a *ActiveBlock) ClearActive() {
 	}
 	a.ActiveBlockKey = ""
 }
+
+// pushRecent moves blockKey to the front of the recent list, removing any
+// earlier occurrence and capping the list at MaxRecentBlocks.
+func (a *ActiveBlock) pushRecent(blockKey string) {
+	if blockKey == "" {
+		return
+	}
+
+	recent := make([]string, 0, len(a.RecentBlockKeys)+1)
+	recent = append(recent, blockKey)
+	for _, k := range a.RecentBlockKeys {
+		if k != blockKey {
+			recent = append(recent, k)
+		}
+	}
+	if len(recent) > MaxRecentBlocks {
+		recent = recent[:MaxRecentBlocks]
+	}
+	a.RecentBlockKeys = recent
+}
@@ -2,10 +2,35 @@ package model
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/manav03panchal/humantime/internal/errors"
 )
 
+// DefaultMaxNoteLength is the default maximum note length in characters.
+const DefaultMaxNoteLength = 65536
+
+// noteEllipsis marks a note that was truncated to fit the length limit.
+const noteEllipsis = "…"
+
+// hashtagPattern matches a #tag token at the start of a note or after
+// whitespace.
+var hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_-]*)`)
+
+// NoteHashtags returns the hashtag tokens in a note, without the leading #,
+// in order of appearance.
+func NoteHashtags(note string) []string {
+	var tags []string
+	for _, m := range hashtagPattern.FindAllStringSubmatch(note, -1) {
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
 // Block represents a tracked time period.
 type Block struct {
 	Key            string    `json:"key"`
@@ -14,8 +39,22 @@ type Block struct {
 	TaskSID        string    `json:"task_sid,omitempty" validate:"max=32"`
 	Note           string    `json:"note,omitempty" validate:"max=65536"`
 	Tags           []string  `json:"tags,omitempty"`
+	Kind           string    `json:"kind,omitempty"`
 	TimestampStart time.Time `json:"timestamp_start" validate:"required"`
 	TimestampEnd   time.Time `json:"timestamp_end,omitempty"`
+
+	// ExternalID identifies the block in the tool it was synced from. No two
+	// blocks created with BlockRepo.CreateIdempotent share one.
+	ExternalID string `json:"external_id,omitempty"`
+	// ContinuedFrom is the key of the block this one continues, when a
+	// session was split or resumed.
+	ContinuedFrom string `json:"continued_from,omitempty"`
+	// UpdatedAt is when the block was last written. Zero for blocks stored
+	// before it was recorded.
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	// Heartbeat is the last time an active block was known to be running.
+	// Zero if no heartbeat was ever recorded.
+	Heartbeat time.Time `json:"heartbeat,omitempty"`
 }
 
 // HasTag returns true if the block has the specified tag (case-insensitive).
@@ -29,6 +68,113 @@ func (b *Block) HasTag(tag string) bool {
 	return false
 }
 
+// AddTag adds a tag unless the block already has it (case-insensitive).
+// Returns true if the tag was added.
+func (b *Block) AddTag(tag string) bool {
+	if b.HasTag(tag) {
+		return false
+	}
+	b.Tags = append(b.Tags, tag)
+	return true
+}
+
+// RemoveTag removes all occurrences of a tag (case-insensitive).
+// Returns true if the tag was present.
+func (b *Block) RemoveTag(tag string) bool {
+	tagLower := strings.ToLower(tag)
+	kept := b.Tags[:0]
+	for _, t := range b.Tags {
+		if strings.ToLower(t) != tagLower {
+			kept = append(kept, t)
+		}
+	}
+	removed := len(kept) != len(b.Tags)
+	if len(kept) == 0 {
+		kept = nil
+	}
+	b.Tags = kept
+	return removed
+}
+
+// NormalizeTags trims whitespace around the block's tags, drops empty ones
+// and removes case-insensitive duplicates, keeping the first spelling. With
+// lower set, tags are lowercased as well. Returns true if the tags changed.
+func (b *Block) NormalizeTags(lower bool) bool {
+	var normalized []string
+	seen := make(map[string]bool, len(b.Tags))
+	for _, t := range b.Tags {
+		t = strings.TrimSpace(t)
+		if lower {
+			t = strings.ToLower(t)
+		}
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		normalized = append(normalized, t)
+	}
+
+	if slices.Equal(normalized, b.Tags) {
+		return false
+	}
+	b.Tags = normalized
+	return true
+}
+
+// Validate checks that the block has the fields required for storage,
+// using DefaultMaxNoteLength as the note limit.
+func (b *Block) Validate() error {
+	return b.ValidateWithNoteLimit(DefaultMaxNoteLength)
+}
+
+// ValidateWithNoteLimit is like Validate but with a custom note length limit
+// in characters. A limit of zero or less disables the note check.
+func (b *Block) ValidateWithNoteLimit(maxNoteLength int) error {
+	if maxNoteLength > 0 && utf8.RuneCountInString(b.Note) > maxNoteLength {
+		return errors.ErrNoteTooLong
+	}
+	if b.ProjectSID == "" {
+		return errors.ErrProjectRequired
+	}
+	if b.TimestampStart.IsZero() {
+		return errors.ErrInvalidTimestamp
+	}
+	if !b.TimestampEnd.IsZero() && b.TimestampEnd.Before(b.TimestampStart) {
+		return errors.ErrEndBeforeStart
+	}
+	return nil
+}
+
+// AppendNote appends text to the note, separated by " - " if a note already
+// exists. If the result would exceed maxLength characters, it is either cut
+// short and marked with an ellipsis (truncate) or rejected with
+// ErrNoteTooLong, leaving the note unchanged. A maxLength of zero or less
+// disables the limit.
+func (b *Block) AppendNote(text string, maxLength int, truncate bool) error {
+	note := text
+	if b.Note != "" && text != "" {
+		note = b.Note + " - " + text
+	} else if text == "" {
+		note = b.Note
+	}
+
+	if maxLength > 0 {
+		if length := utf8.RuneCountInString(note); length > maxLength {
+			if !truncate {
+				return fmt.Errorf("%w: %d characters exceeds the limit of %d", errors.ErrNoteTooLong, length, maxLength)
+			}
+			keep := maxLength - utf8.RuneCountInString(noteEllipsis)
+			if keep < 0 {
+				keep = 0
+			}
+			note = string([]rune(note)[:keep]) + noteEllipsis
+		}
+	}
+
+	b.Note = note
+	return nil
+}
+
 // SetKey sets the database key for this block.
 func (b *Block) SetKey(key string) {
 	b.Key = key
@@ -44,13 +190,76 @@ func (b *Block) IsActive() bool {
 	return b.TimestampEnd.IsZero()
 }
 
+// IsFuture returns true if the block starts after now, which usually means it
+// was recorded on a machine with a fast clock.
+func (b *Block) IsFuture(now time.Time) bool {
+	return b.TimestampStart.After(now)
+}
+
 // Duration returns the duration of the block.
-// If the block is active, it returns the duration from start until now.
+// If the block is active, it returns the duration from start until now,
+// clamped to zero for blocks that start in the future.
 func (b *Block) Duration() time.Duration {
-	if b.IsActive() {
-		return time.Since(b.TimestampStart)
+	return b.DurationAt(time.Now())
+}
+
+// DurationAt returns the duration of the block as of now: end minus start
+// for completed blocks, and now minus start, clamped to zero, for active
+// ones.
+func (b *Block) DurationAt(now time.Time) time.Duration {
+	return b.EndAt(now).Sub(b.TimestampStart)
+}
+
+// EndAt returns the end of the block as of now: the end time of a completed
+// block, and now, but no earlier than the start, for an active one.
+func (b *Block) EndAt(now time.Time) time.Time {
+	if !b.IsActive() {
+		return b.TimestampEnd
+	}
+	if now.Before(b.TimestampStart) {
+		return b.TimestampStart
+	}
+	return now
+}
+
+// OverlapsWith reports whether two blocks share any time, treating each as
+// the half-open interval [start, end). Blocks that merely touch do not
+// overlap, nor does a zero-length block. Active blocks are treated as ending
+// now.
+func (b *Block) OverlapsWith(other *Block) bool {
+	now := time.Now()
+	bEnd, otherEnd := b.EndAt(now), other.EndAt(now)
+	if !bEnd.After(b.TimestampStart) || !otherEnd.After(other.TimestampStart) {
+		return false
+	}
+	return b.TimestampStart.Before(otherEnd) && other.TimestampStart.Before(bEnd)
+}
+
+// StaleHeartbeat reports whether an active block has missed at least two
+// heartbeats of the given interval by now, suggesting the timer was left
+// running by a crash. Blocks without a heartbeat are never stale, and an
+// interval of zero disables the check.
+func (b *Block) StaleHeartbeat(now time.Time, interval time.Duration) bool {
+	if !b.IsActive() || b.Heartbeat.IsZero() || interval <= 0 {
+		return false
+	}
+	return now.Sub(b.Heartbeat) > 2*interval
+}
+
+// RoundUpTo extends the end time so the block's duration is a whole multiple
+// of unit, measured from the start. Returns the amount added. Active blocks
+// and non-positive units are left unchanged.
+func (b *Block) RoundUpTo(unit time.Duration) time.Duration {
+	if unit <= 0 || b.IsActive() {
+		return 0
+	}
+	remainder := b.TimestampEnd.Sub(b.TimestampStart) % unit
+	if remainder <= 0 {
+		return 0
 	}
-	return b.TimestampEnd.Sub(b.TimestampStart)
+	added := unit - remainder
+	b.TimestampEnd = b.TimestampEnd.Add(added)
+	return added
 }
 
 // DurationSeconds returns the duration in seconds.
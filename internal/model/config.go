@@ -1,9 +1,287 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
 // Config holds application configuration (singleton).
 type Config struct {
 	Key     string `json:"key"`
 	UserKey string `json:"user_key" validate:"required"`
+
+	// MinTrackUnit, when non-zero, rounds a block's duration up to the next
+	// multiple of this unit when it is stopped (e.g. 6m for tenth-hour billing).
+	MinTrackUnit time.Duration `json:"min_track_unit,omitempty"`
+
+	// MaxNoteLength limits block notes, in characters. Zero means
+	// DefaultMaxNoteLength.
+	MaxNoteLength int `json:"max_note_length,omitempty"`
+	// TruncateNotes cuts notes that would exceed MaxNoteLength instead of
+	// rejecting them.
+	TruncateNotes bool `json:"truncate_notes,omitempty"`
+
+	// IdleAfter, when non-zero, is the shortest gap between stopping and
+	// resuming that counts as idle time; resume then offers to track it.
+	IdleAfter time.Duration `json:"idle_after,omitempty"`
+
+	// ErrorOnNoActiveStop makes stopping with nothing active an error
+	// rather than a notice, to catch mistakes in scripts.
+	ErrorOnNoActiveStop bool `json:"error_on_no_active_stop,omitempty"`
+
+	// ResumeSnap snaps the start of resumed blocks to a whole minute: one of
+	// the ResumeSnap* modes. Empty means ResumeSnapExact.
+	ResumeSnap string `json:"resume_snap,omitempty"`
+
+	// HeartbeatInterval, when non-zero, is how often the active block's
+	// heartbeat is expected to be recorded. An active block whose heartbeat
+	// is more than two intervals old is offered to be closed at it.
+	HeartbeatInterval time.Duration `json:"heartbeat_interval,omitempty"`
+
+	// SafetyBackups copies the database before destructive operations such
+	// as a forced import, a permanent delete or emptying the trash.
+	SafetyBackups bool `json:"safety_backups,omitempty"`
+	// SafetyBackupRetention is how many backups to keep. Zero means
+	// DefaultSafetyBackupRetention.
+	SafetyBackupRetention int `json:"safety_backup_retention,omitempty"`
+
+	// SessionGap is the shortest pause that separates two sessions; blocks
+	// closer together count as one session, e.g. when consolidating. Zero
+	// means DefaultSessionGap.
+	SessionGap time.Duration `json:"session_gap,omitempty"`
+
+	// DailyCap, when non-zero, is the most time a single day counts for in
+	// day totals, so a timer left running overnight cannot skew them. Stored
+	// blocks are never changed.
+	DailyCap time.Duration `json:"daily_cap,omitempty"`
+
+	// DefaultListLimit, when non-zero, caps block listings that do not ask
+	// for a limit of their own.
+	DefaultListLimit int `json:"default_list_limit,omitempty"`
+
+	// BlockKinds lists the kinds a block may be classified as. Empty means
+	// DefaultBlockKinds.
+	BlockKinds []string `json:"block_kinds,omitempty"`
+
+	// AutoTagRules tag blocks whose note matches a pattern.
+	AutoTagRules []AutoTagRule `json:"auto_tag_rules,omitempty"`
+	// TagHashtags turns #hashtag tokens in notes into tags.
+	TagHashtags bool `json:"tag_hashtags,omitempty"`
+	// PreserveTagCase keeps the spelling of tags when they are normalized
+	// instead of lowercasing them.
+	PreserveTagCase bool `json:"preserve_tag_case,omitempty"`
+
+	// ProjectPalette lists hex colors assigned to auto-created projects.
+	// Empty means DefaultProjectPalette.
+	ProjectPalette []string `json:"project_palette,omitempty"`
+
+	// NoAutoCreateProjects makes tracking on an unknown project an error
+	// instead of creating it. Stored negated so the zero value keeps the
+	// default; use AutoCreateProjects to read it.
+	NoAutoCreateProjects bool `json:"no_auto_create_projects,omitempty"`
+	// RawProjectNames keeps the SID as the display name of auto-created
+	// projects instead of deriving one with DisplayNameFromSID; use
+	// ProjectDisplayName.
+	RawProjectNames bool `json:"raw_project_names,omitempty"`
+}
+
+// AutoTagRule adds Tag to blocks whose note matches Pattern, a regular
+// expression.
+type AutoTagRule struct {
+	Pattern string `json:"pattern"`
+	Tag     string `json:"tag"`
+}
+
+// Modes for snapping the start of resumed blocks.
+const (
+	ResumeSnapExact   = "exact"
+	ResumeSnapNearest = "nearest"
+	ResumeSnapUp      = "up"
+	ResumeSnapDown    = "down"
+)
+
+// ResumeSnapModes lists the valid ResumeSnap values.
+var ResumeSnapModes = []string{ResumeSnapExact, ResumeSnapNearest, ResumeSnapUp, ResumeSnapDown}
+
+// DefaultProjectPalette is the palette used when none is configured.
+var DefaultProjectPalette = []string{
+	"#4E79A7", "#F28E2B", "#E15759", "#76B7B2",
+	"#59A14F", "#EDC948", "#B07AA1", "#FF9DA7",
+}
+
+// DefaultSafetyBackupRetention is the number of safety backups kept when
+// none is configured.
+const DefaultSafetyBackupRetention = 5
+
+// DefaultSessionGap is the session gap used when none is configured.
+const DefaultSessionGap = 5 * time.Minute
+
+// DefaultBlockKinds are the block kinds used when none are configured.
+var DefaultBlockKinds = []string{"meeting", "deep-work", "break"}
+
+// kindPattern matches a valid block kind: the characters allowed in a SID.
+var kindPattern = regexp.MustCompile(`^[a-zA-Z0-9\-_.]+$`)
+
+// Validate checks the settings the config command would refuse to set, so a
+// config loaded from elsewhere cannot fail later at use.
+func (c *Config) Validate() error {
+	if c.MinTrackUnit < 0 || c.IdleAfter < 0 || c.HeartbeatInterval < 0 ||
+		c.SessionGap < 0 || c.DailyCap < 0 {
+		return fmt.Errorf("negative durations")
+	}
+	if c.MaxNoteLength < 0 || c.SafetyBackupRetention < 0 || c.DefaultListLimit < 0 {
+		return fmt.Errorf("negative limits")
+	}
+
+	if c.ResumeSnap != "" {
+		valid := false
+		for _, mode := range ResumeSnapModes {
+			if c.ResumeSnap == mode {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid resume snap mode %q", c.ResumeSnap)
+		}
+	}
+
+	seen := make(map[string]bool, len(c.BlockKinds))
+	for _, kind := range c.BlockKinds {
+		if !kindPattern.MatchString(kind) {
+			return fmt.Errorf("invalid block kind %q", kind)
+		}
+		if seen[strings.ToLower(kind)] {
+			return fmt.Errorf("duplicate block kind %q", kind)
+		}
+		seen[strings.ToLower(kind)] = true
+	}
+
+	for _, rule := range c.AutoTagRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid auto-tag pattern %q: %v", rule.Pattern, err)
+		}
+		if rule.Tag == "" || strings.ContainsAny(rule.Tag, " \t,") {
+			return fmt.Errorf("invalid auto-tag tag %q", rule.Tag)
+		}
+	}
+
+	for _, color := range c.ProjectPalette {
+		if color == "" || !ValidateColor(color) {
+			return fmt.Errorf("invalid palette color %q", color)
+		}
+	}
+	return nil
+}
+
+// NoteLimit returns the effective maximum note length.
+func (c *Config) NoteLimit() int {
+	if c.MaxNoteLength > 0 {
+		return c.MaxNoteLength
+	}
+	return DefaultMaxNoteLength
+}
+
+// BackupRetention returns the effective number of safety backups to keep.
+func (c *Config) BackupRetention() int {
+	if c.SafetyBackupRetention > 0 {
+		return c.SafetyBackupRetention
+	}
+	return DefaultSafetyBackupRetention
+}
+
+// SessionThreshold returns the effective session gap.
+func (c *Config) SessionThreshold() time.Duration {
+	if c.SessionGap > 0 {
+		return c.SessionGap
+	}
+	return DefaultSessionGap
+}
+
+// Kinds returns the effective list of block kinds.
+func (c *Config) Kinds() []string {
+	if len(c.BlockKinds) > 0 {
+		return c.BlockKinds
+	}
+	return DefaultBlockKinds
+}
+
+// ValidKind reports whether kind is one of the configured block kinds
+// (case-insensitive). The empty kind is always valid.
+func (c *Config) ValidKind(kind string) bool {
+	if kind == "" {
+		return true
+	}
+	for _, k := range c.Kinds() {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoTags returns the tags a note earns from the auto-tag rules and, if
+// enabled, its hashtags. Rules whose pattern does not compile are skipped.
+func (c *Config) AutoTags(note string) []string {
+	if note == "" {
+		return nil
+	}
+
+	var tags []string
+	for _, rule := range c.AutoTagRules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(note) {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	if c.TagHashtags {
+		tags = append(tags, NoteHashtags(note)...)
+	}
+	return tags
+}
+
+// SnapResume returns t snapped to a whole minute according to ResumeSnap.
+// Unknown modes leave t unchanged.
+func (c *Config) SnapResume(t time.Time) time.Time {
+	switch c.ResumeSnap {
+	case ResumeSnapNearest:
+		return t.Round(time.Minute)
+	case ResumeSnapDown:
+		return t.Truncate(time.Minute)
+	case ResumeSnapUp:
+		down := t.Truncate(time.Minute)
+		if down.Equal(t) {
+			return t
+		}
+		return down.Add(time.Minute)
+	}
+	return t
+}
+
+// Palette returns the effective project color palette.
+func (c *Config) Palette() []string {
+	if len(c.ProjectPalette) > 0 {
+		return c.ProjectPalette
+	}
+	return DefaultProjectPalette
+}
+
+// AutoCreateProjects reports whether unknown projects are created on first use.
+func (c *Config) AutoCreateProjects() bool {
+	return !c.NoAutoCreateProjects
+}
+
+// ProjectDisplayName returns the display name for a project auto-created
+// with the SID.
+func (c *Config) ProjectDisplayName(sid string) string {
+	if c.RawProjectNames {
+		return sid
+	}
+	return DisplayNameFromSID(sid)
 }
 
 // SetKey sets the database key for this config.
@@ -15,6 +15,7 @@ const (
 	PrefixProject     = "project"
 	PrefixTask        = "task"
 	PrefixGoal        = "goal"
+	PrefixTrash       = "trash"
 	KeyActiveBlock    = "activeblock"
 	KeyConfig         = "config"
 	// New prefixes for reminders daemon feature
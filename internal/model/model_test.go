@@ -1,10 +1,14 @@
 package model
 
 import (
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/manav03panchal/humantime/internal/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // =============================================================================
@@ -47,6 +51,51 @@ func TestBlockHasTag(t *testing.T) {
 	assert.False(t, emptyBlock.HasTag("any"))
 }
 
+func TestBlockAddRemoveTag(t *testing.T) {
+	block := &Block{Tags: []string{"urgent"}}
+
+	assert.True(t, block.AddTag("billable"))
+	assert.False(t, block.AddTag("BILLABLE"))
+	assert.Equal(t, []string{"urgent", "billable"}, block.Tags)
+
+	assert.True(t, block.RemoveTag("Urgent"))
+	assert.False(t, block.RemoveTag("urgent"))
+	assert.Equal(t, []string{"billable"}, block.Tags)
+
+	assert.True(t, block.RemoveTag("billable"))
+	assert.Nil(t, block.Tags)
+}
+
+func TestBlockNormalizeTags(t *testing.T) {
+	block := &Block{Tags: []string{"Billable", "billable ", " BILLABLE", " ", "urgent"}}
+	assert.True(t, block.NormalizeTags(true))
+	assert.Equal(t, []string{"billable", "urgent"}, block.Tags)
+	assert.False(t, block.NormalizeTags(true))
+
+	block = &Block{Tags: []string{" Billable", "BILLABLE"}}
+	assert.True(t, block.NormalizeTags(false))
+	assert.Equal(t, []string{"Billable"}, block.Tags)
+
+	block = &Block{}
+	assert.False(t, block.NormalizeTags(true))
+}
+
+func TestBlockRoundUpTo(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	block := &Block{TimestampStart: start, TimestampEnd: start.Add(4 * time.Minute)}
+	assert.Equal(t, 2*time.Minute, block.RoundUpTo(6*time.Minute))
+	assert.Equal(t, 6*time.Minute, block.Duration())
+
+	// Already a multiple
+	assert.Zero(t, block.RoundUpTo(6*time.Minute))
+	assert.Zero(t, block.RoundUpTo(0))
+
+	active := &Block{TimestampStart: start}
+	assert.Zero(t, active.RoundUpTo(6*time.Minute))
+	assert.True(t, active.IsActive())
+}
+
 func TestBlockIsActive(t *testing.T) {
 	// Active block (no end time)
 	active := &Block{
@@ -86,6 +135,100 @@ func TestBlockDuration(t *testing.T) {
 	})
 }
 
+func TestBlockOverlapsWith(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := func(from, to time.Duration) *Block {
+		b := NewBlock("", "work", "", "", base.Add(from))
+		if to >= 0 {
+			b.TimestampEnd = base.Add(to)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		a, b *Block
+		want bool
+	}{
+		{"partial", block(0, 2*time.Hour), block(time.Hour, 3*time.Hour), true},
+		{"nested", block(0, 3*time.Hour), block(time.Hour, 2*time.Hour), true},
+		{"identical", block(0, time.Hour), block(0, time.Hour), true},
+		{"touching", block(0, time.Hour), block(time.Hour, 2*time.Hour), false},
+		{"disjoint", block(0, time.Hour), block(2*time.Hour, 3*time.Hour), false},
+		{"zero_length_inside", block(0, 2*time.Hour), block(time.Hour, time.Hour), false},
+		{"active_runs_to_now", block(0, -1), block(time.Hour, 2*time.Hour), true},
+		{"active_starts_after_end", block(2*time.Hour, -1), block(0, time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.a.OverlapsWith(tt.b))
+			assert.Equal(t, tt.want, tt.b.OverlapsWith(tt.a), "overlap is symmetric")
+		})
+	}
+}
+
+func TestBlockStaleHeartbeat(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	interval := 5 * time.Minute
+
+	active := NewBlock("", "work", "", "", now.Add(-time.Hour))
+	assert.False(t, active.StaleHeartbeat(now, interval), "no heartbeat recorded")
+
+	active.Heartbeat = now.Add(-9 * time.Minute)
+	assert.False(t, active.StaleHeartbeat(now, interval), "within two intervals")
+
+	active.Heartbeat = now.Add(-11 * time.Minute)
+	assert.True(t, active.StaleHeartbeat(now, interval))
+	assert.False(t, active.StaleHeartbeat(now, 0), "disabled")
+
+	active.TimestampEnd = now
+	assert.False(t, active.StaleHeartbeat(now, interval), "completed block")
+}
+
+func TestBlockIsFuture(t *testing.T) {
+	now := time.Now()
+	assert.True(t, (&Block{TimestampStart: now.Add(time.Hour)}).IsFuture(now))
+	assert.False(t, (&Block{TimestampStart: now}).IsFuture(now))
+	assert.False(t, (&Block{TimestampStart: now.Add(-time.Hour)}).IsFuture(now))
+}
+
+func TestBlockDurationFutureActive(t *testing.T) {
+	block := &Block{
+		TimestampStart: time.Now().Add(2 * time.Hour),
+	}
+	assert.True(t, block.IsActive())
+	assert.Equal(t, time.Duration(0), block.Duration())
+	assert.Equal(t, int64(0), block.DurationSeconds())
+}
+
+func TestBlockDurationAt(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := start.Add(3 * time.Hour)
+
+	completed := &Block{TimestampStart: start, TimestampEnd: start.Add(time.Hour)}
+	assert.Equal(t, time.Hour, completed.DurationAt(now))
+
+	active := &Block{TimestampStart: start}
+	assert.Equal(t, 3*time.Hour, active.DurationAt(now))
+
+	future := &Block{TimestampStart: now.Add(time.Hour)}
+	assert.Zero(t, future.DurationAt(now))
+}
+
+func TestBlockEndAt(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := start.Add(3 * time.Hour)
+
+	completed := &Block{TimestampStart: start, TimestampEnd: start.Add(time.Hour)}
+	assert.Equal(t, start.Add(time.Hour), completed.EndAt(now))
+
+	active := &Block{TimestampStart: start}
+	assert.Equal(t, now, active.EndAt(now))
+
+	future := &Block{TimestampStart: now.Add(time.Hour)}
+	assert.Equal(t, future.TimestampStart, future.EndAt(now))
+}
+
 func TestBlockDurationSeconds(t *testing.T) {
 	start := time.Now().Add(-1 * time.Hour)
 	end := time.Now()
@@ -98,6 +241,136 @@ func TestBlockDurationSeconds(t *testing.T) {
 	assert.InDelta(t, 3600, seconds, 1)
 }
 
+func TestBlockValidate(t *testing.T) {
+	now := time.Now()
+
+	valid := &Block{ProjectSID: "proj", TimestampStart: now}
+	assert.NoError(t, valid.Validate())
+
+	completed := &Block{ProjectSID: "proj", TimestampStart: now, TimestampEnd: now.Add(time.Hour)}
+	assert.NoError(t, completed.Validate())
+
+	assert.Error(t, (&Block{TimestampStart: now}).Validate(), "missing project")
+	assert.Error(t, (&Block{ProjectSID: "proj"}).Validate(), "missing start")
+	assert.Error(t, (&Block{ProjectSID: "proj", TimestampStart: now, TimestampEnd: now.Add(-time.Hour)}).Validate(), "end before start")
+}
+
+func TestBlockValidateNoteLength(t *testing.T) {
+	now := time.Now()
+
+	atLimit := &Block{ProjectSID: "proj", TimestampStart: now, Note: strings.Repeat("é", DefaultMaxNoteLength)}
+	assert.NoError(t, atLimit.Validate(), "limit counts characters, not bytes")
+
+	over := &Block{ProjectSID: "proj", TimestampStart: now, Note: strings.Repeat("a", DefaultMaxNoteLength+1)}
+	assert.ErrorIs(t, over.Validate(), errors.ErrNoteTooLong)
+
+	assert.ErrorIs(t, atLimit.ValidateWithNoteLimit(10), errors.ErrNoteTooLong)
+	assert.NoError(t, over.ValidateWithNoteLimit(0), "zero disables the check")
+}
+
+func TestBlockAppendNote(t *testing.T) {
+	t.Run("appends_with_separator", func(t *testing.T) {
+		b := &Block{}
+		require.NoError(t, b.AppendNote("first", 100, false))
+		require.NoError(t, b.AppendNote("second", 100, false))
+		assert.Equal(t, "first - second", b.Note)
+	})
+
+	t.Run("exactly_at_limit", func(t *testing.T) {
+		b := &Block{Note: "abc"}
+		require.NoError(t, b.AppendNote("de", 8, false)) // "abc - de"
+		assert.Equal(t, "abc - de", b.Note)
+	})
+
+	t.Run("beyond_limit_errors", func(t *testing.T) {
+		b := &Block{Note: "abc"}
+		err := b.AppendNote("def", 8, false)
+		assert.ErrorIs(t, err, errors.ErrNoteTooLong)
+		assert.Equal(t, "abc", b.Note, "note unchanged on error")
+	})
+
+	t.Run("beyond_limit_truncates", func(t *testing.T) {
+		b := &Block{Note: "abc"}
+		require.NoError(t, b.AppendNote("def", 8, true))
+		assert.Equal(t, "abc - d…", b.Note)
+		assert.Equal(t, 8, utf8.RuneCountInString(b.Note))
+	})
+}
+
+func TestConfigNoteLimit(t *testing.T) {
+	c := NewConfig("")
+	assert.Equal(t, DefaultMaxNoteLength, c.NoteLimit())
+	c.MaxNoteLength = 500
+	assert.Equal(t, 500, c.NoteLimit())
+}
+
+func TestConfigKinds(t *testing.T) {
+	c := NewConfig("")
+	assert.Equal(t, DefaultBlockKinds, c.Kinds())
+	assert.True(t, c.ValidKind("Meeting"))
+	assert.True(t, c.ValidKind(""))
+	assert.False(t, c.ValidKind("lunch"))
+
+	c.BlockKinds = []string{"lunch"}
+	assert.True(t, c.ValidKind("lunch"))
+	assert.False(t, c.ValidKind("meeting"))
+}
+
+func TestConfigAutoTags(t *testing.T) {
+	note := "fix #urgent login bug for #ClientA, see issue#12"
+
+	t.Run("hashtags_off_by_default", func(t *testing.T) {
+		assert.Empty(t, NewConfig("").AutoTags(note))
+	})
+
+	t.Run("two_hashtags", func(t *testing.T) {
+		c := NewConfig("")
+		c.TagHashtags = true
+		assert.Equal(t, []string{"urgent", "ClientA"}, c.AutoTags(note))
+	})
+
+	t.Run("custom_rule", func(t *testing.T) {
+		c := NewConfig("")
+		c.AutoTagRules = []AutoTagRule{
+			{Pattern: `(?i)\blogin\b`, Tag: "auth"},
+			{Pattern: `standup`, Tag: "meeting"},
+			{Pattern: `(`, Tag: "broken"},
+		}
+		assert.Equal(t, []string{"auth"}, c.AutoTags(note))
+	})
+
+	t.Run("empty_note", func(t *testing.T) {
+		c := NewConfig("")
+		c.TagHashtags = true
+		c.AutoTagRules = []AutoTagRule{{Pattern: `.*`, Tag: "any"}}
+		assert.Empty(t, c.AutoTags(""))
+	})
+}
+
+func TestConfigSnapResume(t *testing.T) {
+	at := time.Date(2025, 3, 10, 10, 0, 37, 0, time.UTC)
+	whole := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		mode string
+		at   time.Time
+		want time.Time
+	}{
+		{"", at, at},
+		{ResumeSnapExact, at, at},
+		{ResumeSnapNearest, at, whole.Add(time.Minute)},
+		{ResumeSnapNearest, whole.Add(29 * time.Second), whole},
+		{ResumeSnapUp, at, whole.Add(time.Minute)},
+		{ResumeSnapUp, whole, whole},
+		{ResumeSnapDown, at, whole},
+	}
+	for _, tt := range tests {
+		c := NewConfig("")
+		c.ResumeSnap = tt.mode
+		assert.Equal(t, tt.want, c.SnapResume(tt.at), "mode %q at %s", tt.mode, tt.at.Format(time.TimeOnly))
+	}
+}
+
 func TestGenerateBlockKey(t *testing.T) {
 	key := GenerateBlockKey("abc123")
 	assert.Equal(t, "block:abc123", key)
@@ -117,6 +390,23 @@ func TestNewProject(t *testing.T) {
 	assert.Equal(t, "#FF5733", project.Color)
 }
 
+func TestProjectStartNote(t *testing.T) {
+	project := NewProject("support", "Support", "")
+	project.NoteTemplate = "ticket: "
+
+	t.Run("empty_note_uses_template", func(t *testing.T) {
+		assert.Equal(t, "ticket: ", project.StartNote(""))
+	})
+
+	t.Run("explicit_note_overrides", func(t *testing.T) {
+		assert.Equal(t, "ticket: 1234", project.StartNote("ticket: 1234"))
+	})
+
+	t.Run("no_template", func(t *testing.T) {
+		assert.Equal(t, "", NewProject("plain", "Plain", "").StartNote(""))
+	})
+}
+
 func TestProjectSetGetKey(t *testing.T) {
 	project := &Project{}
 	project.SetKey("project:test")
@@ -258,3 +548,114 @@ func TestKeyPrefixConstants(t *testing.T) {
 	assert.Equal(t, "activeblock", KeyActiveBlock)
 	assert.Equal(t, "undo", KeyUndo)
 }
+
+func TestActiveBlockRecentBlockKeys(t *testing.T) {
+	t.Run("most_recent_first", func(t *testing.T) {
+		ab := NewActiveBlock()
+		ab.SetActive("block:1")
+		ab.SetActive("block:2")
+		ab.SetActive("block:3")
+		assert.Equal(t, []string{"block:3", "block:2", "block:1"}, ab.RecentBlockKeys)
+	})
+
+	t.Run("dedupes_reactivated_keys", func(t *testing.T) {
+		ab := NewActiveBlock()
+		ab.SetActive("block:1")
+		ab.SetActive("block:2")
+		ab.SetActive("block:1")
+		assert.Equal(t, []string{"block:1", "block:2"}, ab.RecentBlockKeys)
+	})
+
+	t.Run("capped_at_max", func(t *testing.T) {
+		ab := NewActiveBlock()
+		for i := 0; i < MaxRecentBlocks+5; i++ {
+			ab.SetActive(GenerateBlockKey(string(rune('a' + i))))
+		}
+		assert.Len(t, ab.RecentBlockKeys, MaxRecentBlocks)
+		assert.Equal(t, ab.ActiveBlockKey, ab.RecentBlockKeys[0])
+	})
+
+	t.Run("clear_keeps_recent", func(t *testing.T) {
+		ab := NewActiveBlock()
+		ab.SetActive("block:1")
+		ab.ClearActive()
+		assert.Equal(t, []string{"block:1"}, ab.RecentBlockKeys)
+	})
+}
+
+func TestValidateTimezone(t *testing.T) {
+	assert.NoError(t, ValidateTimezone(""))
+	assert.NoError(t, ValidateTimezone("UTC"))
+	assert.NoError(t, ValidateTimezone("America/New_York"))
+	assert.Error(t, ValidateTimezone("Mars/Olympus"))
+	assert.Error(t, ValidateTimezone("Local"))
+}
+
+func TestProjectLocation(t *testing.T) {
+	p := NewProject("client", "Client", "")
+	assert.Equal(t, time.UTC, p.Location(time.UTC))
+
+	p.Timezone = "Asia/Tokyo"
+	assert.Equal(t, "Asia/Tokyo", p.Location(time.UTC).String())
+
+	// An invalid stored zone falls back rather than failing reports
+	p.Timezone = "Mars/Olympus"
+	assert.Equal(t, time.UTC, p.Location(time.UTC))
+}
+
+func TestConfigSessionThreshold(t *testing.T) {
+	c := NewConfig("")
+	assert.Equal(t, DefaultSessionGap, c.SessionThreshold())
+
+	c.SessionGap = 15 * time.Minute
+	assert.Equal(t, 15*time.Minute, c.SessionThreshold())
+}
+
+func TestDisplayNameFromSID(t *testing.T) {
+	tests := []struct {
+		sid  string
+		want string
+	}{
+		{"my-project", "My Project"},
+		{"client_work", "Client Work"},
+		{"api--v2", "Api V2"},
+		{"v1.2", "V1.2"},
+		{"already", "Already"},
+		{"---", "---"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DisplayNameFromSID(tt.sid), tt.sid)
+	}
+
+	c := NewConfig("")
+	assert.Equal(t, "My Project", c.ProjectDisplayName("my-project"))
+	c.RawProjectNames = true
+	assert.Equal(t, "my-project", c.ProjectDisplayName("my-project"))
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, NewConfig("").Validate())
+
+	valid := NewConfig("")
+	valid.ResumeSnap = ResumeSnapUp
+	valid.BlockKinds = []string{"meeting", "deep-work"}
+	valid.AutoTagRules = []AutoTagRule{{Pattern: `^fix\b`, Tag: "bug"}}
+	valid.ProjectPalette = []string{"#112233"}
+	assert.NoError(t, valid.Validate())
+
+	for name, mutate := range map[string]func(c *Config){
+		"negative_session_gap": func(c *Config) { c.SessionGap = -time.Minute },
+		"negative_list_limit":  func(c *Config) { c.DefaultListLimit = -1 },
+		"unknown_snap_mode":    func(c *Config) { c.ResumeSnap = "sideways" },
+		"empty_kind":           func(c *Config) { c.BlockKinds = []string{"meeting", ""} },
+		"duplicate_kind":       func(c *Config) { c.BlockKinds = []string{"meeting", "Meeting"} },
+		"bad_pattern":          func(c *Config) { c.AutoTagRules = []AutoTagRule{{Pattern: "(", Tag: "x"}} },
+		"bad_tag":              func(c *Config) { c.AutoTagRules = []AutoTagRule{{Pattern: "x", Tag: "a b"}} },
+		"bad_color":            func(c *Config) { c.ProjectPalette = []string{"red"} },
+	} {
+		c := NewConfig("")
+		mutate(c)
+		assert.Error(t, c.Validate(), name)
+	}
+}
@@ -3,6 +3,10 @@ package model
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Project represents a top-level organizational unit for time tracking.
@@ -12,6 +16,33 @@ type Project struct {
 	DisplayName string `json:"display_name" validate:"required,max=64"`
 	Color       string `json:"color,omitempty" validate:"omitempty,hexcolor"`
 	Archived    bool   `json:"archived,omitempty"`
+	// NoteTemplate seeds the note of blocks started without one.
+	NoteTemplate string `json:"note_template,omitempty"`
+	// Timezone is an IANA time zone name, such as "America/New_York", whose
+	// days and weeks this project's reports use instead of the local ones.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// StartNote returns the note for a block started on this project: note
+// itself, or the project's template when note is empty.
+func (p *Project) StartNote(note string) string {
+	if note != "" {
+		return note
+	}
+	return p.NoteTemplate
+}
+
+// Location returns the project's time zone, or fallback when the project has
+// none or it cannot be loaded.
+func (p *Project) Location(fallback *time.Location) *time.Location {
+	if p.Timezone == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fallback
+	}
+	return loc
 }
 
 // SetKey sets the database key for this project.
@@ -39,6 +70,23 @@ func NewProject(sid, displayName, color string) *Project {
 	}
 }
 
+// DisplayNameFromSID derives a readable display name from a SID: hyphens
+// and underscores become spaces and each word is capitalized, so
+// "my-project" becomes "My Project".
+func DisplayNameFromSID(sid string) string {
+	words := strings.FieldsFunc(sid, func(r rune) bool {
+		return r == '-' || r == '_' || unicode.IsSpace(r)
+	})
+	if len(words) == 0 {
+		return sid
+	}
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
+
 // hexColorRegex validates hex color format.
 var hexColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
 
@@ -49,3 +97,16 @@ func ValidateColor(color string) bool {
 	}
 	return hexColorRegex.MatchString(color)
 }
+
+// ValidateTimezone checks that tz is empty or a time zone name known to
+// time.LoadLocation. "Local" is rejected, as it is not a fixed zone.
+func ValidateTimezone(tz string) error {
+	if tz == "" {
+		return nil
+	}
+	if tz == "Local" {
+		return fmt.Errorf("unknown time zone %s", tz)
+	}
+	_, err := time.LoadLocation(tz)
+	return err
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TrashedBlock is a soft-deleted block kept so it can be restored.
+type TrashedBlock struct {
+	Key       string    `json:"key"`
+	Block     *Block    `json:"block"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SetKey sets the database key for this trash entry.
+func (t *TrashedBlock) SetKey(key string) {
+	t.Key = key
+}
+
+// GetKey returns the database key for this trash entry.
+func (t *TrashedBlock) GetKey() string {
+	return t.Key
+}
+
+// BlockKey returns the key the block had before it was deleted.
+func (t *TrashedBlock) BlockKey() string {
+	return strings.TrimPrefix(t.Key, PrefixTrash+":")
+}
+
+// GenerateTrashKey generates the trash key for a block key.
+func GenerateTrashKey(blockKey string) string {
+	return fmt.Sprintf("%s:%s", PrefixTrash, blockKey)
+}
+
+// NewTrashedBlock wraps a block as a trash entry deleted at the given time.
+func NewTrashedBlock(block *Block, deletedAt time.Time) *TrashedBlock {
+	return &TrashedBlock{
+		Key:       GenerateTrashKey(block.Key),
+		Block:     block,
+		DeletedAt: deletedAt,
+	}
+}
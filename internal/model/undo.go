@@ -7,6 +7,7 @@ const (
 	UndoActionStart  UndoAction = "start"
 	UndoActionStop   UndoAction = "stop"
 	UndoActionDelete UndoAction = "delete"
+	UndoActionSplit  UndoAction = "split"
 )
 
 // KeyUndo is the database key for the undo state.
@@ -1,6 +1,8 @@
 package output
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/manav03panchal/humantime/internal/model"
@@ -29,10 +31,15 @@ type BlockOutput struct {
 	TaskSID         string   `json:"task_sid,omitempty"`
 	Note            string   `json:"note,omitempty"`
 	Tags            []string `json:"tags,omitempty"`
+	Kind            string   `json:"kind,omitempty"`
 	TimestampStart  string   `json:"timestamp_start"`
 	TimestampEnd    string   `json:"timestamp_end,omitempty"`
 	DurationSeconds int64    `json:"duration_seconds"`
 	IsActive        bool     `json:"is_active"`
+
+	// CumulativeSeconds is the running total up to and including this block,
+	// set only when requested via BlocksResponseOptions.
+	CumulativeSeconds *int64 `json:"cumulative_seconds,omitempty"`
 }
 
 // NewBlockOutput creates a BlockOutput from a Block.
@@ -43,6 +50,7 @@ func NewBlockOutput(b *model.Block) *BlockOutput {
 		TaskSID:         b.TaskSID,
 		Note:            b.Note,
 		Tags:            b.Tags,
+		Kind:            b.Kind,
 		TimestampStart:  b.TimestampStart.Format(time.RFC3339),
 		DurationSeconds: b.DurationSeconds(),
 		IsActive:        b.IsActive(),
@@ -66,13 +74,27 @@ type StopResponse struct {
 	Block  *BlockOutput `json:"block"`
 }
 
-// ErrorResponse represents an error in JSON.
+// ErrorResponse represents an error in JSON. Code is a stable identifier
+// for the kind of failure that scripts can match on; Error is the human
+// readable text and may change.
 type ErrorResponse struct {
 	Status  string `json:"status"`
 	Error   string `json:"error"`
+	Code    string `json:"code"`
 	Message string `json:"message,omitempty"`
 }
 
+// NewErrorResponse builds the error envelope for err with the given code
+// and suggestion.
+func NewErrorResponse(code string, err error, suggestion string) ErrorResponse {
+	return ErrorResponse{
+		Status:  "error",
+		Error:   err.Error(),
+		Code:    code,
+		Message: suggestion,
+	}
+}
+
 // BlocksResponse represents the blocks list output in JSON.
 type BlocksResponse struct {
 	Blocks               []*BlockOutput `json:"blocks"`
@@ -81,13 +103,28 @@ type BlocksResponse struct {
 	TotalDurationSeconds int64          `json:"total_duration_seconds"`
 }
 
+// BlocksResponseOptions configures NewBlocksResponse.
+type BlocksResponseOptions struct {
+	// Cumulative annotates each block with the running total, in listing order.
+	Cumulative bool
+}
+
 // NewBlocksResponse creates a BlocksResponse from blocks.
-func NewBlocksResponse(blocks []*model.Block, total int) *BlocksResponse {
+func NewBlocksResponse(blocks []*model.Block, total int, opts ...BlocksResponseOptions) *BlocksResponse {
+	var opt BlocksResponseOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
 	outputs := make([]*BlockOutput, len(blocks))
 	var totalDuration int64
 	for i, b := range blocks {
 		outputs[i] = NewBlockOutput(b)
 		totalDuration += b.DurationSeconds()
+		if opt.Cumulative {
+			cumulative := totalDuration
+			outputs[i].CumulativeSeconds = &cumulative
+		}
 	}
 	return &BlocksResponse{
 		Blocks:               outputs,
@@ -97,11 +134,87 @@ func NewBlocksResponse(blocks []*model.Block, total int) *BlocksResponse {
 	}
 }
 
+// BlockGroupBy selects how NewGroupedBlocksResponse groups blocks.
+type BlockGroupBy string
+
+const (
+	GroupByProject BlockGroupBy = "project"
+	GroupByTask    BlockGroupBy = "task" // project/task pairs
+	GroupByDay     BlockGroupBy = "day"  // local start date
+)
+
+// BlockGroupOutput is one group of blocks with its subtotal.
+type BlockGroupOutput struct {
+	Key             string         `json:"key"`
+	Blocks          []*BlockOutput `json:"blocks"`
+	Count           int            `json:"count"`
+	DurationSeconds int64          `json:"duration_seconds"`
+}
+
+// GroupedBlocksResponse represents blocks grouped by project, task, or day.
+type GroupedBlocksResponse struct {
+	GroupBy              BlockGroupBy        `json:"group_by"`
+	Groups               []*BlockGroupOutput `json:"groups"`
+	TotalCount           int                 `json:"total_count"`
+	TotalDurationSeconds int64               `json:"total_duration_seconds"`
+}
+
+// NewGroupedBlocksResponse groups blocks and computes per-group subtotals and
+// an overall total. Groups are sorted by key; blocks keep their input order.
+func NewGroupedBlocksResponse(blocks []*model.Block, groupBy BlockGroupBy) (*GroupedBlocksResponse, error) {
+	var keyFunc func(b *model.Block) string
+	switch groupBy {
+	case GroupByProject:
+		keyFunc = func(b *model.Block) string { return b.ProjectSID }
+	case GroupByTask:
+		keyFunc = func(b *model.Block) string {
+			if b.TaskSID == "" {
+				return b.ProjectSID
+			}
+			return b.ProjectSID + "/" + b.TaskSID
+		}
+	case GroupByDay:
+		keyFunc = func(b *model.Block) string { return b.TimestampStart.Local().Format("2006-01-02") }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (valid: project, task, day)", groupBy)
+	}
+
+	resp := &GroupedBlocksResponse{
+		GroupBy: groupBy,
+		Groups:  []*BlockGroupOutput{},
+	}
+	byKey := make(map[string]*BlockGroupOutput)
+	for _, b := range blocks {
+		key := keyFunc(b)
+		group, ok := byKey[key]
+		if !ok {
+			group = &BlockGroupOutput{Key: key}
+			byKey[key] = group
+			resp.Groups = append(resp.Groups, group)
+		}
+
+		seconds := b.DurationSeconds()
+		group.Blocks = append(group.Blocks, NewBlockOutput(b))
+		group.Count++
+		group.DurationSeconds += seconds
+		resp.TotalCount++
+		resp.TotalDurationSeconds += seconds
+	}
+
+	sort.Slice(resp.Groups, func(i, j int) bool {
+		return resp.Groups[i].Key < resp.Groups[j].Key
+	})
+
+	return resp, nil
+}
+
 // ProjectOutput represents a project in JSON output.
 type ProjectOutput struct {
 	SID                  string `json:"sid"`
 	DisplayName          string `json:"display_name"`
 	Color                string `json:"color,omitempty"`
+	NoteTemplate         string `json:"note_template,omitempty"`
+	Timezone             string `json:"timezone,omitempty"`
 	TotalDurationSeconds int64  `json:"total_duration_seconds"`
 }
 
@@ -111,6 +224,8 @@ func NewProjectOutput(p *model.Project, duration time.Duration) *ProjectOutput {
 		SID:                  p.SID,
 		DisplayName:          p.DisplayName,
 		Color:                p.Color,
+		NoteTemplate:         p.NoteTemplate,
+		Timezone:             p.Timezone,
 		TotalDurationSeconds: int64(duration.Seconds()),
 	}
 }
@@ -138,6 +253,14 @@ type PeriodOutput struct {
 type SummaryOutput struct {
 	TotalDurationSeconds int64                    `json:"total_duration_seconds"`
 	ByProject            []*ProjectSummaryOutput  `json:"by_project"`
+	ByNoteGroup          []*NoteGroupOutput       `json:"by_note_group,omitempty"`
+}
+
+// NoteGroupOutput represents the total for one value extracted from notes.
+type NoteGroupOutput struct {
+	Group           string `json:"group"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	BlockCount      int    `json:"block_count"`
 }
 
 // ProjectSummaryOutput represents a project summary.
@@ -195,16 +318,23 @@ func (j *JSONFormatter) PrintStop(block *model.Block) error {
 	return j.JSON(resp)
 }
 
-// PrintError outputs an error in JSON format.
+// PrintError outputs an error in JSON format. The status doubles as the
+// error code.
 func (j *JSONFormatter) PrintError(status, errMsg, message string) error {
 	resp := ErrorResponse{
 		Status:  status,
 		Error:   errMsg,
+		Code:    status,
 		Message: message,
 	}
 	return j.JSON(resp)
 }
 
+// PrintErrorResponse outputs an error envelope.
+func (j *JSONFormatter) PrintErrorResponse(resp ErrorResponse) error {
+	return j.JSON(resp)
+}
+
 // PrintBlocks outputs blocks in JSON format.
 func (j *JSONFormatter) PrintBlocks(blocks []*model.Block, total int) error {
 	return j.JSON(NewBlocksResponse(blocks, total))
@@ -3,6 +3,7 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -556,6 +557,115 @@ func TestNewBlocksResponse(t *testing.T) {
 	assert.InDelta(t, 7200, resp.TotalDurationSeconds, 2)
 }
 
+func TestNewBlocksResponseCumulative(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	blocks := []*model.Block{
+		{Key: "block:1", TimestampStart: start, TimestampEnd: start.Add(time.Hour)},
+		{Key: "block:2", TimestampStart: start.Add(2 * time.Hour), TimestampEnd: start.Add(150 * time.Minute)},
+		{Key: "block:3", TimestampStart: start.Add(3 * time.Hour), TimestampEnd: start.Add(5 * time.Hour)},
+	}
+
+	resp := NewBlocksResponse(blocks, 3, BlocksResponseOptions{Cumulative: true})
+
+	var got []int64
+	for _, b := range resp.Blocks {
+		require.NotNil(t, b.CumulativeSeconds)
+		got = append(got, *b.CumulativeSeconds)
+	}
+	assert.Equal(t, []int64{3600, 5400, 12600}, got)
+	assert.Equal(t, resp.TotalDurationSeconds, *resp.Blocks[2].CumulativeSeconds)
+
+	t.Run("omitted_by_default", func(t *testing.T) {
+		plain := NewBlocksResponse(blocks, 3)
+		assert.Nil(t, plain.Blocks[0].CumulativeSeconds)
+		data, err := json.Marshal(plain)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "cumulative_seconds")
+	})
+}
+
+func TestNewGroupedBlocksResponse(t *testing.T) {
+	day1 := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	mk := func(project, task string, start time.Time, d time.Duration) *model.Block {
+		return &model.Block{ProjectSID: project, TaskSID: task, TimestampStart: start, TimestampEnd: start.Add(d)}
+	}
+	blocks := []*model.Block{
+		mk("beta", "", day1, time.Hour),
+		mk("alpha", "api", day1.Add(2*time.Hour), 30*time.Minute),
+		mk("alpha", "ui", day2, 2*time.Hour),
+		mk("alpha", "api", day2.Add(3*time.Hour), 15*time.Minute),
+	}
+
+	assertSubtotals := func(t *testing.T, resp *GroupedBlocksResponse) {
+		var sum int64
+		count := 0
+		for _, g := range resp.Groups {
+			var groupSum int64
+			for _, b := range g.Blocks {
+				groupSum += b.DurationSeconds
+			}
+			assert.Equal(t, groupSum, g.DurationSeconds, g.Key)
+			assert.Equal(t, len(g.Blocks), g.Count)
+			sum += g.DurationSeconds
+			count += g.Count
+		}
+		assert.Equal(t, resp.TotalDurationSeconds, sum)
+		assert.Equal(t, resp.TotalCount, count)
+		assert.Equal(t, int64(13500), resp.TotalDurationSeconds)
+	}
+
+	keys := func(resp *GroupedBlocksResponse) []string {
+		var out []string
+		for _, g := range resp.Groups {
+			out = append(out, g.Key)
+		}
+		return out
+	}
+
+	t.Run("project", func(t *testing.T) {
+		resp, err := NewGroupedBlocksResponse(blocks, GroupByProject)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alpha", "beta"}, keys(resp))
+		assert.Equal(t, int64(9900), resp.Groups[0].DurationSeconds)
+		assertSubtotals(t, resp)
+	})
+
+	t.Run("task", func(t *testing.T) {
+		resp, err := NewGroupedBlocksResponse(blocks, GroupByTask)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alpha/api", "alpha/ui", "beta"}, keys(resp))
+		assert.Equal(t, 2, resp.Groups[0].Count)
+		assertSubtotals(t, resp)
+	})
+
+	t.Run("day", func(t *testing.T) {
+		resp, err := NewGroupedBlocksResponse(blocks, GroupByDay)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2025-03-10", "2025-03-11"}, keys(resp))
+		assert.Equal(t, int64(5400), resp.Groups[0].DurationSeconds)
+		assertSubtotals(t, resp)
+	})
+
+	t.Run("serializes", func(t *testing.T) {
+		resp, err := NewGroupedBlocksResponse(blocks, GroupByProject)
+		require.NoError(t, err)
+		data, err := json.Marshal(resp)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"group_by":"project"`)
+		assert.Contains(t, string(data), `"groups":[{"key":"alpha"`)
+	})
+
+	t.Run("empty_and_invalid", func(t *testing.T) {
+		resp, err := NewGroupedBlocksResponse(nil, GroupByDay)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Groups)
+
+		_, err = NewGroupedBlocksResponse(blocks, "week")
+		assert.Error(t, err)
+	})
+}
+
 func TestNewProjectOutput(t *testing.T) {
 	project := &model.Project{
 		SID:         "myproject",
@@ -700,6 +810,30 @@ func TestJSONFormatterPrintError(t *testing.T) {
 	assert.Equal(t, "Please try again", resp.Message)
 }
 
+func TestJSONFormatterPrintErrorResponse(t *testing.T) {
+	var buf bytes.Buffer
+	jf := NewJSONFormatter(&Formatter{Writer: &buf})
+
+	resp := NewErrorResponse("no_active_tracking", errors.New("no active tracking"), "Start tracking first")
+	require.NoError(t, jf.PrintErrorResponse(resp))
+
+	var fields map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &fields))
+	assert.Equal(t, map[string]string{
+		"status":  "error",
+		"error":   "no active tracking",
+		"code":    "no_active_tracking",
+		"message": "Start tracking first",
+	}, fields)
+
+	t.Run("code_always_present", func(t *testing.T) {
+		data, err := json.Marshal(NewErrorResponse("", errors.New("x"), ""))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"code":""`)
+		assert.NotContains(t, string(data), "message")
+	})
+}
+
 func TestJSONFormatterPrintBlocks(t *testing.T) {
 	var buf bytes.Buffer
 	f := &Formatter{Writer: &buf}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// timesheetLineRegex matches "HH:MM-HH:MM project[/task] [note]".
+var timesheetLineRegex = regexp.MustCompile(`^(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\s+(\S+)(?:\s+(.*))?$`)
+
+// ParseTimesheet parses one block per line in the form
+//
+//	09:00-10:30 project/task note
+//
+// on the calendar day of date in loc. The task and note are optional, and
+// an end time of 24:00 means midnight at the end of the day. Blank lines and
+// lines starting with # are ignored. Every block must end after it starts
+// and no two blocks may overlap; errors name the offending line. Blocks are
+// returned in line order.
+func ParseTimesheet(text string, date time.Time, loc *time.Location) ([]*model.Block, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	date = date.In(loc)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	var blocks []*model.Block
+	lineOf := make(map[*model.Block]int)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		match := timesheetLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: expected \"HH:MM-HH:MM project[/task] [note]\"", lineNum)
+		}
+
+		start, err := timesheetClock(day, match[1], match[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start time: %w", lineNum, err)
+		}
+		end, err := timesheetClock(day, match[3], match[4])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end time: %w", lineNum, err)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("line %d: end time %s:%s is not after start time %s:%s",
+				lineNum, match[3], match[4], match[1], match[2])
+		}
+
+		projectSID, taskSID := ParseProjectTask(match[5])
+		if !ValidateSID(projectSID) {
+			return nil, fmt.Errorf("line %d: invalid project %q", lineNum, projectSID)
+		}
+		if taskSID != "" && !ValidateSID(taskSID) {
+			return nil, fmt.Errorf("line %d: invalid task %q", lineNum, taskSID)
+		}
+
+		block := model.NewBlock("", projectSID, taskSID, strings.TrimSpace(match[6]), start)
+		block.TimestampEnd = end
+		blocks = append(blocks, block)
+		lineOf[block] = lineNum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ordered := append([]*model.Block(nil), blocks...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].TimestampStart.Before(ordered[j].TimestampStart)
+	})
+	for i := 1; i < len(ordered); i++ {
+		prev, cur := ordered[i-1], ordered[i]
+		if cur.OverlapsWith(prev) {
+			first, second := lineOf[prev], lineOf[cur]
+			if first > second {
+				first, second = second, first
+			}
+			return nil, fmt.Errorf("line %d: overlaps line %d", second, first)
+		}
+	}
+
+	return blocks, nil
+}
+
+// timesheetClock returns the time hh:mm on day. 24:00 is the following midnight.
+func timesheetClock(day time.Time, hh, mm string) (time.Time, error) {
+	hour, _ := strconv.Atoi(hh)
+	minute, _ := strconv.Atoi(mm)
+	if minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return time.Time{}, fmt.Errorf("%s:%s is not a time of day", hh, mm)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location()), nil
+}
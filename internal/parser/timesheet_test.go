@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimesheet(t *testing.T) {
+	date := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	text := `
+# Monday
+09:00-10:30 clientwork/api design review
+10:30-12:00 clientwork
+13:00 - 17:45 internal/docs  write the handbook
+22:00-24:00 oncall
+`
+	blocks, err := ParseTimesheet(text, date, time.UTC)
+	require.NoError(t, err)
+	require.Len(t, blocks, 4)
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "clientwork", blocks[0].ProjectSID)
+	assert.Equal(t, "api", blocks[0].TaskSID)
+	assert.Equal(t, "design review", blocks[0].Note)
+	assert.Equal(t, day.Add(9*time.Hour), blocks[0].TimestampStart)
+	assert.Equal(t, day.Add(10*time.Hour+30*time.Minute), blocks[0].TimestampEnd)
+
+	assert.Equal(t, "", blocks[1].TaskSID)
+	assert.Equal(t, "", blocks[1].Note)
+
+	assert.Equal(t, "docs", blocks[2].TaskSID)
+	assert.Equal(t, "write the handbook", blocks[2].Note)
+	assert.Equal(t, 4*time.Hour+45*time.Minute, blocks[2].Duration())
+
+	assert.Equal(t, day.AddDate(0, 0, 1), blocks[3].TimestampEnd)
+}
+
+func TestParseTimesheetErrors(t *testing.T) {
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"overlap", "09:00-10:30 a\n10:00-11:00 b", "line 2: overlaps line 1"},
+		{"overlap_out_of_order", "13:00-14:00 a\n\n09:00-10:00 b\n13:30-15:00 c", "line 4: overlaps line 1"},
+		{"end_before_start", "09:00-10:00 a\n11:00-10:00 b", "line 2: end time"},
+		{"bad_format", "9am-10am a", "line 1: expected"},
+		{"bad_time", "09:00-10:75 a", "line 1: invalid end time"},
+		{"bad_project", "09:00-10:00 edit", "line 1: invalid project"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTimesheet(tt.text, date, time.UTC)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
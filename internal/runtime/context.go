@@ -18,6 +18,7 @@ type Context struct {
 	ProjectRepo     *storage.ProjectRepo
 	ActiveBlockRepo *storage.ActiveBlockRepo
 	UndoRepo        *storage.UndoRepo
+	ConfigRepo      *storage.ConfigRepo
 
 	// Debug mode
 	Debug bool
@@ -68,6 +69,7 @@ func New(opts Options) (*Context, error) {
 	projectRepo := storage.NewProjectRepo(db)
 	activeBlockRepo := storage.NewActiveBlockRepo(db)
 	undoRepo := storage.NewUndoRepo(db)
+	configRepo := storage.NewConfigRepo(db)
 
 	// Create formatter
 	formatter := output.NewFormatter()
@@ -81,6 +83,7 @@ func New(opts Options) (*Context, error) {
 		ProjectRepo:     projectRepo,
 		ActiveBlockRepo: activeBlockRepo,
 		UndoRepo:        undoRepo,
+		ConfigRepo:      configRepo,
 		Debug:           opts.Debug,
 	}, nil
 }
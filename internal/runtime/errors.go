@@ -72,6 +72,61 @@ var Suggestions = map[error]string{
 	ErrDiskFull:         "Free up disk space and try again. Your active tracking state is preserved in memory.",
 }
 
+// Error codes reported in JSON error output. They are part of the scripting
+// interface, so existing codes must not change.
+const (
+	CodeError            = "error"
+	CodeValidation       = "validation_error"
+	CodeParse            = "parse_error"
+	CodeNoActiveTracking = "no_active_tracking"
+	CodeBlockNotFound    = "block_not_found"
+	CodeProjectNotFound  = "project_not_found"
+	CodeProjectRequired  = "project_required"
+	CodeInvalidSID       = "invalid_sid"
+	CodeInvalidTimestamp = "invalid_timestamp"
+	CodeEndBeforeStart   = "end_before_start"
+	CodeInvalidColor     = "invalid_color"
+	CodeInvalidDuration  = "invalid_duration"
+	CodeDiskFull         = "disk_full"
+)
+
+// ErrorCodes maps common errors to their JSON error codes.
+var ErrorCodes = map[error]string{
+	ErrNoActiveTracking: CodeNoActiveTracking,
+	ErrProjectRequired:  CodeProjectRequired,
+	ErrInvalidSID:       CodeInvalidSID,
+	ErrInvalidTimestamp: CodeInvalidTimestamp,
+	ErrEndBeforeStart:   CodeEndBeforeStart,
+	ErrBlockNotFound:    CodeBlockNotFound,
+	ErrProjectNotFound:  CodeProjectNotFound,
+	ErrInvalidColor:     CodeInvalidColor,
+	ErrInvalidDuration:  CodeInvalidDuration,
+	ErrDiskFull:         CodeDiskFull,
+}
+
+// ErrorCode returns the JSON error code for an error, or CodeError if it
+// has none of its own.
+func ErrorCode(err error) string {
+	for knownErr, code := range ErrorCodes {
+		if errors.Is(err, knownErr) {
+			return code
+		}
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return CodeValidation
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return CodeParse
+	}
+	if IsDiskFullError(err) {
+		return CodeDiskFull
+	}
+	return CodeError
+}
+
 // GetSuggestion returns a suggestion for an error, if available.
 func GetSuggestion(err error) string {
 	for knownErr, suggestion := range Suggestions {
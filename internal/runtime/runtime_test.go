@@ -264,6 +264,26 @@ func TestSuggestionsMap(t *testing.T) {
 	}
 }
 
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrNoActiveTracking, CodeNoActiveTracking},
+		{fmt.Errorf("stopping: %w", ErrNoActiveTracking), CodeNoActiveTracking},
+		{ErrBlockNotFound, CodeBlockNotFound},
+		{NewValidationError("time", "must fall inside the block"), CodeValidation},
+		{NewParseError("duration", "xyz", "unknown unit"), CodeParse},
+		{NewDiskFullError("write", "", errors.New("no space")), CodeDiskFull},
+		{errors.New("something else"), CodeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorCode(tt.err))
+		})
+	}
+}
+
 // =============================================================================
 // DiskFullError Tests
 // =============================================================================
@@ -1,9 +1,21 @@
 package storage
 
 import (
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v4"
 	"github.com/manav03panchal/humantime/internal/model"
 )
 
+// ErrActiveBlockConflict is returned when the active block record was modified
+// by another writer between read and write. The operation can be retried.
+var ErrActiveBlockConflict = errors.New("active block modified concurrently")
+
+// IsErrConflict returns true if the error is a retryable write conflict.
+func IsErrConflict(err error) bool {
+	return errors.Is(err, ErrActiveBlockConflict) || errors.Is(err, badger.ErrConflict)
+}
+
 // ActiveBlockRepo provides operations for the ActiveBlock singleton.
 type ActiveBlockRepo struct {
 	db *DB
@@ -28,13 +40,49 @@ func (r *ActiveBlockRepo) Get() (*model.ActiveBlock, error) {
 	return active, nil
 }
 
-// Save persists the active block state.
+// Save persists the active block state unconditionally, bumping its revision.
+// Prefer SetActive/ClearActive or CompareAndSwap for concurrent-safe updates.
 func (r *ActiveBlockRepo) Save(active *model.ActiveBlock) error {
 	active.Key = model.KeyActiveBlock
+	active.Revision++
 	return r.db.Set(active)
 }
 
+// CompareAndSwap applies mutate to the stored active block state and saves it,
+// provided the stored revision still equals expectedRevision. The read, check,
+// and write happen in a single transaction. Returns ErrActiveBlockConflict if
+// the revision has moved on or a concurrent writer committed first.
+func (r *ActiveBlockRepo) CompareAndSwap(expectedRevision int64, mutate func(*model.ActiveBlock)) error {
+	return r.update(&expectedRevision, mutate)
+}
+
+// update runs mutate against the current state within a transaction. If
+// expectedRevision is non-nil, the stored revision must match it.
+func (r *ActiveBlockRepo) update(expectedRevision *int64, mutate func(*model.ActiveBlock)) error {
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		active := model.NewActiveBlock()
+		if err := getTxn(txn, model.KeyActiveBlock, active); err != nil && !IsErrKeyNotFound(err) {
+			return err
+		}
+
+		if expectedRevision != nil && active.Revision != *expectedRevision {
+			return ErrActiveBlockConflict
+		}
+
+		mutate(active)
+		active.Key = model.KeyActiveBlock
+		active.Revision++
+		return setTxn(txn, active)
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return ErrActiveBlockConflict
+	}
+	return err
+}
+
 // GetActiveBlock retrieves the currently active block, if any.
+// If the active pointer refers to a block that no longer exists, the pointer
+// is cleared and nil is returned.
 func (r *ActiveBlockRepo) GetActiveBlock(blockRepo *BlockRepo) (*model.Block, error) {
 	active, err := r.Get()
 	if err != nil {
@@ -45,32 +93,125 @@ func (r *ActiveBlockRepo) GetActiveBlock(blockRepo *BlockRepo) (*model.Block, er
 		return nil, nil
 	}
 
-	return blockRepo.Get(active.ActiveBlockKey)
+	block, err := blockRepo.Get(active.ActiveBlockKey)
+	if IsErrKeyNotFound(err) {
+		danglingKey := active.ActiveBlockKey
+		err := r.CompareAndSwap(active.Revision, func(a *model.ActiveBlock) {
+			a.ActiveBlockKey = ""
+			a.RecentBlockKeys = removeKey(a.RecentBlockKeys, danglingKey)
+		})
+		if err != nil && !IsErrConflict(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return block, err
 }
 
-// SetActiveBlock sets the given block as active.
-func (r *ActiveBlockRepo) SetActiveBlock(block *model.Block) error {
-	active, err := r.Get()
+// ActiveBlocks returns the active block as a one-element slice, or an empty
+// slice when nothing is being tracked, for use with the export functions.
+func (r *ActiveBlockRepo) ActiveBlocks(blockRepo *BlockRepo) ([]*model.Block, error) {
+	block, err := r.GetActiveBlock(blockRepo)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	active.SetActive(block.Key)
-	return r.Save(active)
+	if block == nil {
+		return []*model.Block{}, nil
+	}
+	return []*model.Block{block}, nil
 }
 
-// ClearActiveBlock clears the active block.
-func (r *ActiveBlockRepo) ClearActiveBlock() error {
-	active, err := r.Get()
+// Repair removes references to blocks that no longer exist: the active
+// pointer, the previous pointer, and entries in the recent list. Returns
+// true if anything was changed.
+func (r *ActiveBlockRepo) Repair() (bool, error) {
+	repaired := false
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		active := model.NewActiveBlock()
+		if err := getTxn(txn, model.KeyActiveBlock, active); err != nil {
+			if IsErrKeyNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		exists := func(key string) (bool, error) {
+			if key == "" {
+				return true, nil
+			}
+			_, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return false, nil
+			}
+			return err == nil, err
+		}
+
+		for _, ptr := range []*string{&active.ActiveBlockKey, &active.PreviousBlockKey} {
+			ok, err := exists(*ptr)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				*ptr = ""
+				repaired = true
+			}
+		}
+
+		recent := active.RecentBlockKeys[:0]
+		for _, key := range active.RecentBlockKeys {
+			ok, err := exists(key)
+			if err != nil {
+				return err
+			}
+			if ok {
+				recent = append(recent, key)
+			} else {
+				repaired = true
+			}
+		}
+		active.RecentBlockKeys = recent
+
+		if !repaired {
+			return nil
+		}
+		active.Revision++
+		return setTxn(txn, active)
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return false, ErrActiveBlockConflict
+	}
 	if err != nil {
-		return err
+		return false, err
 	}
+	return repaired, nil
+}
 
-	active.ClearActive()
-	return r.Save(active)
+// removeKey returns keys without any occurrence of key.
+func removeKey(keys []string, key string) []string {
+	var kept []string
+	for _, k := range keys {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	return kept
+}
+
+// SetActiveBlock sets the given block as active.
+func (r *ActiveBlockRepo) SetActiveBlock(block *model.Block) error {
+	return r.SetActive(block.Key)
+}
+
+// ClearActiveBlock clears the active block.
+// Returns ErrActiveBlockConflict if a concurrent writer got there first.
+func (r *ActiveBlockRepo) ClearActiveBlock() error {
+	return r.update(nil, func(active *model.ActiveBlock) {
+		active.ClearActive()
+	})
 }
 
 // GetPreviousBlock retrieves the previous block (for resume functionality).
+// Returns nil if there is no previous block or it has been deleted.
 func (r *ActiveBlockRepo) GetPreviousBlock(blockRepo *BlockRepo) (*model.Block, error) {
 	active, err := r.Get()
 	if err != nil {
@@ -81,18 +222,45 @@ func (r *ActiveBlockRepo) GetPreviousBlock(blockRepo *BlockRepo) (*model.Block,
 		return nil, nil
 	}
 
-	return blockRepo.Get(active.PreviousBlockKey)
+	block, err := blockRepo.Get(active.PreviousBlockKey)
+	if IsErrKeyNotFound(err) {
+		// Previous block was deleted; nothing to resume
+		return nil, nil
+	}
+	return block, err
 }
 
-// SetActive sets the given block key as active (convenience method).
-func (r *ActiveBlockRepo) SetActive(blockKey string) error {
+// RecentBlocks retrieves up to n recently-active blocks, most recent first.
+// Keys whose blocks have since been deleted are skipped.
+func (r *ActiveBlockRepo) RecentBlocks(blockRepo *BlockRepo, n int) ([]*model.Block, error) {
 	active, err := r.Get()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	active.SetActive(blockKey)
-	return r.Save(active)
+	var blocks []*model.Block
+	for _, key := range active.RecentBlockKeys {
+		if n > 0 && len(blocks) >= n {
+			break
+		}
+		block, err := blockRepo.Get(key)
+		if err != nil {
+			if IsErrKeyNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// SetActive sets the given block key as active (convenience method).
+// Returns ErrActiveBlockConflict if a concurrent writer got there first.
+func (r *ActiveBlockRepo) SetActive(blockKey string) error {
+	return r.update(nil, func(active *model.ActiveBlock) {
+		active.SetActive(blockKey)
+	})
 }
 
 // ClearActive clears the active block (convenience method).
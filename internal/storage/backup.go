@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// BackupManifestSuffix is appended to a backup file name to name its manifest.
+const BackupManifestSuffix = ".manifest.json"
+
+// ErrBackupMismatch is returned when a backup does not match its manifest.
+var ErrBackupMismatch = errors.New("backup does not match manifest")
+
+// Backup is a full export of the database.
+type Backup struct {
+	Version     string             `json:"version"`
+	ExportedAt  string             `json:"exported_at"`
+	Projects    []*model.Project   `json:"projects"`
+	Blocks      []*model.Block     `json:"blocks"`
+	ActiveBlock *model.ActiveBlock `json:"active_block"`
+}
+
+// BackupCounts holds the number of entities in a backup.
+type BackupCounts struct {
+	Projects int `json:"projects"`
+	Blocks   int `json:"blocks"`
+}
+
+// BackupManifest describes a backup payload so it can be verified later.
+type BackupManifest struct {
+	Version   string       `json:"version"`
+	CreatedAt string       `json:"created_at"`
+	SHA256    string       `json:"sha256"`
+	Size      int64        `json:"size"`
+	Counts    BackupCounts `json:"counts"`
+}
+
+// NewBackup builds a backup from the contents of the database.
+func NewBackup(db *DB) (*Backup, error) {
+	projects, err := NewProjectRepo(db).List()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := NewBlockRepo(db).List()
+	if err != nil {
+		return nil, err
+	}
+
+	activeBlock, err := NewActiveBlockRepo(db).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backup{
+		Version:     ExportVersion,
+		ExportedAt:  time.Now().Format(time.RFC3339),
+		Projects:    projects,
+		Blocks:      blocks,
+		ActiveBlock: activeBlock,
+	}, nil
+}
+
+// Encode returns the backup as indented JSON.
+func (b *Backup) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// gzipMagic is the header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsCompressed reports whether data is gzip-compressed.
+func IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+// Compress gzips an export or backup payload.
+func Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress returns data with gzip compression removed. Uncompressed data
+// is returned unchanged, so callers need not know how a file was written.
+func Decompress(data []byte) ([]byte, error) {
+	if !IsCompressed(data) {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	return out, nil
+}
+
+// NewBackupManifest computes the manifest for an encoded backup payload.
+func NewBackupManifest(data []byte) (*BackupManifest, error) {
+	counts, err := backupCounts(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &BackupManifest{
+		Version:   ExportVersion,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Size:      int64(len(data)),
+		Counts:    counts,
+	}, nil
+}
+
+// VerifyBackup recomputes the checksum and entity counts of a backup payload
+// and compares them with the manifest.
+func VerifyBackup(data []byte, manifest *BackupManifest) error {
+	if manifest == nil {
+		return fmt.Errorf("%w: manifest is empty", ErrBackupMismatch)
+	}
+
+	if int64(len(data)) != manifest.Size {
+		return fmt.Errorf("%w: size is %d bytes, expected %d", ErrBackupMismatch, len(data), manifest.Size)
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != manifest.SHA256 {
+		return fmt.Errorf("%w: sha256 is %s, expected %s", ErrBackupMismatch, got, manifest.SHA256)
+	}
+
+	counts, err := backupCounts(data)
+	if err != nil {
+		return err
+	}
+	if counts != manifest.Counts {
+		return fmt.Errorf("%w: contains %d projects and %d blocks, expected %d and %d",
+			ErrBackupMismatch, counts.Projects, counts.Blocks, manifest.Counts.Projects, manifest.Counts.Blocks)
+	}
+
+	return nil
+}
+
+// backupCounts parses a backup payload and counts its entities.
+func backupCounts(data []byte) (BackupCounts, error) {
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return BackupCounts{}, fmt.Errorf("failed to parse backup: %w", err)
+	}
+	return BackupCounts{
+		Projects: len(backup.Projects),
+		Blocks:   len(backup.Blocks),
+	}, nil
+}
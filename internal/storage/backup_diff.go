@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EntityChange names an entity present in both backups and the fields, by
+// their JSON names, whose values differ.
+type EntityChange struct {
+	Key    string   `json:"key"`
+	Fields []string `json:"fields"`
+}
+
+// CollectionDiff lists the differences in one collection of a backup.
+// Entities are identified by key and listed in key order.
+type CollectionDiff struct {
+	Added    []string       `json:"added,omitempty"`
+	Removed  []string       `json:"removed,omitempty"`
+	Modified []EntityChange `json:"modified,omitempty"`
+}
+
+// Empty reports whether the collection is unchanged.
+func (d CollectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// BackupDiff lists what changed between two backups. Projects are keyed by
+// SID and blocks by key.
+type BackupDiff struct {
+	Projects CollectionDiff `json:"projects"`
+	Blocks   CollectionDiff `json:"blocks"`
+}
+
+// Empty reports whether the backups hold the same projects and blocks.
+func (d BackupDiff) Empty() bool {
+	return d.Projects.Empty() && d.Blocks.Empty()
+}
+
+// DiffBackups compares backup b against an earlier backup a. Entities only in
+// b are added, those only in a are removed, and those in both with differing
+// fields are modified. The active block pointer is not compared.
+func DiffBackups(a, b *Backup) BackupDiff {
+	return BackupDiff{
+		Projects: diffCollection(projectsBySID(a), projectsBySID(b)),
+		Blocks:   diffCollection(blocksByKey(a), blocksByKey(b)),
+	}
+}
+
+func projectsBySID(backup *Backup) map[string]interface{} {
+	m := make(map[string]interface{})
+	if backup != nil {
+		for _, p := range backup.Projects {
+			m[p.SID] = p
+		}
+	}
+	return m
+}
+
+func blocksByKey(backup *Backup) map[string]interface{} {
+	m := make(map[string]interface{})
+	if backup != nil {
+		for _, b := range backup.Blocks {
+			m[b.Key] = b
+		}
+	}
+	return m
+}
+
+// diffCollection compares two sets of entities keyed by identity.
+func diffCollection(a, b map[string]interface{}) CollectionDiff {
+	var diff CollectionDiff
+	for key, before := range a {
+		after, ok := b[key]
+		if !ok {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		if fields := changedFields(before, after); len(fields) > 0 {
+			diff.Modified = append(diff.Modified, EntityChange{Key: key, Fields: fields})
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Modified, func(i, j int) bool {
+		return diff.Modified[i].Key < diff.Modified[j].Key
+	})
+	return diff
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// changedFields returns the JSON names of the exported fields that differ
+// between two pointers to the same struct type. Times are compared as
+// instants, and nil and empty slices are treated as equal.
+func changedFields(a, b interface{}) []string {
+	va := reflect.ValueOf(a).Elem()
+	vb := reflect.ValueOf(b).Elem()
+
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fa, fb := va.Field(i), vb.Field(i)
+
+		var equal bool
+		switch {
+		case field.Type == timeType:
+			equal = fa.Interface().(time.Time).Equal(fb.Interface().(time.Time))
+		case field.Type.Kind() == reflect.Slice && fa.Len() == 0 && fb.Len() == 0:
+			equal = true
+		default:
+			equal = reflect.DeepEqual(fa.Interface(), fb.Interface())
+		}
+		if !equal {
+			fields = append(fields, jsonFieldName(field))
+		}
+	}
+	return fields
+}
+
+// jsonFieldName returns the name a struct field is encoded under.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Backup Diff Tests
+// =============================================================================
+
+func TestDiffBackups(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	kept := &model.Block{Key: "block:kept", ProjectSID: "alpha", TimestampStart: start, TimestampEnd: start.Add(time.Hour)}
+	gone := &model.Block{Key: "block:gone", ProjectSID: "alpha", TimestampStart: start.Add(2 * time.Hour), TimestampEnd: start.Add(3 * time.Hour)}
+
+	a := &Backup{
+		Projects: []*model.Project{
+			model.NewProject("alpha", "Alpha", "#111111"),
+			model.NewProject("beta", "Beta", "#222222"),
+		},
+		Blocks: []*model.Block{kept, gone},
+	}
+
+	// Round-trip a through JSON so b is an independent copy, as if read
+	// from a second backup file
+	data, err := a.Encode()
+	require.NoError(t, err)
+	b := &Backup{}
+	require.NoError(t, json.Unmarshal(data, b))
+
+	t.Run("identical", func(t *testing.T) {
+		assert.True(t, DiffBackups(a, b).Empty())
+	})
+
+	b.Projects[0].DisplayName = "Alpha Corp"
+	b.Projects = append(b.Projects, model.NewProject("gamma", "Gamma", ""))
+	b.Blocks = b.Blocks[:1]
+	b.Blocks[0].Note = "edited"
+	b.Blocks[0].TimestampEnd = b.Blocks[0].TimestampEnd.In(time.FixedZone("UTC+2", 2*60*60))
+
+	diff := DiffBackups(a, b)
+	assert.False(t, diff.Empty())
+
+	assert.Equal(t, []string{"gamma"}, diff.Projects.Added)
+	assert.Empty(t, diff.Projects.Removed)
+	assert.Equal(t, []EntityChange{{Key: "alpha", Fields: []string{"display_name"}}}, diff.Projects.Modified)
+
+	assert.Empty(t, diff.Blocks.Added)
+	assert.Equal(t, []string{"block:gone"}, diff.Blocks.Removed)
+	// The end time only moved zone, so it is not a change
+	assert.Equal(t, []EntityChange{{Key: "block:kept", Fields: []string{"note"}}}, diff.Blocks.Modified)
+
+	t.Run("reversed", func(t *testing.T) {
+		reversed := DiffBackups(b, a)
+		assert.Equal(t, []string{"gamma"}, reversed.Projects.Removed)
+		assert.Equal(t, []string{"block:gone"}, reversed.Blocks.Added)
+	})
+}
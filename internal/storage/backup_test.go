@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodedTestBackup builds and encodes a backup of a small database.
+func encodedTestBackup(t *testing.T) []byte {
+	t.Helper()
+	db := setupTestDB(t)
+
+	_, _, err := NewProjectRepo(db).GetOrCreate("alpha", "Alpha")
+	require.NoError(t, err)
+
+	blockRepo := NewBlockRepo(db)
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		block := model.NewBlock("", "alpha", "", "work", start.Add(time.Duration(i)*time.Hour))
+		block.TimestampEnd = block.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, blockRepo.Create(block))
+	}
+
+	backup, err := NewBackup(db)
+	require.NoError(t, err)
+	data, err := backup.Encode()
+	require.NoError(t, err)
+	return data
+}
+
+// =============================================================================
+// Backup Manifest Tests
+// =============================================================================
+
+func TestBackupManifest(t *testing.T) {
+	data := encodedTestBackup(t)
+
+	manifest, err := NewBackupManifest(data)
+	require.NoError(t, err)
+	assert.Len(t, manifest.SHA256, 64)
+	assert.Equal(t, int64(len(data)), manifest.Size)
+	assert.Equal(t, BackupCounts{Projects: 1, Blocks: 3}, manifest.Counts)
+
+	t.Run("untouched_backup_verifies", func(t *testing.T) {
+		assert.NoError(t, VerifyBackup(data, manifest))
+	})
+
+	t.Run("tampered_byte_fails", func(t *testing.T) {
+		for _, i := range []int{0, len(data) / 2, len(data) - 1} {
+			tampered := append([]byte(nil), data...)
+			tampered[i] ^= 0x01
+			err := VerifyBackup(tampered, manifest)
+			assert.True(t, errors.Is(err, ErrBackupMismatch), "byte %d: %v", i, err)
+		}
+	})
+
+	t.Run("truncated_fails", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyBackup(data[:len(data)-1], manifest), ErrBackupMismatch)
+	})
+
+	t.Run("wrong_counts_fail", func(t *testing.T) {
+		wrong := *manifest
+		wrong.Counts.Blocks = 2
+		assert.ErrorIs(t, VerifyBackup(data, &wrong), ErrBackupMismatch)
+	})
+
+	t.Run("nil_manifest_fails", func(t *testing.T) {
+		assert.ErrorIs(t, VerifyBackup(data, nil), ErrBackupMismatch)
+	})
+}
+
+func TestNewBackupManifestInvalidPayload(t *testing.T) {
+	_, err := NewBackupManifest([]byte("not json"))
+	assert.Error(t, err)
+}
+
+// =============================================================================
+// Compression Tests
+// =============================================================================
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := encodedTestBackup(t)
+
+	compressed, err := Compress(data)
+	require.NoError(t, err)
+	assert.True(t, IsCompressed(compressed))
+	assert.Less(t, len(compressed), len(data))
+
+	decompressed, err := Decompress(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+
+	var backup Backup
+	require.NoError(t, json.Unmarshal(decompressed, &backup))
+	assert.Len(t, backup.Blocks, 3)
+}
+
+func TestDecompressUncompressedInput(t *testing.T) {
+	data := encodedTestBackup(t)
+	assert.False(t, IsCompressed(data))
+
+	out, err := Decompress(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+
+	manifest, err := NewBackupManifest(out)
+	require.NoError(t, err)
+	assert.Equal(t, 3, manifest.Counts.Blocks)
+}
+
+func TestDecompressCorruptInput(t *testing.T) {
+	_, err := Decompress([]byte{0x1f, 0x8b, 0x00, 0x01})
+	assert.Error(t, err)
+}
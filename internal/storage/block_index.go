@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/manav03panchal/humantime/internal/logging"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// Secondary indexes over blocks. Each index entry is a key with an empty
+// value whose suffix is the block key, so a lookup reads only the matching
+// blocks; the unique external ID index instead maps each ID to its block key.
+// Entries are written in the same transaction as the block itself.
+const (
+	// tagIndexPrefix keys are tagindex:<lowercased tag>:<block key>.
+	tagIndexPrefix = "tagindex:"
+	// projectIndexPrefix keys are projectindex:<project SID>:<block key>.
+	projectIndexPrefix = "projectindex:"
+	// dayIndexPrefix keys are dayindex:<yyyymmdd>:<block key>, one for each
+	// UTC day a block touches. Active blocks are listed under their start day
+	// and under dayindex:open: until they are stopped.
+	dayIndexPrefix = "dayindex:"
+	// externalIndexPrefix keys are externalindex:<external ID>, holding the
+	// block key.
+	externalIndexPrefix = "externalindex:"
+
+	// blockIndexVersionKey records which index layout the database holds.
+	// Bump blockIndexVersion whenever an index is added or changed so
+	// existing databases are reindexed on open.
+	blockIndexVersionKey = "blockindex:version"
+	blockIndexVersion    = "4"
+)
+
+// ErrDuplicateExternalID is returned when a block is stored with an external
+// ID that already belongs to another block.
+var ErrDuplicateExternalID = errors.New("external ID already belongs to another block")
+
+// blockIndexPrefixes lists the prefixes of every block index.
+var blockIndexPrefixes = []string{tagIndexPrefix, projectIndexPrefix, dayIndexPrefix, externalIndexPrefix}
+
+// isBlockIndexKey reports whether key belongs to a block index. Index
+// entries are derived data and can always be rebuilt from the blocks.
+func isBlockIndexKey(key string) bool {
+	if key == blockIndexVersionKey {
+		return true
+	}
+	for _, prefix := range blockIndexPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagIndexKeyPrefix returns the prefix of the index entries for a tag.
+func tagIndexKeyPrefix(tag string) string {
+	return tagIndexPrefix + strings.ToLower(tag) + ":"
+}
+
+// projectIndexKeyPrefix returns the prefix of the index entries for a project.
+func projectIndexKeyPrefix(projectSID string) string {
+	return projectIndexPrefix + projectSID + ":"
+}
+
+// externalIndexKey returns the index entry for an external ID.
+func externalIndexKey(externalID string) string {
+	return externalIndexPrefix + externalID
+}
+
+// dayIndexBucket returns the day index bucket holding t.
+func dayIndexBucket(t time.Time) string {
+	return dayIndexPrefix + t.UTC().Format("20060102") + ":"
+}
+
+// dayIndexOpenBucket holds the active blocks, whose last day is not known.
+const dayIndexOpenBucket = dayIndexPrefix + "open:"
+
+// dayIndexBuckets returns the day index buckets a block belongs to.
+func dayIndexBuckets(b *model.Block) []string {
+	if b.IsActive() {
+		return []string{dayIndexBucket(b.TimestampStart), dayIndexOpenBucket}
+	}
+
+	first := b.TimestampStart.UTC()
+	last := b.TimestampEnd.UTC()
+	if last.After(first) {
+		// The end is exclusive, so a block ending at midnight stays out
+		// of the next day's bucket
+		last = last.Add(-time.Nanosecond)
+	}
+
+	buckets := []string{dayIndexBucket(first)}
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	for day = day.AddDate(0, 0, 1); !day.After(last); day = day.AddDate(0, 0, 1) {
+		buckets = append(buckets, dayIndexBucket(day))
+	}
+	return buckets
+}
+
+// blockIndexKeys returns every index entry for a block.
+func blockIndexKeys(b *model.Block) []string {
+	keys := make([]string, 0, len(b.Tags)+1)
+	keys = append(keys, projectIndexKeyPrefix(b.ProjectSID)+b.Key)
+	for _, bucket := range dayIndexBuckets(b) {
+		keys = append(keys, bucket+b.Key)
+	}
+	if b.ExternalID != "" {
+		keys = append(keys, externalIndexKey(b.ExternalID))
+	}
+	seen := make(map[string]bool, len(b.Tags))
+	for _, tag := range b.Tags {
+		key := tagIndexKeyPrefix(tag) + b.Key
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// blockIndexValue returns the value stored under a block's index entry.
+func blockIndexValue(key string, b *model.Block) []byte {
+	if strings.HasPrefix(key, externalIndexPrefix) {
+		return []byte(b.Key)
+	}
+	return nil
+}
+
+// putBlockTxn stores a block, stamping its UpdatedAt, and brings its index
+// entries in line with it, removing entries left over from the previously
+// stored version. It fails with ErrDuplicateExternalID if another block has
+// the same external ID.
+func putBlockTxn(txn *badger.Txn, b *model.Block) error {
+	if b.ExternalID != "" {
+		owner, err := getByExternalIDTxn(txn, b.ExternalID)
+		if err != nil {
+			return err
+		}
+		if owner != nil && owner.Key != b.Key {
+			return fmt.Errorf("%w: %q is used by %s", ErrDuplicateExternalID, b.ExternalID, owner.Key)
+		}
+	}
+
+	b.UpdatedAt = time.Now()
+	newKeys := blockIndexKeys(b)
+
+	old := &model.Block{}
+	err := getTxn(txn, b.Key, old)
+	if err != nil && !IsErrKeyNotFound(err) {
+		return err
+	}
+	if err == nil {
+		keep := make(map[string]bool, len(newKeys))
+		for _, key := range newKeys {
+			keep[key] = true
+		}
+		for _, key := range blockIndexKeys(old) {
+			if keep[key] {
+				continue
+			}
+			if err := deleteIndexEntryTxn(txn, key, old); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := setTxn(txn, b); err != nil {
+		return err
+	}
+	for _, key := range newKeys {
+		if err := txn.Set([]byte(key), blockIndexValue(key, b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBlockTxn removes a stored block and its index entries.
+func deleteBlockTxn(txn *badger.Txn, b *model.Block) error {
+	for _, key := range blockIndexKeys(b) {
+		if err := deleteIndexEntryTxn(txn, key, b); err != nil {
+			return err
+		}
+	}
+	return txn.Delete([]byte(b.Key))
+}
+
+// deleteIndexEntryTxn removes a block's index entry. An external ID entry
+// is only removed while it still names the block, so deleting one block
+// never drops the entry another block relies on.
+func deleteIndexEntryTxn(txn *badger.Txn, key string, b *model.Block) error {
+	if strings.HasPrefix(key, externalIndexPrefix) {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		owner, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if string(owner) != b.Key {
+			return nil
+		}
+	}
+	return txn.Delete([]byte(key))
+}
+
+// putBlock stores a block and its index entries in one transaction.
+func (r *BlockRepo) putBlock(b *model.Block) error {
+	return r.db.db.Update(func(txn *badger.Txn) error {
+		return putBlockTxn(txn, b)
+	})
+}
+
+// listByIndex returns the blocks named by the index entries under prefix,
+// in block key order. Entries whose block no longer exists are skipped.
+func (r *BlockRepo) listByIndex(prefix string) ([]*model.Block, error) {
+	var blocks []*model.Block
+	err := r.db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefixBytes := []byte(prefix)
+		for it.Seek(prefixBytes); it.ValidForPrefix(prefixBytes); it.Next() {
+			blockKey := string(it.Item().Key()[len(prefixBytes):])
+			b := &model.Block{}
+			if err := getTxn(txn, blockKey, b); err != nil {
+				if IsErrKeyNotFound(err) {
+					continue
+				}
+				return err
+			}
+			blocks = append(blocks, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// getByExternalIDTxn returns the block with the external ID, or nil if there
+// is none. The lookup is recorded as a read of the index entry, so two
+// transactions claiming the same ID conflict.
+func getByExternalIDTxn(txn *badger.Txn, externalID string) (*model.Block, error) {
+	item, err := txn.Get([]byte(externalIndexKey(externalID)))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	blockKey, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &model.Block{}
+	if err := getTxn(txn, string(blockKey), b); err != nil {
+		if IsErrKeyNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// listByDayIndex returns the blocks listed in the day buckets from start's day
+// through end's day and in the open bucket, in block key order. The result
+// is a superset of the blocks overlapping [start, end).
+func (r *BlockRepo) listByDayIndex(start, end time.Time) ([]*model.Block, error) {
+	keys := make(map[string]bool)
+	// Every bucket in a scanned run has the same length, so the block key
+	// starts at a fixed offset
+	collect := func(txn *badger.Txn, from, to string, bucketLen int) {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(from)); it.Valid(); it.Next() {
+			key := string(it.Item().Key())
+			if key >= to {
+				break
+			}
+			keys[key[bucketLen:]] = true
+		}
+	}
+
+	var blocks []*model.Block
+	err := r.db.db.View(func(txn *badger.Txn) error {
+		// Buckets sort by date, so one pass covers the whole range
+		first := dayIndexBucket(start)
+		collect(txn, first, dayIndexBucket(end)+"\xff", len(first))
+		collect(txn, dayIndexOpenBucket, dayIndexOpenBucket+"\xff", len(dayIndexOpenBucket))
+
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+
+		for _, key := range sorted {
+			b := &model.Block{}
+			if err := getTxn(txn, key, b); err != nil {
+				if IsErrKeyNotFound(err) {
+					continue
+				}
+				return err
+			}
+			blocks = append(blocks, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// ensureBlockIndexes rebuilds the block indexes unless they are already at
+// the current version.
+func ensureBlockIndexes(d *DB) error {
+	var version string
+	err := d.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(blockIndexVersionKey))
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		version = string(val)
+		return err
+	})
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return err
+	}
+	if version == blockIndexVersion {
+		return nil
+	}
+	return rebuildBlockIndexes(d)
+}
+
+// rebuildBlockIndexes discards every block index entry and recreates them
+// from the stored blocks.
+func rebuildBlockIndexes(d *DB) error {
+	prefixes := make([][]byte, len(blockIndexPrefixes))
+	for i, prefix := range blockIndexPrefixes {
+		prefixes[i] = []byte(prefix)
+	}
+	if err := d.db.DropPrefix(prefixes...); err != nil {
+		return err
+	}
+
+	blocks, err := GetAllByPrefix(d, model.PrefixBlock+":", func() *model.Block {
+		return &model.Block{}
+	})
+	if err != nil {
+		return err
+	}
+
+	wb := d.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, b := range blocks {
+		for _, key := range blockIndexKeys(b) {
+			if err := wb.Set([]byte(key), blockIndexValue(key, b)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := wb.Set([]byte(blockIndexVersionKey), []byte(blockIndexVersion)); err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+
+	logging.DebugLog("rebuilt block indexes", "blocks", len(blocks), "version", blockIndexVersion)
+	return nil
+}
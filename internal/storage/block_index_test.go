@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexedKeys returns the block keys listed under an index prefix.
+func indexedKeys(t *testing.T, db *DB, prefix string) []string {
+	t.Helper()
+	keys, err := db.ListByPrefix(prefix)
+	require.NoError(t, err)
+	blockKeys := make([]string, len(keys))
+	for i, key := range keys {
+		blockKeys[i] = key[len(prefix):]
+	}
+	return blockKeys
+}
+
+// =============================================================================
+// Tag Index Tests
+// =============================================================================
+
+func TestTagIndex(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	var blocks []*model.Block
+	for i := 0; i < 10; i++ {
+		b := model.NewBlock("", "work", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		if i%5 == 0 {
+			b.AddTag("Billable")
+		}
+		require.NoError(t, repo.Create(b))
+		blocks = append(blocks, b)
+	}
+
+	t.Run("reads_only_tagged_blocks", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{blocks[0].Key, blocks[5].Key}, indexedKeys(t, db, tagIndexKeyPrefix("billable")))
+
+		tagged, err := repo.ListByTag("BILLABLE")
+		require.NoError(t, err)
+		assert.Len(t, tagged, 2)
+	})
+
+	t.Run("update_adds_and_removes_entries", func(t *testing.T) {
+		b := blocks[3]
+		b.AddTag("urgent")
+		require.NoError(t, repo.Update(b))
+		assert.Equal(t, []string{b.Key}, indexedKeys(t, db, tagIndexKeyPrefix("urgent")))
+
+		b.RemoveTag("urgent")
+		require.NoError(t, repo.Update(b))
+		assert.Empty(t, indexedKeys(t, db, tagIndexKeyPrefix("urgent")))
+
+		tagged, err := repo.ListByTag("urgent")
+		require.NoError(t, err)
+		assert.Empty(t, tagged)
+	})
+
+	t.Run("tag_filtered_updates", func(t *testing.T) {
+		changed, err := repo.AddTagFiltered(BlockFilter{Tag: "billable"}, "invoiced")
+		require.NoError(t, err)
+		assert.Equal(t, 2, changed)
+		assert.Len(t, indexedKeys(t, db, tagIndexKeyPrefix("invoiced")), 2)
+
+		_, err = repo.RemoveTagFiltered(BlockFilter{}, "invoiced")
+		require.NoError(t, err)
+		assert.Empty(t, indexedKeys(t, db, tagIndexKeyPrefix("invoiced")))
+	})
+
+	t.Run("delete_and_restore", func(t *testing.T) {
+		require.NoError(t, repo.Delete(blocks[0].Key))
+		assert.Equal(t, []string{blocks[5].Key}, indexedKeys(t, db, tagIndexKeyPrefix("billable")))
+
+		_, err := repo.Restore(blocks[0].Key)
+		require.NoError(t, err)
+		assert.Len(t, indexedKeys(t, db, tagIndexKeyPrefix("billable")), 2)
+
+		require.NoError(t, repo.HardDelete(blocks[5].Key))
+		assert.Equal(t, []string{blocks[0].Key}, indexedKeys(t, db, tagIndexKeyPrefix("billable")))
+	})
+
+	t.Run("list_filtered_matches_scan", func(t *testing.T) {
+		filter := BlockFilter{Tag: "billable", StartAfter: start}
+		indexed, err := repo.ListFiltered(filter)
+		require.NoError(t, err)
+
+		all, err := repo.List()
+		require.NoError(t, err)
+		var scanned []*model.Block
+		for _, b := range all {
+			if filter.matches(b) {
+				scanned = append(scanned, b)
+			}
+		}
+		assert.Equal(t, filter.sortAndLimit(scanned), indexed)
+	})
+}
+
+func TestTagIndexCreateBatch(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	var blocks []*model.Block
+	for i := 0; i < 3; i++ {
+		b := model.NewBlock("", "work", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(time.Hour)
+		b.AddTag("imported")
+		blocks = append(blocks, b)
+	}
+	require.NoError(t, repo.CreateBatch(blocks))
+
+	tagged, err := repo.ListByTag("imported")
+	require.NoError(t, err)
+	assert.Len(t, tagged, 3)
+}
+
+func TestBlockIndexesRebuiltOnOpen(t *testing.T) {
+	path := t.TempDir()
+	db, err := Open(Options{Path: path})
+	require.NoError(t, err)
+
+	b := model.NewBlock("", "work", "", "", time.Now().Add(-time.Hour))
+	b.AddTag("billable")
+	require.NoError(t, NewBlockRepo(db).Create(b))
+
+	// Simulate a database written before the index existed
+	require.NoError(t, db.deleteKeys([]string{blockIndexVersionKey, tagIndexKeyPrefix("billable") + b.Key}))
+	require.NoError(t, db.Close())
+
+	db, err = Open(Options{Path: path})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tagged, err := NewBlockRepo(db).ListByTag("billable")
+	require.NoError(t, err)
+	require.Len(t, tagged, 1)
+	assert.Equal(t, b.Key, tagged[0].Key)
+}
+
+// =============================================================================
+// Project Index Tests
+// =============================================================================
+
+func TestProjectIndex(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	var blocks []*model.Block
+	for i := 0; i < 6; i++ {
+		project := "alpha"
+		if i%3 == 0 {
+			project = "beta"
+		}
+		b := model.NewBlock("", project, "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, repo.Create(b))
+		blocks = append(blocks, b)
+	}
+
+	t.Run("reads_only_project_blocks", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{blocks[0].Key, blocks[3].Key}, indexedKeys(t, db, projectIndexKeyPrefix("beta")))
+
+		listed, err := repo.ListByProject("alpha")
+		require.NoError(t, err)
+		assert.Len(t, listed, 4)
+	})
+
+	t.Run("move_between_projects", func(t *testing.T) {
+		b := blocks[1]
+		b.ProjectSID = "beta"
+		require.NoError(t, repo.Update(b))
+
+		assert.NotContains(t, indexedKeys(t, db, projectIndexKeyPrefix("alpha")), b.Key)
+		assert.Contains(t, indexedKeys(t, db, projectIndexKeyPrefix("beta")), b.Key)
+
+		alpha, err := repo.ListByProject("alpha")
+		require.NoError(t, err)
+		assert.Len(t, alpha, 3)
+		beta, err := repo.ListByProject("beta")
+		require.NoError(t, err)
+		assert.Len(t, beta, 3)
+	})
+
+	t.Run("prefix_sids_do_not_collide", func(t *testing.T) {
+		b := model.NewBlock("", "alphabet", "", "", start.Add(24*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(time.Hour)
+		require.NoError(t, repo.Create(b))
+
+		alpha, err := repo.ListByProject("alpha")
+		require.NoError(t, err)
+		assert.Len(t, alpha, 3)
+	})
+
+	t.Run("list_filtered_matches_scan", func(t *testing.T) {
+		filter := BlockFilter{ProjectSIDs: []string{"alpha", "beta"}, StartAfter: start.Add(time.Hour)}
+		indexed, err := repo.ListFiltered(filter)
+		require.NoError(t, err)
+
+		all, err := repo.List()
+		require.NoError(t, err)
+		var scanned []*model.Block
+		for _, b := range all {
+			if filter.matches(b) {
+				scanned = append(scanned, b)
+			}
+		}
+		assert.Equal(t, filter.sortAndLimit(scanned), indexed)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, repo.HardDelete(blocks[0].Key))
+		assert.NotContains(t, indexedKeys(t, db, projectIndexKeyPrefix("beta")), blocks[0].Key)
+	})
+}
+
+// =============================================================================
+// Day Index Tests
+// =============================================================================
+
+func TestDayIndex(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	span := func(from, to time.Duration) *model.Block {
+		b := model.NewBlock("", "work", "", "", day.Add(from))
+		b.TimestampEnd = day.Add(to)
+		require.NoError(t, repo.Create(b))
+		return b
+	}
+
+	morning := span(9*time.Hour, 11*time.Hour)
+	overnight := span(22*time.Hour, 50*time.Hour)            // Mar 10 22:00 - Mar 12 02:00
+	toMidnight := span(72*time.Hour-time.Hour, 72*time.Hour) // ends at Mar 13 00:00
+	later := span(10*24*time.Hour, 10*24*time.Hour+time.Hour)
+
+	t.Run("multi_day_block_in_each_bucket", func(t *testing.T) {
+		for _, d := range []int{10, 11, 12} {
+			bucket := dayIndexBucket(time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC))
+			assert.Contains(t, indexedKeys(t, db, bucket), overnight.Key, "day %d", d)
+		}
+		assert.NotContains(t, indexedKeys(t, db, dayIndexBucket(day.AddDate(0, 0, 3))), overnight.Key)
+	})
+
+	t.Run("end_at_midnight_stays_in_its_day", func(t *testing.T) {
+		assert.Contains(t, indexedKeys(t, db, dayIndexBucket(day.AddDate(0, 0, 2))), toMidnight.Key)
+		assert.NotContains(t, indexedKeys(t, db, dayIndexBucket(day.AddDate(0, 0, 3))), toMidnight.Key)
+	})
+
+	t.Run("update_moves_buckets", func(t *testing.T) {
+		morning.TimestampEnd = day.Add(25 * time.Hour)
+		require.NoError(t, repo.Update(morning))
+		assert.Contains(t, indexedKeys(t, db, dayIndexBucket(day.AddDate(0, 0, 1))), morning.Key)
+
+		morning.TimestampEnd = day.Add(11 * time.Hour)
+		require.NoError(t, repo.Update(morning))
+		assert.NotContains(t, indexedKeys(t, db, dayIndexBucket(day.AddDate(0, 0, 1))), morning.Key)
+	})
+
+	t.Run("active_block_in_open_bucket", func(t *testing.T) {
+		active := model.NewBlock("", "work", "", "", day.Add(-48*time.Hour))
+		require.NoError(t, repo.Create(active))
+		assert.Equal(t, []string{active.Key}, indexedKeys(t, db, dayIndexOpenBucket))
+
+		blocks, err := repo.ListByTimeRange(later.TimestampStart, later.TimestampEnd)
+		require.NoError(t, err)
+		assert.Len(t, blocks, 2)
+
+		active.TimestampEnd = day.Add(-47 * time.Hour)
+		require.NoError(t, repo.Stop(active))
+		assert.Empty(t, indexedKeys(t, db, dayIndexOpenBucket))
+	})
+
+	t.Run("matches_scan", func(t *testing.T) {
+		all, err := repo.List()
+		require.NoError(t, err)
+
+		ranges := [][2]time.Time{
+			{day.Add(10 * time.Hour), day.Add(30 * time.Hour)},
+			{day.Add(36 * time.Hour), day.Add(37 * time.Hour)},
+			{day.Add(72 * time.Hour), day.Add(96 * time.Hour)},
+			{day.AddDate(0, 0, -5), day.AddDate(0, 0, 30)},
+			{time.Time{}, day.AddDate(1, 0, 0)},
+		}
+		for _, rng := range ranges {
+			var scanned []*model.Block
+			for _, b := range all {
+				if b.TimestampStart.Before(rng[1]) && b.TimestampEnd.After(rng[0]) {
+					scanned = append(scanned, b)
+				}
+			}
+			indexed, err := repo.ListByTimeRange(rng[0], rng[1])
+			require.NoError(t, err)
+			assert.Equal(t, scanned, indexed, "range %v - %v", rng[0], rng[1])
+		}
+	})
+}
+
+// =============================================================================
+// External ID Tests
+// =============================================================================
+
+func TestCreateIdempotent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	newSynced := func(externalID, note string) *model.Block {
+		b := model.NewBlock("", "work", "", note, start)
+		b.TimestampEnd = start.Add(time.Hour)
+		b.ExternalID = externalID
+		return b
+	}
+
+	original, created, err := repo.CreateIdempotent(newSynced("toggl:42", "first"))
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	t.Run("retry_returns_original", func(t *testing.T) {
+		got, created, err := repo.CreateIdempotent(newSynced("toggl:42", "retried"))
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, original.Key, got.Key)
+		assert.Equal(t, "first", got.Note)
+
+		all, err := repo.List()
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("prefix_ids_are_distinct", func(t *testing.T) {
+		_, created, err := repo.CreateIdempotent(newSynced("toggl:42:1", "other"))
+		require.NoError(t, err)
+		assert.True(t, created)
+		_, created, err = repo.CreateIdempotent(newSynced("toggl:4", "other"))
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("without_external_id_always_creates", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, created, err := repo.CreateIdempotent(newSynced("", "manual"))
+			require.NoError(t, err)
+			assert.True(t, created)
+		}
+	})
+
+	t.Run("concurrent_retries_create_once", func(t *testing.T) {
+		const workers = 8
+		var wg sync.WaitGroup
+		keys := make(chan string, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b, _, err := repo.CreateIdempotent(newSynced("toggl:99", "sync"))
+				assert.NoError(t, err)
+				keys <- b.Key
+			}()
+		}
+		wg.Wait()
+		close(keys)
+
+		first := <-keys
+		for key := range keys {
+			assert.Equal(t, first, key)
+		}
+	})
+
+	t.Run("id_freed_after_delete", func(t *testing.T) {
+		require.NoError(t, repo.HardDelete(original.Key))
+		_, created, err := repo.CreateIdempotent(newSynced("toggl:42", "again"))
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+}
+
+func TestExternalIDUnique(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	newSynced := func(externalID string) *model.Block {
+		b := model.NewBlock("", "work", "", "", start)
+		b.TimestampEnd = start.Add(time.Hour)
+		b.ExternalID = externalID
+		return b
+	}
+
+	owner := newSynced("toggl:42")
+	require.NoError(t, repo.Create(owner))
+
+	t.Run("create_rejects_taken_id", func(t *testing.T) {
+		err := repo.Create(newSynced("toggl:42"))
+		assert.ErrorIs(t, err, ErrDuplicateExternalID)
+		err = repo.CreateBatch([]*model.Block{newSynced("toggl:7"), newSynced("toggl:42")})
+		assert.ErrorIs(t, err, ErrDuplicateExternalID)
+	})
+
+	t.Run("update_rejects_taken_id", func(t *testing.T) {
+		other := newSynced("")
+		require.NoError(t, repo.Create(other))
+		other.ExternalID = "toggl:42"
+		assert.ErrorIs(t, repo.Update(other), ErrDuplicateExternalID)
+
+		// Saving the owner again is not a conflict
+		owner.Note = "edited"
+		assert.NoError(t, repo.Update(owner))
+	})
+
+	t.Run("delete_keeps_entry_of_other_block", func(t *testing.T) {
+		// A block stored before IDs were checked may share the ID
+		legacy := newSynced("toggl:42")
+		legacy.Key = "block:legacy"
+		require.NoError(t, db.Set(legacy))
+		require.NoError(t, repo.HardDelete(legacy.Key))
+
+		got, created, err := repo.CreateIdempotent(newSynced("toggl:42"))
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, owner.Key, got.Key)
+	})
+}
@@ -1,13 +1,29 @@
 package storage
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
+	badger "github.com/dgraph-io/badger/v4"
 	"github.com/google/uuid"
+	"github.com/manav03panchal/humantime/internal/logging"
 	"github.com/manav03panchal/humantime/internal/model"
 )
 
+// ErrNoAdjacentBlock is returned by NextAfter and PreviousBefore at either
+// end of the timeline.
+var ErrNoAdjacentBlock = errors.New("no adjacent block")
+
+// ErrNonPositiveDuration is returned by CreateFromDuration for a duration
+// of zero or less.
+var ErrNonPositiveDuration = errors.New("duration must be positive")
+
 // BlockRepo provides operations for Block entities.
 type BlockRepo struct {
 	db *DB
@@ -18,15 +34,235 @@ func NewBlockRepo(db *DB) *BlockRepo {
 	return &BlockRepo{db: db}
 }
 
-// Create creates a new block with a generated key.
+// Create creates a new block with a generated key. The block is validated
+// first, including its note against the configured limit.
 func (r *BlockRepo) Create(block *model.Block) error {
 	// Generate UUID v7 for time-sortable keys
 	id, err := uuid.NewV7()
 	if err != nil {
 		return err
 	}
+	if err := r.validate(block); err != nil {
+		return err
+	}
 	block.Key = model.GenerateBlockKey(id.String())
-	return r.db.Set(block)
+	if err := r.putBlock(block); err != nil {
+		return err
+	}
+	r.db.events.Publish(Event{Type: EventCreate, Block: block})
+	return nil
+}
+
+// noteLimit returns the configured maximum note length, which blocks are
+// validated against before they are stored.
+func (r *BlockRepo) noteLimit() (int, error) {
+	config, err := NewConfigRepo(r.db).Get()
+	if err != nil {
+		return 0, err
+	}
+	return config.NoteLimit(), nil
+}
+
+// validate checks a block before it is stored, including its note against
+// the configured limit.
+func (r *BlockRepo) validate(block *model.Block) error {
+	noteLimit, err := r.noteLimit()
+	if err != nil {
+		return err
+	}
+	return block.ValidateWithNoteLimit(noteLimit)
+}
+
+// CreateFromDuration stores block as a completed block that lasts dur from
+// its start. The duration must be positive.
+func (r *BlockRepo) CreateFromDuration(block *model.Block, dur time.Duration) error {
+	if dur <= 0 {
+		return fmt.Errorf("%w: %s", ErrNonPositiveDuration, dur)
+	}
+
+	block.TimestampEnd = block.TimestampStart.Add(dur)
+	return r.Create(block)
+}
+
+// CreateIdempotent creates a block unless one with the same ExternalID
+// already exists, in which case the existing block is returned instead and
+// nothing is written. The bool reports whether the block was created. Blocks
+// without an ExternalID are always created.
+func (r *BlockRepo) CreateIdempotent(block *model.Block) (*model.Block, bool, error) {
+	if block.ExternalID == "" {
+		if err := r.Create(block); err != nil {
+			return nil, false, err
+		}
+		return block, true, nil
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, false, err
+	}
+	key := model.GenerateBlockKey(id.String())
+
+	for attempt := 1; ; attempt++ {
+		var existing *model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			var err error
+			existing, err = getByExternalIDTxn(txn, block.ExternalID)
+			if err != nil || existing != nil {
+				return err
+			}
+			block.Key = key
+			return putBlockTxn(txn, block)
+		})
+		// A conflict means a concurrent write may have created the block,
+		// so check again
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+		r.db.events.Publish(Event{Type: EventCreate, Block: block})
+		return block, true, nil
+	}
+}
+
+// CreatePreservingKey creates a block under its existing key, so that
+// references to it such as ContinuedFrom stay valid, for example when
+// importing. If the key is not a block key or already belongs to a stored or
+// trashed block, a new key is generated instead and the bool reports true.
+func (r *BlockRepo) CreatePreservingKey(block *model.Block) (bool, error) {
+	if err := r.validate(block); err != nil {
+		return false, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return false, err
+	}
+	fallback := model.GenerateBlockKey(id.String())
+	original := block.Key
+
+	for attempt := 1; ; attempt++ {
+		rekeyed := false
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			block.Key = original
+			free, err := blockKeyFreeTxn(txn, original)
+			if err != nil {
+				return err
+			}
+			if !free {
+				block.Key = fallback
+				rekeyed = true
+			}
+			return putBlockTxn(txn, block)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			block.Key = original
+			return false, err
+		}
+		r.db.events.Publish(Event{Type: EventCreate, Block: block})
+		return rekeyed, nil
+	}
+}
+
+// blockKeyFreeTxn reports whether key is a block key that neither a stored
+// nor a trashed block uses.
+func blockKeyFreeTxn(txn *badger.Txn, key string) (bool, error) {
+	if !strings.HasPrefix(key, model.PrefixBlock+":") || len(key) == len(model.PrefixBlock)+1 {
+		return false, nil
+	}
+	for _, k := range []string{key, model.GenerateTrashKey(key)} {
+		_, err := txn.Get([]byte(k))
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// CreateBatch creates multiple blocks with generated keys in a single write.
+// All blocks are validated before anything is written. Large batches are split
+// across transactions to stay within Badger's size limits; if any chunk fails,
+// blocks written by earlier chunks are removed so the batch leaves nothing behind.
+func (r *BlockRepo) CreateBatch(blocks []*model.Block) error {
+	noteLimit, err := r.noteLimit()
+	if err != nil {
+		return err
+	}
+	for i, b := range blocks {
+		if err := b.ValidateWithNoteLimit(noteLimit); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+
+	keys := make([]string, len(blocks))
+	for i := range blocks {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		keys[i] = model.GenerateBlockKey(id.String())
+	}
+
+	originalKeys := make([]string, len(blocks))
+	for i, b := range blocks {
+		originalKeys[i] = b.Key
+		b.Key = keys[i]
+	}
+
+	// Blocks before committedUpTo may have been written, along with their
+	// index entries, by an earlier chunk
+	committedUpTo := 0
+	rollback := func(err error) error {
+		var committed []string
+		for _, b := range blocks[:committedUpTo] {
+			committed = append(committed, b.Key)
+			committed = append(committed, blockIndexKeys(b)...)
+		}
+		for i, b := range blocks {
+			b.Key = originalKeys[i]
+		}
+		if delErr := r.db.deleteKeys(committed); delErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, delErr)
+		}
+		return err
+	}
+
+	txn := r.db.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+
+	for i, b := range blocks {
+		err := putBlockTxn(txn, b)
+		if errors.Is(err, badger.ErrTxnTooBig) {
+			// Commit what fits and continue in a fresh transaction. The
+			// current block may be partly written, so rewrite it in full.
+			if err := txn.Commit(); err != nil {
+				return rollback(err)
+			}
+			committedUpTo = i + 1
+			txn = r.db.db.NewTransaction(true)
+			err = putBlockTxn(txn, b)
+		}
+		if err != nil {
+			return rollback(err)
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return rollback(err)
+	}
+	for _, b := range blocks {
+		r.db.events.Publish(Event{Type: EventCreate, Block: b})
+	}
+	return nil
 }
 
 // Get retrieves a block by key.
@@ -38,14 +274,81 @@ func (r *BlockRepo) Get(key string) (*model.Block, error) {
 	return block, nil
 }
 
+// Exists checks if a block exists by key without decoding it.
+func (r *BlockRepo) Exists(key string) (bool, error) {
+	return r.db.Exists(key)
+}
+
 // Update updates an existing block.
 func (r *BlockRepo) Update(block *model.Block) error {
-	return r.db.Set(block)
+	if err := r.validate(block); err != nil {
+		return err
+	}
+	return r.putBlock(block)
 }
 
-// Delete removes a block by key.
-func (r *BlockRepo) Delete(key string) error {
-	return r.db.Delete(key)
+// maxUpdateAttempts bounds how often UpdatePartial retries after a write
+// conflict.
+const maxUpdateAttempts = 100
+
+// UpdatePartial reads a block, applies mut and writes it back in a single
+// transaction, so concurrent partial updates cannot overwrite each other.
+// On a write conflict the transaction is retried against the fresh block,
+// so mut may run more than once and must only change the block it is
+// given. An error from mut aborts the update.
+func (r *BlockRepo) UpdatePartial(key string, mut func(*model.Block) error) error {
+	for attempt := 1; ; attempt++ {
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			block := &model.Block{}
+			if err := getTxn(txn, key, block); err != nil {
+				return err
+			}
+			if err := mut(block); err != nil {
+				return err
+			}
+			block.Key = key
+			return putBlockTxn(txn, block)
+		})
+		if !errors.Is(err, badger.ErrConflict) || attempt == maxUpdateAttempts {
+			return err
+		}
+	}
+}
+
+// Heartbeat records at as the last time the block was known to be running.
+// Completed blocks are left unchanged.
+func (r *BlockRepo) Heartbeat(key string, at time.Time) error {
+	return r.UpdatePartial(key, func(b *model.Block) error {
+		if b.IsActive() {
+			b.Heartbeat = at
+		}
+		return nil
+	})
+}
+
+// Stop persists a block that has just been given an end time and publishes
+// a stop event. If a minimum tracking unit is configured, the end time is
+// first rounded up to the next whole unit from the start. Blocks stopped by
+// StartExclusive are not rounded.
+func (r *BlockRepo) Stop(block *model.Block) error {
+	if block.IsActive() {
+		return fmt.Errorf("block %s has no end time", block.Key)
+	}
+
+	config, err := NewConfigRepo(r.db).Get()
+	if err != nil {
+		return err
+	}
+	if added := block.RoundUpTo(config.MinTrackUnit); added > 0 {
+		logging.DebugLog("rounded block end to minimum tracking unit",
+			"block", block.Key, "unit", config.MinTrackUnit, "added", added)
+	}
+
+	if err := r.putBlock(block); err != nil {
+		return err
+	}
+	r.db.events.Publish(Event{Type: EventStop, Block: block})
+	return nil
 }
 
 // List retrieves all blocks.
@@ -55,109 +358,497 @@ func (r *BlockRepo) List() ([]*model.Block, error) {
 	})
 }
 
-// ListByProject retrieves all blocks for a specific project.
-// Uses filtered iteration to avoid loading all blocks into memory.
+// Sample returns up to n blocks chosen at random, in key order. The choice
+// depends only on the seed and the stored blocks, so a seed reproduces the
+// same sample until the data changes.
+func (r *BlockRepo) Sample(n int, seed int64) ([]*model.Block, error) {
+	blocks, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return []*model.Block{}, nil
+	}
+	if n >= len(blocks) {
+		return blocks, nil
+	}
+
+	// Partial Fisher-Yates shuffle of the first n positions
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	for i := 0; i < n; i++ {
+		j := i + rng.IntN(len(blocks)-i)
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	sample := blocks[:n]
+	sort.Slice(sample, func(i, j int) bool { return sample[i].Key < sample[j].Key })
+	return sample, nil
+}
+
+// ListByProject retrieves all blocks for a specific project, in key order.
+// Only the project's blocks are read, via the project index.
 func (r *BlockRepo) ListByProject(projectSID string) ([]*model.Block, error) {
-	return GetFilteredByPrefix(r.db, model.PrefixBlock+":", func() *model.Block {
-		return &model.Block{}
-	}, func(b *model.Block) bool {
-		return b.ProjectSID == projectSID
-	}, 0)
+	candidates, err := r.listByIndex(projectIndexKeyPrefix(projectSID))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := candidates[:0]
+	for _, b := range candidates {
+		if b.ProjectSID == projectSID {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+// ListByTag retrieves blocks carrying the tag (case-insensitive), in key
+// order. Only the matching blocks are read, via the tag index.
+func (r *BlockRepo) ListByTag(tag string) ([]*model.Block, error) {
+	candidates, err := r.listByIndex(tagIndexKeyPrefix(tag))
+	if err != nil {
+		return nil, err
+	}
+
+	// A tag containing ":" can share a prefix with a longer one
+	blocks := candidates[:0]
+	for _, b := range candidates {
+		if b.HasTag(tag) {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
 }
 
 // ListByProjectAndTask retrieves all blocks for a specific project and task.
-// Uses filtered iteration to avoid loading all blocks into memory.
+// Only the project's blocks are read, via the project index.
 func (r *BlockRepo) ListByProjectAndTask(projectSID, taskSID string) ([]*model.Block, error) {
-	return GetFilteredByPrefix(r.db, model.PrefixBlock+":", func() *model.Block {
-		return &model.Block{}
-	}, func(b *model.Block) bool {
-		return b.ProjectSID == projectSID && b.TaskSID == taskSID
-	}, 0)
+	blocks, err := r.ListByProject(projectSID)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := blocks[:0]
+	for _, b := range blocks {
+		if b.TaskSID == taskSID {
+			matched = append(matched, b)
+		}
+	}
+	return matched, nil
+}
+
+// ListWithoutTask retrieves the blocks of a project that have no task, in
+// key order. For a project whose time is normally tracked against tasks,
+// these are the blocks still to be classified.
+func (r *BlockRepo) ListWithoutTask(projectSID string) ([]*model.Block, error) {
+	blocks, err := r.ListByProject(projectSID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasTask := false
+	filter := BlockFilter{HasTask: &hasTask}
+	matched := blocks[:0]
+	for _, b := range blocks {
+		if filter.matches(b) {
+			matched = append(matched, b)
+		}
+	}
+	return matched, nil
 }
 
-// ListByTimeRange retrieves blocks within a time range.
-// Uses filtered iteration to avoid loading all blocks into memory.
+// FirstAndLast returns a project's earliest-starting and latest-ending blocks.
+// Active blocks are treated as ending now. Returns ErrKeyNotFound if the
+// project has no blocks.
+func (r *BlockRepo) FirstAndLast(projectSID string) (first, last *model.Block, err error) {
+	blocks, err := r.ListByProject(projectSID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, nil, fmt.Errorf("no blocks for project %s: %w", projectSID, ErrKeyNotFound)
+	}
+
+	now := time.Now()
+	end := func(b *model.Block) time.Time {
+		if b.IsActive() {
+			return now
+		}
+		return b.TimestampEnd
+	}
+
+	first, last = blocks[0], blocks[0]
+	for _, b := range blocks[1:] {
+		if b.TimestampStart.Before(first.TimestampStart) {
+			first = b
+		}
+		if end(b).After(end(last)) {
+			last = b
+		}
+	}
+	return first, last, nil
+}
+
+// NextAfter returns the block that follows key in chronological order.
+// Blocks with equal start times are ordered by key. Returns
+// ErrNoAdjacentBlock if key is the last block.
+func (r *BlockRepo) NextAfter(key string) (*model.Block, error) {
+	return r.adjacent(key, 1)
+}
+
+// PreviousBefore returns the block that precedes key in chronological order.
+// Blocks with equal start times are ordered by key. Returns
+// ErrNoAdjacentBlock if key is the first block.
+func (r *BlockRepo) PreviousBefore(key string) (*model.Block, error) {
+	return r.adjacent(key, -1)
+}
+
+// adjacent returns the block offset positions from key in (start, key) order.
+func (r *BlockRepo) adjacent(key string, offset int) (*model.Block, error) {
+	if _, err := r.Get(key); err != nil {
+		return nil, err
+	}
+
+	blocks, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		if !blocks[i].TimestampStart.Equal(blocks[j].TimestampStart) {
+			return blocks[i].TimestampStart.Before(blocks[j].TimestampStart)
+		}
+		return blocks[i].Key < blocks[j].Key
+	})
+
+	for i, b := range blocks {
+		if b.Key != key {
+			continue
+		}
+		j := i + offset
+		if j < 0 || j >= len(blocks) {
+			return nil, ErrNoAdjacentBlock
+		}
+		return blocks[j], nil
+	}
+	return nil, ErrNoAdjacentBlock
+}
+
+// ListByTimeRange retrieves blocks within a time range, in key order.
+// Only the blocks in the range's day buckets are read, via the day index.
 func (r *BlockRepo) ListByTimeRange(start, end time.Time) ([]*model.Block, error) {
-	return GetFilteredByPrefix(r.db, model.PrefixBlock+":", func() *model.Block {
-		return &model.Block{}
-	}, func(b *model.Block) bool {
+	candidates, err := r.listByDayIndex(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	blocks := candidates[:0]
+	for _, b := range candidates {
 		// Block overlaps with range if:
 		// - Block starts before range ends AND
 		// - Block ends after range starts (or is still active)
-		blockEnd := b.TimestampEnd
-		if blockEnd.IsZero() {
-			blockEnd = time.Now()
+		if b.TimestampStart.Before(end) && b.EndAt(now).After(start) {
+			blocks = append(blocks, b)
 		}
-		return b.TimestampStart.Before(end) && blockEnd.After(start)
-	}, 0)
+	}
+	return blocks, nil
 }
 
+// NoLimit is a BlockFilter.Limit that explicitly asks for every matching
+// block, bypassing the configured default list limit.
+const NoLimit = -1
+
 // BlockFilter defines filtering criteria for blocks.
 type BlockFilter struct {
+	// ProjectSID is shorthand for a single entry in ProjectSIDs.
 	ProjectSID string
-	TaskSID    string
-	Tag        string
-	StartAfter time.Time
-	EndBefore  time.Time
-	Limit      int
+	// ProjectSIDs restricts results to blocks in any of these projects.
+	ProjectSIDs []string
+	TaskSID     string
+	Tag         string
+	Kind        string
+	StartAfter  time.Time
+	EndBefore   time.Time
+
+	// HasTask restricts results to blocks with a task (true) or without one
+	// (false). Nil matches both.
+	HasTask *bool
+
+	// Limit caps the number of blocks ListFiltered returns. Zero applies
+	// the configured default list limit, if any; NoLimit returns every
+	// matching block regardless of configuration.
+	Limit int
+
+	// UpdatedAfter restricts results to blocks written after this time.
+	// Blocks without an UpdatedAt never match.
+	UpdatedAfter time.Time
+
+	// ContainsInstant restricts results to blocks whose interval contains the
+	// instant: start <= t < end, or start <= t <= now for active blocks.
+	ContainsInstant *time.Time
+
+	// Weekdays restricts results to blocks starting on one of these days,
+	// evaluated in Location (time.Local if nil). Empty matches every day.
+	Weekdays []time.Weekday
+	Location *time.Location
 }
 
-// ListFiltered retrieves blocks matching the filter criteria.
-// Uses filtered iteration to avoid loading all blocks into memory before filtering.
-// Note: Sorting is still done in memory since BadgerDB uses lexicographical key order.
-func (r *BlockRepo) ListFiltered(filter BlockFilter) ([]*model.Block, error) {
-	// Build filter function from criteria
-	filterFunc := func(b *model.Block) bool {
-		// Apply project filter
-		if filter.ProjectSID != "" && b.ProjectSID != filter.ProjectSID {
-			return false
+// matchesProject reports whether sid is the filter's ProjectSID or one of
+// its ProjectSIDs.
+func (f BlockFilter) matchesProject(sid string) bool {
+	if f.ProjectSID != "" && sid == f.ProjectSID {
+		return true
+	}
+	for _, p := range f.ProjectSIDs {
+		if sid == p {
+			return true
 		}
+	}
+	return false
+}
 
-		// Apply task filter
-		if filter.TaskSID != "" && b.TaskSID != filter.TaskSID {
-			return false
+// matchesWeekday reports whether t falls on one of the filter's Weekdays.
+func (f BlockFilter) matchesWeekday(t time.Time) bool {
+	loc := f.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	day := t.In(loc).Weekday()
+	for _, w := range f.Weekdays {
+		if day == w {
+			return true
 		}
+	}
+	return false
+}
+
+// matches reports whether a block satisfies the filter criteria (ignoring Limit).
+func (f BlockFilter) matches(b *model.Block) bool {
+	// Apply project filter
+	if (f.ProjectSID != "" || len(f.ProjectSIDs) > 0) && !f.matchesProject(b.ProjectSID) {
+		return false
+	}
+
+	// Apply task filter
+	if f.TaskSID != "" && b.TaskSID != f.TaskSID {
+		return false
+	}
+
+	// Apply task presence filter
+	if f.HasTask != nil && (b.TaskSID != "") != *f.HasTask {
+		return false
+	}
+
+	// Apply tag filter
+	if f.Tag != "" && !b.HasTag(f.Tag) {
+		return false
+	}
+
+	// Apply kind filter
+	if f.Kind != "" && !strings.EqualFold(b.Kind, f.Kind) {
+		return false
+	}
+
+	// Apply weekday filter
+	if len(f.Weekdays) > 0 && !f.matchesWeekday(b.TimestampStart) {
+		return false
+	}
+
+	// Apply modification time filter
+	if !f.UpdatedAfter.IsZero() && !b.UpdatedAt.After(f.UpdatedAfter) {
+		return false
+	}
 
-		// Apply tag filter
-		if filter.Tag != "" && !b.HasTag(filter.Tag) {
+	// Apply time range filters
+	if !f.StartAfter.IsZero() && b.TimestampStart.Before(f.StartAfter) {
+		return false
+	}
+
+	blockEnd := b.EndAt(time.Now())
+	if !f.EndBefore.IsZero() && blockEnd.After(f.EndBefore) {
+		return false
+	}
+
+	// Apply instant filter
+	if f.ContainsInstant != nil {
+		instant := *f.ContainsInstant
+		if instant.Before(b.TimestampStart) {
 			return false
 		}
-
-		// Apply time range filters
-		if !filter.StartAfter.IsZero() && b.TimestampStart.Before(filter.StartAfter) {
+		if b.IsActive() {
+			if instant.After(blockEnd) {
+				return false
+			}
+		} else if !instant.Before(b.TimestampEnd) {
 			return false
 		}
+	}
 
-		blockEnd := b.TimestampEnd
-		if blockEnd.IsZero() {
-			blockEnd = time.Now()
-		}
-		if !filter.EndBefore.IsZero() && blockEnd.After(filter.EndBefore) {
-			return false
+	return true
+}
+
+// sortAndLimit orders blocks newest first and applies the filter's limit.
+func (f BlockFilter) sortAndLimit(blocks []*model.Block) []*model.Block {
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].TimestampStart.After(blocks[j].TimestampStart)
+	})
+	if f.Limit > 0 && len(blocks) > f.Limit {
+		blocks = blocks[:f.Limit]
+	}
+	return blocks
+}
+
+// ListFiltered retrieves blocks matching the filter criteria.
+// Uses filtered iteration to avoid loading all blocks into memory before filtering.
+// Note: Sorting is still done in memory since BadgerDB uses lexicographical key order.
+// Filters with a Tag or projects read only the candidate blocks, via the
+// tag or project index. A filter without a Limit gets the configured
+// default list limit.
+func (r *BlockRepo) ListFiltered(filter BlockFilter) ([]*model.Block, error) {
+	if filter.Limit == 0 {
+		config, err := NewConfigRepo(r.db).Get()
+		if err != nil {
+			return nil, err
 		}
+		filter.Limit = config.DefaultListLimit
+	}
 
-		return true
+	candidates, indexed, err := r.indexedCandidates(filter)
+	if err != nil {
+		return nil, err
+	}
+	if indexed {
+		filtered := candidates[:0]
+		for _, b := range candidates {
+			if filter.matches(b) {
+				filtered = append(filtered, b)
+			}
+		}
+		return filter.sortAndLimit(filtered), nil
 	}
 
 	// Use filtered iteration - can't apply limit here since we need to sort first
 	filtered, err := GetFilteredByPrefix(r.db, model.PrefixBlock+":", func() *model.Block {
 		return &model.Block{}
-	}, filterFunc, 0)
+	}, filter.matches, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort by start time (newest first)
-	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].TimestampStart.After(filtered[j].TimestampStart)
+	// Sort by start time (newest first) and apply limit after sorting
+	return filter.sortAndLimit(filtered), nil
+}
+
+// indexedCandidates returns a superset of the blocks matching the filter,
+// read through an index. The bool is false if no index applies.
+func (r *BlockRepo) indexedCandidates(filter BlockFilter) ([]*model.Block, bool, error) {
+	if filter.Tag != "" {
+		blocks, err := r.ListByTag(filter.Tag)
+		return blocks, true, err
+	}
+
+	if filter.ProjectSID != "" || len(filter.ProjectSIDs) > 0 {
+		sids := append([]string(nil), filter.ProjectSIDs...)
+		if filter.ProjectSID != "" {
+			sids = append(sids, filter.ProjectSID)
+		}
+
+		var blocks []*model.Block
+		seen := make(map[string]bool)
+		for _, sid := range sids {
+			if seen[sid] {
+				continue
+			}
+			seen[sid] = true
+			projectBlocks, err := r.ListByProject(sid)
+			if err != nil {
+				return nil, true, err
+			}
+			blocks = append(blocks, projectBlocks...)
+		}
+		return blocks, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// AddTagFiltered adds tag to every block matching the filter in a single
+// transaction. Blocks that already carry the tag are left untouched.
+// Returns the number of blocks changed.
+func (r *BlockRepo) AddTagFiltered(filter BlockFilter, tag string) (int, error) {
+	return r.updateFiltered(filter, func(b *model.Block) bool {
+		return b.AddTag(tag)
 	})
+}
+
+// RemoveTagFiltered removes tag from every block matching the filter in a
+// single transaction. Returns the number of blocks changed.
+func (r *BlockRepo) RemoveTagFiltered(filter BlockFilter, tag string) (int, error) {
+	return r.updateFiltered(filter, func(b *model.Block) bool {
+		return b.RemoveTag(tag)
+	})
+}
 
-	// Apply limit after sorting
-	if filter.Limit > 0 && len(filtered) > filter.Limit {
-		filtered = filtered[:filter.Limit]
+// NormalizeTags trims, dedupes and, unless the config's PreserveTagCase is
+// set, lowercases the tags of every block in a single transaction, so that
+// variants like "Billable" and " billable" become one tag. Returns the
+// number of blocks changed.
+func (r *BlockRepo) NormalizeTags() (int, error) {
+	config, err := NewConfigRepo(r.db).Get()
+	if err != nil {
+		return 0, err
 	}
+	lower := !config.PreserveTagCase
+	return r.updateFiltered(BlockFilter{}, func(b *model.Block) bool {
+		return b.NormalizeTags(lower)
+	})
+}
+
+// updateFiltered applies mutate to each block matching the filter and writes
+// back those it reports as changed, all within one transaction.
+func (r *BlockRepo) updateFiltered(filter BlockFilter, mutate func(*model.Block) bool) (int, error) {
+	changed := 0
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		var matched []*model.Block
 
-	return filtered, nil
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchSize = 100
+		it := txn.NewIterator(opts)
+		prefix := []byte(model.PrefixBlock + ":")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			err := item.Value(func(val []byte) error {
+				b := &model.Block{}
+				if err := json.Unmarshal(val, b); err != nil {
+					return err
+				}
+				b.SetKey(string(item.Key()))
+				if filter.matches(b) {
+					matched = append(matched, b)
+				}
+				return nil
+			})
+			if err != nil {
+				it.Close()
+				return err
+			}
+		}
+		it.Close()
+
+		for _, b := range filter.sortAndLimit(matched) {
+			if !mutate(b) {
+				continue
+			}
+			if err := putBlockTxn(txn, b); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
 }
 
 // TotalDuration calculates the total duration of given blocks.
@@ -202,3 +893,347 @@ func AggregateByProject(blocks []*model.Block) []ProjectAggregate {
 
 	return result
 }
+
+// OtherProjects is the ProjectSID of the bucket TopProjects gathers the
+// remaining projects in.
+const OtherProjects = "(other)"
+
+// TopProjects returns the n projects with the most tracked time, most first
+// and ties by SID, followed by an OtherProjects bucket totalling the rest.
+// The bucket only appears when there are more than n projects.
+func TopProjects(blocks []*model.Block, n int) []ProjectAggregate {
+	if n < 0 {
+		n = 0
+	}
+	aggs := AggregateByProject(blocks)
+	sort.SliceStable(aggs, func(i, j int) bool {
+		if aggs[i].Duration != aggs[j].Duration {
+			return aggs[i].Duration > aggs[j].Duration
+		}
+		return aggs[i].ProjectSID < aggs[j].ProjectSID
+	})
+	if len(aggs) <= n {
+		return aggs
+	}
+
+	other := ProjectAggregate{ProjectSID: OtherProjects}
+	for _, a := range aggs[n:] {
+		other.Duration += a.Duration
+		other.BlockCount += a.BlockCount
+	}
+	return append(aggs[:n:n], other)
+}
+
+// KindAggregate holds aggregated data for a block kind.
+type KindAggregate struct {
+	Kind       string // Empty for unclassified blocks
+	Duration   time.Duration
+	BlockCount int
+}
+
+// AggregateByKind aggregates blocks by kind, ignoring case. Results are sorted
+// by duration, highest first, then by kind.
+func AggregateByKind(blocks []*model.Block) []KindAggregate {
+	agg := make(map[string]*KindAggregate)
+	for _, b := range blocks {
+		kind := strings.ToLower(b.Kind)
+		if _, ok := agg[kind]; !ok {
+			agg[kind] = &KindAggregate{Kind: kind}
+		}
+		agg[kind].Duration += b.Duration()
+		agg[kind].BlockCount++
+	}
+
+	result := make([]KindAggregate, 0, len(agg))
+	for _, a := range agg {
+		result = append(result, *a)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].Kind < result[j].Kind
+	})
+
+	return result
+}
+
+// OwnerAggregate holds the tracked totals for a single owner.
+type OwnerAggregate struct {
+	OwnerKey   string
+	Duration   time.Duration
+	BlockCount int
+}
+
+// AggregateByOwner aggregates blocks by owner, sorted by duration (highest first).
+func AggregateByOwner(blocks []*model.Block) []OwnerAggregate {
+	agg := make(map[string]*OwnerAggregate)
+
+	for _, b := range blocks {
+		if _, ok := agg[b.OwnerKey]; !ok {
+			agg[b.OwnerKey] = &OwnerAggregate{OwnerKey: b.OwnerKey}
+		}
+		agg[b.OwnerKey].Duration += b.Duration()
+		agg[b.OwnerKey].BlockCount++
+	}
+
+	result := make([]OwnerAggregate, 0, len(agg))
+	for _, a := range agg {
+		result = append(result, *a)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].OwnerKey < result[j].OwnerKey
+	})
+
+	return result
+}
+
+// UnmatchedNoteGroup is the group of blocks whose note does not match the
+// pattern given to AggregateByNotePattern.
+const UnmatchedNoteGroup = "(unmatched)"
+
+// NoteGroupAggregate holds the tracked totals for one value extracted from
+// block notes.
+type NoteGroupAggregate struct {
+	Group      string
+	Duration   time.Duration
+	BlockCount int
+}
+
+// AggregateByNotePattern groups blocks by the first capture group of pattern
+// in their note, or by the whole match if it has no groups. Blocks whose note
+// does not match go under UnmatchedNoteGroup. Results are sorted by duration,
+// highest first, then by group, with the unmatched group last.
+func AggregateByNotePattern(blocks []*model.Block, pattern *regexp.Regexp) []NoteGroupAggregate {
+	agg := make(map[string]*NoteGroupAggregate)
+	for _, b := range blocks {
+		group := UnmatchedNoteGroup
+		if m := pattern.FindStringSubmatch(b.Note); m != nil {
+			group = m[0]
+			if len(m) > 1 {
+				group = m[1]
+			}
+		}
+		if _, ok := agg[group]; !ok {
+			agg[group] = &NoteGroupAggregate{Group: group}
+		}
+		agg[group].Duration += b.Duration()
+		agg[group].BlockCount++
+	}
+
+	result := make([]NoteGroupAggregate, 0, len(agg))
+	for _, a := range agg {
+		result = append(result, *a)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Group == UnmatchedNoteGroup) != (result[j].Group == UnmatchedNoteGroup) {
+			return result[j].Group == UnmatchedNoteGroup
+		}
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].Group < result[j].Group
+	})
+
+	return result
+}
+
+// DayAggregate holds the tracked totals for a single calendar day.
+type DayAggregate struct {
+	Date       time.Time // Midnight at the start of the day
+	Duration   time.Duration
+	BlockCount int
+}
+
+// AggregateByDay aggregates blocks by the local calendar day they start on.
+// Results are sorted chronologically.
+func AggregateByDay(blocks []*model.Block, loc *time.Location) []DayAggregate {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	agg := make(map[time.Time]*DayAggregate)
+	for _, b := range blocks {
+		day := startOfDay(b.TimestampStart, loc)
+		if _, ok := agg[day]; !ok {
+			agg[day] = &DayAggregate{Date: day}
+		}
+		agg[day].Duration += b.Duration()
+		agg[day].BlockCount++
+	}
+
+	result := make([]DayAggregate, 0, len(agg))
+	for _, a := range agg {
+		result = append(result, *a)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+// CapDays returns a copy of days with each Duration clamped to limit.
+// A limit of zero or less leaves the durations unchanged.
+func CapDays(days []DayAggregate, limit time.Duration) []DayAggregate {
+	capped := make([]DayAggregate, len(days))
+	copy(capped, days)
+	if limit <= 0 {
+		return capped
+	}
+	for i := range capped {
+		if capped[i].Duration > limit {
+			capped[i].Duration = limit
+		}
+	}
+	return capped
+}
+
+// RoundingMode selects how report durations are rounded to an increment.
+type RoundingMode string
+
+const (
+	RoundNearest RoundingMode = "nearest" // Halves round up
+	RoundUp      RoundingMode = "up"
+	RoundDown    RoundingMode = "down"
+)
+
+// RoundingModes lists the valid rounding modes.
+var RoundingModes = []RoundingMode{RoundNearest, RoundUp, RoundDown}
+
+// ParseRoundingMode parses a rounding mode name. Empty means RoundNearest.
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	if name == "" {
+		return RoundNearest, nil
+	}
+	for _, m := range RoundingModes {
+		if RoundingMode(strings.ToLower(name)) == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rounding mode %q (must be nearest, up or down)", name)
+}
+
+// Rounding rounds report durations to a multiple of Increment. A zero
+// Increment disables rounding; an empty Mode means RoundNearest.
+type Rounding struct {
+	Increment time.Duration
+	Mode      RoundingMode
+}
+
+// Apply returns d rounded to the increment.
+func (r Rounding) Apply(d time.Duration) time.Duration {
+	if r.Increment <= 0 {
+		return d
+	}
+	switch r.Mode {
+	case RoundUp:
+		if rem := d % r.Increment; rem > 0 {
+			return d - rem + r.Increment
+		}
+		return d
+	case RoundDown:
+		return d - d%r.Increment
+	default:
+		return d.Round(r.Increment)
+	}
+}
+
+// RoundProjects returns a copy of aggs with each project's total rounded.
+// Totals are rounded once rather than block by block, so rounding error does
+// not accumulate.
+func RoundProjects(aggs []ProjectAggregate, r Rounding) []ProjectAggregate {
+	rounded := make([]ProjectAggregate, len(aggs))
+	copy(rounded, aggs)
+	for i := range rounded {
+		rounded[i].Duration = r.Apply(rounded[i].Duration)
+	}
+	return rounded
+}
+
+// RoundDays returns a copy of days with each day's total rounded once.
+func RoundDays(days []DayAggregate, r Rounding) []DayAggregate {
+	rounded := make([]DayAggregate, len(days))
+	copy(rounded, days)
+	for i := range rounded {
+		rounded[i].Duration = r.Apply(rounded[i].Duration)
+	}
+	return rounded
+}
+
+// AggregateByProjectDay aggregates each project's blocks by the calendar day
+// they start on, keyed by project SID. Days follow the project's time zone
+// when projects has one for it, and loc otherwise.
+func AggregateByProjectDay(blocks []*model.Block, projects map[string]*model.Project, loc *time.Location) map[string][]DayAggregate {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	byProject := make(map[string][]*model.Block)
+	for _, b := range blocks {
+		byProject[b.ProjectSID] = append(byProject[b.ProjectSID], b)
+	}
+
+	result := make(map[string][]DayAggregate, len(byProject))
+	for sid, projectBlocks := range byProject {
+		projectLoc := loc
+		if p, ok := projects[sid]; ok && p != nil {
+			projectLoc = p.Location(loc)
+		}
+		result[sid] = AggregateByDay(projectBlocks, projectLoc)
+	}
+	return result
+}
+
+// SplitBlocksByDay cuts blocks at local midnights so that each segment falls
+// within a single calendar day. Blocks that already fit in one day are
+// returned unchanged; the segments of a split block are copies with "#N"
+// appended to the key to keep them unique. An active block keeps its last
+// segment active.
+func SplitBlocksByDay(blocks []*model.Block, loc *time.Location) []*model.Block {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	result := make([]*model.Block, 0, len(blocks))
+	for _, b := range blocks {
+		end := b.EndAt(time.Now())
+		nextDay := startOfDay(b.TimestampStart, loc).AddDate(0, 0, 1)
+		if !end.After(nextDay) {
+			result = append(result, b)
+			continue
+		}
+
+		start := b.TimestampStart
+		for i := 1; ; i++ {
+			segment := *b
+			segment.Key = fmt.Sprintf("%s#%d", b.Key, i)
+			segment.Tags = append([]string(nil), b.Tags...)
+			segment.TimestampStart = start
+			if end.After(nextDay) {
+				segment.TimestampEnd = nextDay
+			}
+			result = append(result, &segment)
+
+			if !end.After(nextDay) {
+				break
+			}
+			start = nextDay
+			nextDay = nextDay.AddDate(0, 0, 1)
+		}
+	}
+	return result
+}
+
+// startOfDay returns midnight of t's calendar day in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
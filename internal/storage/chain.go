@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// ErrSplitOutsideBlock is returned by Split when the split time does not fall
+// strictly inside the block.
+var ErrSplitOutsideBlock = errors.New("split time is outside the block")
+
+// ErrNoActiveBlock is returned by Continue when nothing is being tracked.
+var ErrNoActiveBlock = errors.New("no active block")
+
+// ErrNotContinuation is returned by Merge for a block that does not
+// continue another block.
+var ErrNotContinuation = errors.New("block does not continue another block")
+
+// Split ends a block at the given time and continues it in a new block from
+// that time to the original end, with the same project, task, note, tags and
+// kind. Splitting an active block leaves the new block active and points
+// active tracking at it. A block that continued the original now continues
+// the new block. All writes happen in one transaction. Returns the new block.
+func (r *BlockRepo) Split(key string, at time.Time) (*model.Block, error) {
+	return r.split(key, at, false)
+}
+
+// Continue ends the active block at the given time and keeps tracking in a
+// new block continuing it, which becomes the active block. The split and the
+// active pointer change in one transaction. Returns the new block, or
+// ErrNoActiveBlock if nothing is being tracked.
+func (r *BlockRepo) Continue(at time.Time) (*model.Block, error) {
+	activeRepo := NewActiveBlockRepo(r.db)
+	for attempt := 1; ; attempt++ {
+		active, err := activeRepo.Get()
+		if err != nil {
+			return nil, err
+		}
+		if !active.IsTracking() {
+			return nil, ErrNoActiveBlock
+		}
+
+		next, err := r.split(active.ActiveBlockKey, at, true)
+		if IsErrKeyNotFound(err) {
+			return nil, ErrNoActiveBlock
+		}
+		// Another writer moved the pointer since it was read
+		if errors.Is(err, ErrActiveBlockConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		return next, err
+	}
+}
+
+// split implements Split and Continue. When continuing, key must still be
+// the active block when the transaction runs, or ErrActiveBlockConflict is
+// returned.
+func (r *BlockRepo) split(key string, at time.Time, continuing bool) (*model.Block, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		var next *model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			active := model.NewActiveBlock()
+			if err := getTxn(txn, model.KeyActiveBlock, active); err != nil && !IsErrKeyNotFound(err) {
+				return err
+			}
+			if continuing && active.ActiveBlockKey != key {
+				return ErrActiveBlockConflict
+			}
+
+			block := &model.Block{}
+			if err := getTxn(txn, key, block); err != nil {
+				return err
+			}
+			if continuing && !block.IsActive() {
+				return ErrNoActiveBlock
+			}
+			if !at.After(block.TimestampStart) || (!block.IsActive() && !at.Before(block.TimestampEnd)) {
+				return ErrSplitOutsideBlock
+			}
+
+			next = model.NewBlock(block.OwnerKey, block.ProjectSID, block.TaskSID, block.Note, at)
+			next.Key = model.GenerateBlockKey(id.String())
+			next.Tags = append([]string(nil), block.Tags...)
+			next.Kind = block.Kind
+			next.TimestampEnd = block.TimestampEnd
+			next.ContinuedFrom = block.Key
+
+			successors, err := continuationsTxn(txn, block.Key)
+			if err != nil {
+				return err
+			}
+			block.TimestampEnd = at
+			if err := putBlockTxn(txn, block); err != nil {
+				return err
+			}
+			for _, b := range successors {
+				b.ContinuedFrom = next.Key
+				if err := putBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+			if err := putBlockTxn(txn, next); err != nil {
+				return err
+			}
+
+			// The continuation takes over active tracking
+			if !next.IsActive() {
+				return nil
+			}
+			active.SetActive(next.Key)
+			active.Key = model.KeyActiveBlock
+			active.Revision++
+			return setTxn(txn, active)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r.db.events.Publish(Event{Type: EventCreate, Block: next})
+		return next, nil
+	}
+}
+
+// Merge folds a block back into the block it continues, undoing Split: the
+// earlier block runs to the later one's end, takes over its continuations
+// and, if the later block was being tracked, active tracking. The later
+// block is deleted. All writes happen in one transaction. Returns the merged
+// block, or ErrNotContinuation if the block continues nothing.
+func (r *BlockRepo) Merge(key string) (*model.Block, error) {
+	for attempt := 1; ; attempt++ {
+		var merged, removed *model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			next := &model.Block{}
+			if err := getTxn(txn, key, next); err != nil {
+				return err
+			}
+			if next.ContinuedFrom == "" {
+				return ErrNotContinuation
+			}
+			block := &model.Block{}
+			if err := getTxn(txn, next.ContinuedFrom, block); err != nil {
+				return err
+			}
+
+			successors, err := continuationsTxn(txn, next.Key)
+			if err != nil {
+				return err
+			}
+			block.TimestampEnd = next.TimestampEnd
+			if err := putBlockTxn(txn, block); err != nil {
+				return err
+			}
+			for _, b := range successors {
+				b.ContinuedFrom = block.Key
+				if err := putBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+			if err := deleteBlockTxn(txn, next); err != nil {
+				return err
+			}
+			merged, removed = block, next
+
+			active := model.NewActiveBlock()
+			if err := getTxn(txn, model.KeyActiveBlock, active); err != nil {
+				if IsErrKeyNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			if active.ActiveBlockKey == next.Key {
+				active.ActiveBlockKey = block.Key
+			}
+			if active.PreviousBlockKey == next.Key {
+				active.PreviousBlockKey = block.Key
+			}
+			active.RecentBlockKeys = removeKey(active.RecentBlockKeys, next.Key)
+			active.Revision++
+			return setTxn(txn, active)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r.db.events.Publish(Event{Type: EventDelete, Block: removed})
+		return merged, nil
+	}
+}
+
+// continuationsTxn returns the blocks that continue the block with key.
+// Reading them in the writing transaction makes a concurrent edit of one of
+// them a conflict rather than a lost update.
+func continuationsTxn(txn *badger.Txn, key string) ([]*model.Block, error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	var blocks []*model.Block
+	prefix := []byte(model.PrefixBlock + ":")
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		b := &model.Block{}
+		err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, b)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if b.ContinuedFrom == key {
+			b.SetKey(string(item.KeyCopy(nil)))
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+// Chain returns the session a block belongs to: every block linked to it
+// through ContinuedFrom, oldest first. A block that was never split or
+// resumed is a chain of one. Links to deleted blocks end the chain.
+func (r *BlockRepo) Chain(key string) ([]*model.Block, error) {
+	block, err := r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := GetFilteredByPrefix(r.db, model.PrefixBlock+":", func() *model.Block {
+		return &model.Block{}
+	}, func(b *model.Block) bool {
+		return b.ContinuedFrom != ""
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	byKey := map[string]*model.Block{block.Key: block}
+	continuedBy := make(map[string]*model.Block, len(linked))
+	for _, b := range linked {
+		byKey[b.Key] = b
+		continuedBy[b.ContinuedFrom] = b
+	}
+
+	// Walk back to the first segment; seen guards against cycles
+	seen := map[string]bool{block.Key: true}
+	first := block
+	for first.ContinuedFrom != "" && !seen[first.ContinuedFrom] {
+		prev, ok := byKey[first.ContinuedFrom]
+		if !ok {
+			prev, err = r.Get(first.ContinuedFrom)
+			if IsErrKeyNotFound(err) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		seen[prev.Key] = true
+		first = prev
+	}
+
+	chain := []*model.Block{first}
+	visited := map[string]bool{first.Key: true}
+	for b := continuedBy[first.Key]; b != nil && !visited[b.Key]; b = continuedBy[b.Key] {
+		visited[b.Key] = true
+		chain = append(chain, b)
+	}
+	return chain, nil
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Split and Chain Tests
+// =============================================================================
+
+func TestSplit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock("", "work", "api", "refactor", start)
+	block.TimestampEnd = start.Add(2 * time.Hour)
+	block.AddTag("billable")
+	require.NoError(t, repo.Create(block))
+
+	next, err := repo.Split(block.Key, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, block.Key, next.ContinuedFrom)
+	assert.Equal(t, "api", next.TaskSID)
+	assert.Equal(t, "refactor", next.Note)
+	assert.Equal(t, []string{"billable"}, next.Tags)
+	assert.Equal(t, start.Add(2*time.Hour), next.TimestampEnd)
+
+	first, err := repo.Get(block.Key)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(30*time.Minute), first.TimestampEnd)
+
+	t.Run("two_element_chain", func(t *testing.T) {
+		for _, key := range []string{block.Key, next.Key} {
+			chain, err := repo.Chain(key)
+			require.NoError(t, err)
+			require.Len(t, chain, 2)
+			assert.Equal(t, block.Key, chain[0].Key)
+			assert.Equal(t, next.Key, chain[1].Key)
+		}
+	})
+
+	t.Run("splitting_the_first_segment_keeps_order", func(t *testing.T) {
+		middle, err := repo.Split(block.Key, start.Add(15*time.Minute))
+		require.NoError(t, err)
+
+		chain, err := repo.Chain(next.Key)
+		require.NoError(t, err)
+		require.Len(t, chain, 3)
+		assert.Equal(t, []string{block.Key, middle.Key, next.Key},
+			[]string{chain[0].Key, chain[1].Key, chain[2].Key})
+	})
+
+	t.Run("outside_block", func(t *testing.T) {
+		for _, at := range []time.Time{start, start.Add(-time.Hour), start.Add(2 * time.Hour)} {
+			_, err := repo.Split(next.Key, at)
+			assert.ErrorIs(t, err, ErrSplitOutsideBlock)
+		}
+	})
+
+	t.Run("active_block_stays_active", func(t *testing.T) {
+		active := model.NewBlock("", "work", "", "", time.Now().Add(-time.Hour))
+		require.NoError(t, repo.Create(active))
+
+		rest, err := repo.Split(active.Key, time.Now().Add(-10*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, rest.IsActive())
+	})
+
+	t.Run("unlinked_block_is_chain_of_one", func(t *testing.T) {
+		lone := model.NewBlock("", "other", "", "", start)
+		lone.TimestampEnd = start.Add(time.Hour)
+		require.NoError(t, repo.Create(lone))
+
+		chain, err := repo.Chain(lone.Key)
+		require.NoError(t, err)
+		assert.Len(t, chain, 1)
+	})
+
+	t.Run("deleted_link_ends_chain", func(t *testing.T) {
+		require.NoError(t, repo.HardDelete(block.Key))
+		chain, err := repo.Chain(next.Key)
+		require.NoError(t, err)
+		assert.Len(t, chain, 2)
+	})
+}
+
+func TestContinue(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	t.Run("nothing_active", func(t *testing.T) {
+		_, err := repo.Continue(time.Now())
+		assert.ErrorIs(t, err, ErrNoActiveBlock)
+	})
+
+	start := time.Now().Add(-time.Hour)
+	block := model.NewBlock("", "work", "api", "refactor", start)
+	block.AddTag("billable")
+	_, err := repo.StartExclusive(block)
+	require.NoError(t, err)
+
+	at := start.Add(45 * time.Minute)
+	next, err := repo.Continue(at)
+	require.NoError(t, err)
+	assert.Equal(t, block.Key, next.ContinuedFrom)
+	assert.Equal(t, "work", next.ProjectSID)
+	assert.Equal(t, "api", next.TaskSID)
+	assert.Equal(t, []string{"billable"}, next.Tags)
+	assert.True(t, next.IsActive())
+
+	first, err := repo.Get(block.Key)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(first.TimestampEnd))
+
+	active, err := activeRepo.GetActiveBlock(repo)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, next.Key, active.Key)
+
+	chain, err := repo.Chain(next.Key)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	assert.Equal(t, block.Key, chain[0].Key)
+	assert.Equal(t, next.Key, chain[1].Key)
+}
+
+func TestSplitActiveMovesPointer(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	block := model.NewBlock("", "work", "", "", time.Now().Add(-time.Hour))
+	_, err := repo.StartExclusive(block)
+	require.NoError(t, err)
+
+	next, err := repo.Split(block.Key, time.Now().Add(-10*time.Minute))
+	require.NoError(t, err)
+
+	active, err := activeRepo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, next.Key, active.ActiveBlockKey)
+	assert.Equal(t, block.Key, active.PreviousBlockKey)
+}
+
+func TestMerge(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	t.Run("undoes_continue", func(t *testing.T) {
+		start := time.Now().Add(-time.Hour)
+		block := model.NewBlock("", "work", "api", "", start)
+		_, err := repo.StartExclusive(block)
+		require.NoError(t, err)
+		next, err := repo.Continue(start.Add(30 * time.Minute))
+		require.NoError(t, err)
+
+		merged, err := repo.Merge(next.Key)
+		require.NoError(t, err)
+		assert.Equal(t, block.Key, merged.Key)
+		assert.True(t, merged.IsActive())
+
+		_, err = repo.Get(next.Key)
+		assert.True(t, IsErrKeyNotFound(err))
+
+		active, err := activeRepo.GetActiveBlock(repo)
+		require.NoError(t, err)
+		require.NotNil(t, active)
+		assert.Equal(t, block.Key, active.Key)
+		assert.True(t, active.IsActive())
+
+		state, err := activeRepo.Get()
+		require.NoError(t, err)
+		assert.NotContains(t, state.RecentBlockKeys, next.Key)
+		require.NoError(t, repo.HardDelete(block.Key))
+	})
+
+	t.Run("relinks_continuations", func(t *testing.T) {
+		start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+		block := model.NewBlock("", "work", "", "", start)
+		block.TimestampEnd = start.Add(3 * time.Hour)
+		require.NoError(t, repo.Create(block))
+		last, err := repo.Split(block.Key, start.Add(2*time.Hour))
+		require.NoError(t, err)
+		middle, err := repo.Split(block.Key, start.Add(time.Hour))
+		require.NoError(t, err)
+
+		merged, err := repo.Merge(middle.Key)
+		require.NoError(t, err)
+		assert.Equal(t, start.Add(2*time.Hour), merged.TimestampEnd)
+
+		chain, err := repo.Chain(last.Key)
+		require.NoError(t, err)
+		require.Len(t, chain, 2)
+		assert.Equal(t, block.Key, chain[0].Key)
+	})
+
+	t.Run("not_a_continuation", func(t *testing.T) {
+		lone := model.NewBlock("", "other", "", "", time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC))
+		lone.TimestampEnd = lone.TimestampStart.Add(time.Hour)
+		require.NoError(t, repo.Create(lone))
+
+		_, err := repo.Merge(lone.Key)
+		assert.ErrorIs(t, err, ErrNotContinuation)
+	})
+}
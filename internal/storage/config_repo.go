@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// ConfigRepo provides operations for the Config singleton.
+type ConfigRepo struct {
+	db *DB
+}
+
+// NewConfigRepo creates a new config repository.
+func NewConfigRepo(db *DB) *ConfigRepo {
+	return &ConfigRepo{db: db}
+}
+
+// Get retrieves the configuration, returning defaults if none has been saved.
+func (r *ConfigRepo) Get() (*model.Config, error) {
+	config := model.NewConfig("")
+	if err := r.db.Get(model.KeyConfig, config); err != nil {
+		if IsErrKeyNotFound(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+// Save persists the configuration.
+func (r *ConfigRepo) Save(config *model.Config) error {
+	config.Key = model.KeyConfig
+	return r.db.Set(config)
+}
+
+// SettingsExport is a portable settings file holding just the configuration.
+type SettingsExport struct {
+	Version    string        `json:"version"`
+	ExportedAt string        `json:"exported_at"`
+	Config     *model.Config `json:"config"`
+}
+
+// ExportConfig returns the configuration as a portable JSON settings document.
+func (r *ConfigRepo) ExportConfig() ([]byte, error) {
+	config, err := r.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(SettingsExport{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().Format(time.RFC3339),
+		Config:     config,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ImportConfig replaces the configuration with one from a settings document
+// produced by ExportConfig. The local user key is kept; no other data is
+// touched.
+func (r *ConfigRepo) ImportConfig(data []byte) (*model.Config, error) {
+	var doc SettingsExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if doc.Config == nil {
+		return nil, fmt.Errorf("settings file has no config")
+	}
+	if err := doc.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("settings file is invalid: %w", err)
+	}
+
+	current, err := r.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	config := doc.Config
+	config.UserKey = current.UserKey
+	if err := r.Save(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// UseSessionGap is a Consolidate or FindGaps gap threshold that asks for
+// the configured session gap.
+const UseSessionGap time.Duration = -1
+
+// Consolidate merges runs of completed blocks matching the filter that share
+// a project and task and are separated by less than gapThreshold. Each run is
+// collapsed into its earliest block, which takes the latest end time, the
+// other blocks' notes and their tags; the rest are moved to the trash.
+// Active blocks are never merged. A negative gapThreshold, such as
+// UseSessionGap, uses the configured session gap. Returns the number of
+// blocks absorbed.
+func (r *BlockRepo) Consolidate(filter BlockFilter, gapThreshold time.Duration) (int, error) {
+	if gapThreshold < 0 {
+		config, err := NewConfigRepo(r.db).Get()
+		if err != nil {
+			return 0, err
+		}
+		gapThreshold = config.SessionThreshold()
+	}
+	if filter.Limit == 0 {
+		filter.Limit = NoLimit
+	}
+	listed, err := r.ListFiltered(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		var absorbed []*model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			// Plan on fresh copies read in this transaction, so a block
+			// edited or stopped since it was listed conflicts instead of
+			// being overwritten
+			var blocks []*model.Block
+			for _, listedBlock := range listed {
+				b := &model.Block{}
+				if err := getTxn(txn, listedBlock.Key, b); err != nil {
+					if IsErrKeyNotFound(err) {
+						continue
+					}
+					return err
+				}
+				blocks = append(blocks, b)
+			}
+
+			var kept []*model.Block
+			var into map[string]string
+			kept, absorbed, into = consolidationRuns(blocks, gapThreshold)
+			if len(absorbed) == 0 {
+				return nil
+			}
+
+			// Blocks continuing an absorbed block now continue the block
+			// that absorbed it, as with Merge
+			byKey := make(map[string]*model.Block, len(blocks))
+			for _, b := range blocks {
+				byKey[b.Key] = b
+			}
+			writes := make(map[string]*model.Block, len(kept))
+			for _, b := range kept {
+				writes[b.Key] = b
+			}
+			for _, b := range absorbed {
+				successors, err := continuationsTxn(txn, b.Key)
+				if err != nil {
+					return err
+				}
+				for _, next := range successors {
+					if _, gone := into[next.Key]; gone {
+						continue
+					}
+					if fresh, ok := byKey[next.Key]; ok {
+						next = fresh
+					}
+					next.ContinuedFrom = into[b.Key]
+					if next.ContinuedFrom == next.Key {
+						next.ContinuedFrom = ""
+					}
+					writes[next.Key] = next
+				}
+			}
+
+			now := time.Now()
+			for _, b := range writes {
+				if err := putBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+			for _, b := range absorbed {
+				if err := setTxn(txn, model.NewTrashedBlock(b, now)); err != nil {
+					return err
+				}
+				if err := deleteBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+
+			// The previous pointer follows an absorbed block to the block
+			// that absorbed it; absorbed blocks leave the recent list
+			active := model.NewActiveBlock()
+			if err := getTxn(txn, model.KeyActiveBlock, active); err != nil {
+				if IsErrKeyNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			if key, ok := into[active.PreviousBlockKey]; ok {
+				active.PreviousBlockKey = key
+			}
+			for _, b := range absorbed {
+				active.RecentBlockKeys = removeKey(active.RecentBlockKeys, b.Key)
+			}
+			active.Revision++
+			return setTxn(txn, active)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		for _, b := range absorbed {
+			r.db.events.Publish(Event{Type: EventDelete, Block: b})
+		}
+		return len(absorbed), nil
+	}
+}
+
+// consolidationRuns groups completed blocks by project and task and merges
+// each run closer together than gapThreshold into its earliest block.
+// Returns the blocks that absorbed others, the blocks absorbed, and the key
+// of the block each absorbed block went into.
+func consolidationRuns(blocks []*model.Block, gapThreshold time.Duration) (kept, absorbed []*model.Block, into map[string]string) {
+	into = make(map[string]string)
+	type groupKey struct{ project, task string }
+	groups := make(map[groupKey][]*model.Block)
+	for _, b := range blocks {
+		if b.IsActive() {
+			continue
+		}
+		k := groupKey{b.ProjectSID, b.TaskSID}
+		groups[k] = append(groups[k], b)
+	}
+
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].TimestampStart.Before(group[j].TimestampStart)
+		})
+
+		run := group[0]
+		changed := false
+		for _, b := range group[1:] {
+			if b.TimestampStart.Sub(run.TimestampEnd) >= gapThreshold {
+				if changed {
+					kept = append(kept, run)
+				}
+				run, changed = b, false
+				continue
+			}
+			mergeInto(run, b)
+			absorbed = append(absorbed, b)
+			into[b.Key] = run.Key
+			changed = true
+		}
+		if changed {
+			kept = append(kept, run)
+		}
+	}
+	return kept, absorbed, into
+}
+
+// mergeInto extends dst to cover src, appending its note and tags.
+func mergeInto(dst, src *model.Block) {
+	if src.TimestampEnd.After(dst.TimestampEnd) {
+		dst.TimestampEnd = src.TimestampEnd
+	}
+	if src.Note != dst.Note {
+		// No limit is applied, so this cannot fail
+		_ = dst.AppendNote(src.Note, 0, false)
+	}
+	for _, tag := range src.Tags {
+		dst.AddTag(tag)
+	}
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Consolidate Tests
+// =============================================================================
+
+func TestBlockRepoConsolidate(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	create := func(task, note string, start, length time.Duration) *model.Block {
+		b := model.NewBlock("", "alpha", task, note, base.Add(start))
+		b.TimestampEnd = b.TimestampStart.Add(length)
+		require.NoError(t, repo.Create(b))
+		return b
+	}
+
+	// Three adjacent blocks with small gaps
+	first := create("api", "design", 0, time.Hour)
+	create("api", "build", 65*time.Minute, time.Hour)
+	second := create("api", "test", 130*time.Minute, 30*time.Minute)
+	second.AddTag("billable")
+	require.NoError(t, repo.Update(second))
+
+	// Same task but hours later
+	distant := create("api", "later", 8*time.Hour, time.Hour)
+	// Adjacent but a different task
+	other := create("docs", "readme", 161*time.Minute, 20*time.Minute)
+
+	merged, err := repo.Consolidate(BlockFilter{ProjectSID: "alpha"}, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, merged)
+
+	blocks, err := repo.List()
+	require.NoError(t, err)
+	assert.Len(t, blocks, 3)
+
+	got, err := repo.Get(first.Key)
+	require.NoError(t, err)
+	assert.Equal(t, base, got.TimestampStart.UTC())
+	assert.Equal(t, base.Add(160*time.Minute), got.TimestampEnd.UTC())
+	assert.Equal(t, "design - build - test", got.Note)
+	assert.True(t, got.HasTag("billable"))
+
+	for _, key := range []string{distant.Key, other.Key} {
+		exists, err := repo.Exists(key)
+		require.NoError(t, err)
+		assert.True(t, exists, key)
+	}
+
+	trash, err := repo.ListTrash()
+	require.NoError(t, err)
+	assert.Len(t, trash, 2)
+
+	t.Run("nothing_to_merge", func(t *testing.T) {
+		merged, err := repo.Consolidate(BlockFilter{ProjectSID: "alpha"}, 10*time.Minute)
+		require.NoError(t, err)
+		assert.Zero(t, merged)
+	})
+}
+
+func TestBlockRepoConsolidateSkipsActive(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Now().Add(-2 * time.Hour)
+	done := model.NewBlock("", "alpha", "", "", start)
+	done.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(done))
+	require.NoError(t, repo.Create(model.NewBlock("", "alpha", "", "", start.Add(time.Hour))))
+
+	merged, err := repo.Consolidate(BlockFilter{}, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, merged)
+}
+
+func TestBlockRepoConsolidateSessionGap(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	// Four blocks 3, 8 and 20 minutes apart
+	setup := func(t *testing.T) *BlockRepo {
+		db := setupTestDB(t)
+		repo := NewBlockRepo(db)
+		start := base
+		for _, pause := range []time.Duration{0, 3 * time.Minute, 8 * time.Minute, 20 * time.Minute} {
+			start = start.Add(pause)
+			b := model.NewBlock("", "alpha", "", "", start)
+			b.TimestampEnd = start.Add(30 * time.Minute)
+			require.NoError(t, repo.Create(b))
+			start = b.TimestampEnd
+		}
+		return repo
+	}
+
+	tests := []struct {
+		name       string
+		sessionGap time.Duration
+		merged     int
+	}{
+		{"default", 0, 1},
+		{"ten_minutes", 10 * time.Minute, 2},
+		{"half_hour", 30 * time.Minute, 3},
+		{"one_minute", time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setup(t)
+			configRepo := NewConfigRepo(repo.db)
+			config, err := configRepo.Get()
+			require.NoError(t, err)
+			config.SessionGap = tt.sessionGap
+			require.NoError(t, configRepo.Save(config))
+
+			merged, err := repo.Consolidate(BlockFilter{}, UseSessionGap)
+			require.NoError(t, err)
+			assert.Equal(t, tt.merged, merged)
+		})
+	}
+
+	t.Run("explicit_threshold_wins", func(t *testing.T) {
+		repo := setup(t)
+		merged, err := repo.Consolidate(BlockFilter{}, 30*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 3, merged)
+	})
+}
+
+func TestBlockRepoConsolidateRelinks(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	// One session split into three segments, the last after a long pause
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock("", "alpha", "", "", start)
+	block.TimestampEnd = start.Add(5 * time.Hour)
+	require.NoError(t, repo.Create(block))
+	last, err := repo.Split(block.Key, start.Add(4*time.Hour))
+	require.NoError(t, err)
+	middle, err := repo.Split(block.Key, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	// Open a gap before the last segment so it stays on its own
+	last.TimestampStart = start.Add(8 * time.Hour)
+	last.TimestampEnd = start.Add(9 * time.Hour)
+	require.NoError(t, repo.Update(last))
+
+	state, err := activeRepo.Get()
+	require.NoError(t, err)
+	state.PreviousBlockKey = middle.Key
+	state.RecentBlockKeys = []string{middle.Key, block.Key}
+	require.NoError(t, activeRepo.Save(state))
+
+	merged, err := repo.Consolidate(BlockFilter{ProjectSID: "alpha"}, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, merged)
+
+	chain, err := repo.Chain(last.Key)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	assert.Equal(t, block.Key, chain[0].Key)
+
+	state, err = activeRepo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, block.Key, state.PreviousBlockKey)
+	assert.Equal(t, []string{block.Key}, state.RecentBlockKeys)
+}
@@ -115,6 +115,22 @@ func (d *DB) Delete(key string) error {
 	})
 }
 
+// deleteKeys removes the given keys using a write batch.
+func (d *DB) deleteKeys(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	wb := d.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if err := wb.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
 // Exists checks if a key exists in the database.
 func (d *DB) Exists(key string) (bool, error) {
 	var exists bool
@@ -313,3 +329,31 @@ func CountByPrefix[T model.Model](d *DB, prefix string, newFunc func() T, filter
 	})
 	return count, err
 }
+
+// getTxn retrieves a value by key within a transaction and unmarshals it into v.
+func getTxn(txn *badger.Txn, key string, v model.Model) error {
+	item, err := txn.Get([]byte(key))
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrKeyNotFound
+		}
+		return err
+	}
+
+	return item.Value(func(val []byte) error {
+		if err := json.Unmarshal(val, v); err != nil {
+			return err
+		}
+		v.SetKey(key)
+		return nil
+	})
+}
+
+// setTxn stores a model within a transaction.
+func setTxn(txn *badger.Txn, v model.Model) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return txn.Set([]byte(v.GetKey()), data)
+}
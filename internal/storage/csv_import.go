@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// Canonical CSV import columns. The column names written by ExportBlocksCSV
+// are all accepted, so its output can be read back.
+const (
+	CSVFieldDate    = "date"
+	CSVFieldProject = "project"
+	CSVFieldTask    = "task"
+	CSVFieldStart   = "start"
+	CSVFieldEnd     = "end"
+	CSVFieldNote    = "note"
+	CSVFieldTags    = "tags"
+)
+
+// CSVImportFields lists the canonical columns ParseCSVBlocks understands.
+var CSVImportFields = []string{
+	CSVFieldDate, CSVFieldProject, CSVFieldTask, CSVFieldStart, CSVFieldEnd, CSVFieldNote, CSVFieldTags,
+}
+
+// csvRequiredFields must be present, directly or through the header map.
+var csvRequiredFields = []string{CSVFieldProject, CSVFieldStart, CSVFieldEnd}
+
+// csvTimeLayouts are the timestamp layouts accepted for start and end.
+var csvTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// csvClockLayouts are accepted for start and end when a date column gives
+// the day.
+var csvClockLayouts = []string{"15:04:05", "15:04"}
+
+// CSVImportOptions configures ParseCSVBlocks.
+type CSVImportOptions struct {
+	// HeaderMap maps source column names to canonical fields, e.g.
+	// "description" to "note". Matching is case-insensitive. Columns that
+	// are neither mapped nor canonical are ignored.
+	HeaderMap map[string]string
+	// Location is the time zone for timestamps without an offset.
+	// Defaults to local time.
+	Location *time.Location
+}
+
+// ParseCSVBlocks reads blocks from CSV with a header row. Start and end are
+// full timestamps, or times of day combined with a date column; an end
+// time of day before the start falls on the next day. Tags are
+// comma-separated. Project SIDs are taken as they are. If a required field
+// has no column, the error lists every missing field.
+func ParseCSVBlocks(r io.Reader, opts CSVImportOptions) ([]*model.Block, error) {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("empty CSV")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := mapCSVHeader(header, opts.HeaderMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*model.Block
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		project := field(CSVFieldProject)
+		if project == "" {
+			return nil, fmt.Errorf("line %d: missing project", line)
+		}
+		date := field(CSVFieldDate)
+		start, err := parseCSVTime(date, field(CSVFieldStart), loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: start: %w", line, err)
+		}
+		end, err := parseCSVTime(date, field(CSVFieldEnd), loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: end: %w", line, err)
+		}
+		if end.Before(start) && date != "" && isCSVClock(field(CSVFieldEnd)) {
+			// Times of day that wrap past midnight
+			end = end.AddDate(0, 0, 1)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("line %d: end is before start", line)
+		}
+
+		b := model.NewBlock("", project, field(CSVFieldTask), field(CSVFieldNote), start)
+		b.TimestampEnd = end
+		for _, tag := range strings.Split(field(CSVFieldTags), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				b.AddTag(tag)
+			}
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// mapCSVHeader returns the column index of each canonical field found in
+// the header, applying headerMap first.
+func mapCSVHeader(header []string, headerMap map[string]string) (map[string]int, error) {
+	mapping := make(map[string]string, len(headerMap))
+	for source, field := range headerMap {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !isCSVImportField(field) {
+			return nil, fmt.Errorf("unknown CSV field %q (must be one of %s)", field, strings.Join(CSVImportFields, ", "))
+		}
+		mapping[strings.ToLower(strings.TrimSpace(source))] = field
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		field, ok := mapping[name]
+		if !ok {
+			if !isCSVImportField(name) {
+				continue
+			}
+			field = name
+		}
+		if _, seen := columns[field]; !seen {
+			columns[field] = i
+		}
+	}
+
+	var missing []string
+	for _, field := range csvRequiredFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("CSV is missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// isCSVImportField reports whether name is a canonical CSV field.
+func isCSVImportField(name string) bool {
+	for _, field := range CSVImportFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// isCSVClock reports whether value is a time of day without a date.
+func isCSVClock(value string) bool {
+	for _, layout := range csvClockLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCSVTime parses a start or end value, using date for times of day.
+func parseCSVTime(date, value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing value")
+	}
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	if date != "" {
+		for _, layout := range csvClockLayouts {
+			if t, err := time.ParseInLocation("2006-01-02 "+layout, date+" "+value, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// CSV Import Tests
+// =============================================================================
+
+func TestParseCSVBlocks(t *testing.T) {
+	t.Run("header_map", func(t *testing.T) {
+		data := "Client,From,To,Description,Labels\n" +
+			"acme,2025-03-10 09:00,2025-03-10 10:30,fix login,\"billable, urgent\"\n" +
+			"acme,2025-03-10 11:00,2025-03-10 11:15,standup,\n"
+		blocks, err := ParseCSVBlocks(strings.NewReader(data), CSVImportOptions{
+			HeaderMap: map[string]string{
+				"client":      "project",
+				"from":        "start",
+				"to":          "end",
+				"description": "note",
+				"Labels":      "tags",
+			},
+			Location: time.UTC,
+		})
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+
+		assert.Equal(t, "acme", blocks[0].ProjectSID)
+		assert.Equal(t, "fix login", blocks[0].Note)
+		assert.Equal(t, []string{"billable", "urgent"}, blocks[0].Tags)
+		assert.Equal(t, time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), blocks[0].TimestampStart)
+		assert.Equal(t, 90*time.Minute, blocks[0].Duration())
+		assert.Equal(t, "standup", blocks[1].Note)
+		assert.Empty(t, blocks[1].Tags)
+	})
+
+	t.Run("missing_required_columns", func(t *testing.T) {
+		data := "client,start,description\nacme,2025-03-10 09:00,fix\n"
+		_, err := ParseCSVBlocks(strings.NewReader(data), CSVImportOptions{
+			HeaderMap: map[string]string{"description": "note"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "end, project")
+	})
+
+	t.Run("unknown_field_in_map", func(t *testing.T) {
+		_, err := ParseCSVBlocks(strings.NewReader("a\n"), CSVImportOptions{
+			HeaderMap: map[string]string{"a": "client"},
+		})
+		assert.ErrorContains(t, err, `unknown CSV field "client"`)
+	})
+
+	t.Run("bad_time", func(t *testing.T) {
+		data := "project,start,end\nacme,yesterday,2025-03-10 10:00\n"
+		_, err := ParseCSVBlocks(strings.NewReader(data), CSVImportOptions{})
+		assert.ErrorContains(t, err, "line 2: start")
+	})
+
+	t.Run("export_round_trip", func(t *testing.T) {
+		start := time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC)
+		b := model.NewBlock("", "acme", "", "late night", start)
+		b.TimestampEnd = start.Add(2 * time.Hour)
+		b.Tags = []string{"ops"}
+
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, []*model.Block{b}, ExportOptions{Location: time.UTC}))
+
+		blocks, err := ParseCSVBlocks(&buf, CSVImportOptions{Location: time.UTC})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		assert.Equal(t, start, blocks[0].TimestampStart)
+		assert.Equal(t, b.TimestampEnd, blocks[0].TimestampEnd)
+		assert.Equal(t, "late night", blocks[0].Note)
+		assert.Equal(t, []string{"ops"}, blocks[0].Tags)
+	})
+}
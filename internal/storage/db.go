@@ -3,6 +3,8 @@ package storage
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 
@@ -18,9 +20,10 @@ const (
 
 // DB wraps a Badger database connection.
 type DB struct {
-	db   *badger.DB
-	lock *FileLock
-	path string // Database path for error context
+	db     *badger.DB
+	lock   *FileLock
+	path   string    // Database path for error context
+	events *EventBus // Block mutation events
 }
 
 // Options configures the database connection.
@@ -29,8 +32,73 @@ type Options struct {
 	Path string
 	// InMemory forces in-memory mode regardless of Path.
 	InMemory bool
+	// LogLevel sets the minimum level of Badger log messages. Defaults to
+	// LogLevelError.
+	LogLevel LogLevel
+	// LogOutput receives Badger log messages. Defaults to os.Stderr.
+	LogOutput io.Writer
 }
 
+// LogLevel is the minimum severity of Badger log messages to emit.
+type LogLevel string
+
+const (
+	// LogLevelSilent suppresses all Badger log output.
+	LogLevelSilent LogLevel = "silent"
+	// LogLevelError emits errors only.
+	LogLevelError LogLevel = "error"
+	// LogLevelWarn emits warnings and errors.
+	LogLevelWarn LogLevel = "warn"
+	// LogLevelInfo emits informational messages, warnings and errors.
+	LogLevelInfo LogLevel = "info"
+)
+
+// badgerLogger adapts a standard logger to Badger's Logger interface,
+// dropping messages below its level.
+type badgerLogger struct {
+	*log.Logger
+	level LogLevel
+}
+
+// newBadgerLogger returns a Badger logger for the options, or nil to
+// disable Badger logging entirely.
+func newBadgerLogger(opts Options) (badger.Logger, error) {
+	level := opts.LogLevel
+	switch level {
+	case "":
+		level = LogLevelError
+	case LogLevelSilent:
+		return nil, nil
+	case LogLevelError, LogLevelWarn, LogLevelInfo:
+	default:
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	out := opts.LogOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	return &badgerLogger{Logger: log.New(out, "badger ", log.LstdFlags), level: level}, nil
+}
+
+func (l *badgerLogger) Errorf(f string, v ...interface{}) {
+	l.Printf("ERROR: "+f, v...)
+}
+
+func (l *badgerLogger) Warningf(f string, v ...interface{}) {
+	if l.level == LogLevelWarn || l.level == LogLevelInfo {
+		l.Printf("WARNING: "+f, v...)
+	}
+}
+
+func (l *badgerLogger) Infof(f string, v ...interface{}) {
+	if l.level == LogLevelInfo {
+		l.Printf("INFO: "+f, v...)
+	}
+}
+
+func (l *badgerLogger) Debugf(string, ...interface{}) {}
+
 // DefaultPath returns the default database path following XDG spec.
 func DefaultPath() string {
 	return filepath.Join(xdg.DataHome, AppName, "db")
@@ -56,14 +124,23 @@ func Open(opts Options) (*DB, error) {
 	}
 
 	// Reduce logging noise
-	badgerOpts = badgerOpts.WithLoggingLevel(badger.ERROR)
+	logger, err := newBadgerLogger(opts)
+	if err != nil {
+		return nil, err
+	}
+	badgerOpts = badgerOpts.WithLogger(logger)
 
 	db, err := badger.Open(badgerOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DB{db: db, lock: nil, path: opts.Path}, nil
+	d := &DB{db: db, lock: nil, path: opts.Path, events: NewEventBus()}
+	if err := ensureBlockIndexes(d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to build block indexes: %w", err)
+	}
+	return d, nil
 }
 
 // Close closes the database connection and releases the file lock.
@@ -86,11 +163,27 @@ func (d *DB) Badger() *badger.DB {
 	return d.db
 }
 
+// Events returns the event bus that repositories publish mutations to.
+func (d *DB) Events() *EventBus {
+	return d.events
+}
+
 // Path returns the database path.
 func (d *DB) Path() string {
 	return d.path
 }
 
+// SizeOnDisk returns the size in bytes of the LSM tree and value log, as
+// last measured by Badger (it refreshes them periodically and on open).
+// In-memory databases report zero.
+func (d *DB) SizeOnDisk() (lsm, vlog int64, err error) {
+	if d.path == "" {
+		return 0, 0, nil
+	}
+	lsm, vlog = d.db.Size()
+	return lsm, vlog, nil
+}
+
 // CheckIntegrity performs a database integrity check.
 // Returns nil if healthy, error describing the issue otherwise.
 func (d *DB) CheckIntegrity() error {
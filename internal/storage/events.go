@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// EventType identifies a block mutation.
+type EventType string
+
+const (
+	// EventCreate is published after a block is created.
+	EventCreate EventType = "create"
+	// EventStop is published after an active block is stopped.
+	EventStop EventType = "stop"
+	// EventDelete is published after a block is deleted.
+	EventDelete EventType = "delete"
+)
+
+// Event describes a block mutation published by the repositories.
+type Event struct {
+	Type  EventType
+	Block *model.Block
+	At    time.Time
+}
+
+// EventHandler receives published events.
+type EventHandler func(Event)
+
+type subscriber struct {
+	handler EventHandler
+	async   bool
+}
+
+// EventBus is a lightweight in-process event emitter. Repositories publish
+// block mutations to it; webhook dispatch and notifications subscribe.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs []subscriber
+	wg   sync.WaitGroup
+}
+
+// NewEventBus creates an empty event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers a handler that runs synchronously during Publish,
+// in subscription order.
+func (b *EventBus) Subscribe(handler EventHandler) {
+	b.subscribe(handler, false)
+}
+
+// SubscribeAsync registers a handler that runs in its own goroutine for each
+// event, so slow subscribers (e.g. network calls) don't block writers.
+// Use Wait to block until in-flight handlers finish.
+func (b *EventBus) SubscribeAsync(handler EventHandler) {
+	b.subscribe(handler, true)
+}
+
+func (b *EventBus) subscribe(handler EventHandler, async bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscriber{handler: handler, async: async})
+}
+
+// Publish delivers an event to all subscribers.
+func (b *EventBus) Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := make([]subscriber, len(b.subs))
+	copy(subs, b.subs)
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if !s.async {
+			s.handler(e)
+			continue
+		}
+
+		// Async handlers get their own copy so they can't race with the caller
+		ev := e
+		if e.Block != nil {
+			block := *e.Block
+			block.Tags = append([]string(nil), e.Block.Tags...)
+			ev.Block = &block
+		}
+		b.wg.Add(1)
+		go func(h EventHandler) {
+			defer b.wg.Done()
+			h(ev)
+		}(s.handler)
+	}
+}
+
+// Wait blocks until all in-flight async handlers have returned.
+func (b *EventBus) Wait() {
+	b.wg.Wait()
+}
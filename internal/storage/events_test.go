@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// EventBus Tests
+// =============================================================================
+
+func TestEventBusSync(t *testing.T) {
+	bus := NewEventBus()
+
+	var order []string
+	bus.Subscribe(func(e Event) { order = append(order, "first:"+string(e.Type)) })
+	bus.Subscribe(func(e Event) { order = append(order, "second:"+string(e.Type)) })
+
+	bus.Publish(Event{Type: EventCreate})
+	assert.Equal(t, []string{"first:create", "second:create"}, order)
+}
+
+func TestEventBusAsync(t *testing.T) {
+	bus := NewEventBus()
+
+	var mu sync.Mutex
+	var got []Event
+	bus.SubscribeAsync(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e)
+	})
+
+	block := &model.Block{Key: "block:1", Tags: []string{"a"}}
+	bus.Publish(Event{Type: EventCreate, Block: block})
+	block.Tags[0] = "changed"
+	bus.Wait()
+
+	require.Len(t, got, 1)
+	assert.False(t, got[0].At.IsZero())
+	assert.Equal(t, []string{"a"}, got[0].Block.Tags, "async handlers get a copy")
+}
+
+func TestBlockRepoPublishesEvents(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	var events []Event
+	db.Events().Subscribe(func(e Event) { events = append(events, e) })
+
+	start := time.Now().Add(-time.Hour)
+	block := model.NewBlock("", "proj", "", "", start)
+	require.NoError(t, repo.Create(block))
+
+	t.Run("stop_emits_stop_event_with_block", func(t *testing.T) {
+		block.TimestampEnd = time.Now()
+		require.NoError(t, repo.Stop(block))
+
+		require.Len(t, events, 2)
+		assert.Equal(t, EventCreate, events[0].Type)
+		assert.Equal(t, EventStop, events[1].Type)
+		assert.Equal(t, block.Key, events[1].Block.Key)
+		assert.False(t, events[1].Block.TimestampEnd.IsZero())
+	})
+
+	t.Run("stop_requires_end_time", func(t *testing.T) {
+		active := model.NewBlock("", "proj", "", "", start)
+		require.NoError(t, repo.Create(active))
+		assert.Error(t, repo.Stop(active))
+	})
+
+	t.Run("delete_emits_delete_event", func(t *testing.T) {
+		require.NoError(t, repo.Delete(block.Key))
+		last := events[len(events)-1]
+		assert.Equal(t, EventDelete, last.Type)
+		assert.Equal(t, block.Key, last.Block.Key)
+	})
+}
@@ -0,0 +1,505 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// ExportVersion is the version string written to export documents.
+const ExportVersion = "2"
+
+// DurationUnit selects how block durations are written in exports.
+type DurationUnit string
+
+const (
+	// DurationUnitDefault uses the format's default: seconds for JSON, hours for CSV.
+	DurationUnitDefault DurationUnit = ""
+	// DurationUnitSeconds writes whole seconds (duration_seconds).
+	DurationUnitSeconds DurationUnit = "seconds"
+	// DurationUnitHours writes decimal hours to two places (duration_hours).
+	DurationUnitHours DurationUnit = "hours"
+	// DurationUnitBoth writes both columns.
+	DurationUnitBoth DurationUnit = "both"
+)
+
+// RedactedText replaces redacted matches in exported notes.
+const RedactedText = "[redacted]"
+
+// DefaultProgressInterval is how many blocks are written between progress
+// callbacks when ExportOptions.ProgressEvery is unset.
+const DefaultProgressInterval = 1000
+
+// ExportProgressFunc is called during an export with the number of blocks
+// written so far and the total.
+type ExportProgressFunc func(written, total int)
+
+// ExportOptions configures block exports.
+type ExportOptions struct {
+	DurationUnit DurationUnit
+	// Redact lists case-insensitive regular expressions whose matches in
+	// notes are replaced with RedactedText. Stored blocks are not modified.
+	Redact []string
+	// TimeLayout, if set, is a Go time layout for the CSV start and end
+	// columns, which then carry a full timestamp instead of a clock time.
+	TimeLayout string
+	// Location is the time zone for CSV dates and times. Defaults to local time.
+	Location *time.Location
+	// Progress, if set, is called every ProgressEvery blocks and once more
+	// after the last block if that wasn't already reported.
+	Progress      ExportProgressFunc
+	ProgressEvery int
+}
+
+// progressReporter returns a function to call after each written block.
+func (o ExportOptions) progressReporter(total int) (step func(), finish func()) {
+	if o.Progress == nil {
+		return func() {}, func() {}
+	}
+	every := o.ProgressEvery
+	if every <= 0 {
+		every = DefaultProgressInterval
+	}
+
+	written := 0
+	step = func() {
+		written++
+		if written%every == 0 {
+			o.Progress(written, total)
+		}
+	}
+	finish = func() {
+		if written%every != 0 {
+			o.Progress(written, total)
+		}
+	}
+	return step, finish
+}
+
+// validateTimeLayout checks that layout is a usable Go time layout: it must
+// contain at least one reference time element and parse back what it formats.
+func validateTimeLayout(layout string) error {
+	// Any time other than the reference time itself shows whether the layout
+	// contains elements
+	sample := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	formatted := sample.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("invalid time layout %q: no date or time elements (use Go reference time, e.g. \"2006-01-02 15:04\")", layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return fmt.Errorf("invalid time layout %q: %w", layout, err)
+	}
+	return nil
+}
+
+// noteRedactor compiles the redaction patterns into a single replacer.
+func (o ExportOptions) noteRedactor() (func(string) string, error) {
+	if len(o.Redact) == 0 {
+		return func(note string) string { return note }, nil
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(o.Redact))
+	for _, p := range o.Redact {
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	return func(note string) string {
+		for _, re := range patterns {
+			note = re.ReplaceAllLiteralString(note, RedactedText)
+		}
+		return note
+	}, nil
+}
+
+// ExportedBlock is the JSON representation of a block in an export document.
+type ExportedBlock struct {
+	Key             string      `json:"key"`
+	ProjectSID      string      `json:"project_sid"`
+	Note            string      `json:"note,omitempty"`
+	Kind            string      `json:"kind,omitempty"`
+	TimestampStart  string      `json:"timestamp_start"`
+	TimestampEnd    string      `json:"timestamp_end,omitempty"`
+	DurationSeconds *int64      `json:"duration_seconds,omitempty"`
+	DurationHours   json.Number `json:"duration_hours,omitempty"`
+	IsActive        bool        `json:"is_active"`
+}
+
+// ExportBlocksJSON writes blocks as a JSON export document. Blocks are
+// written one at a time, so the document is never built in memory.
+func ExportBlocksJSON(w io.Writer, blocks []*model.Block, opts ExportOptions) error {
+	redact, err := opts.noteRedactor()
+	if err != nil {
+		return err
+	}
+	step, finish := opts.progressReporter(len(blocks))
+
+	bw := bufio.NewWriter(w)
+	exportedAt, _ := json.Marshal(time.Now().Format(time.RFC3339))
+	fmt.Fprintf(bw, "{\n  \"version\": %q,\n  \"exported_at\": %s,\n  \"blocks\": [", ExportVersion, exportedAt)
+
+	for i, b := range blocks {
+		out := exportBlock(b, opts)
+		out.Note = redact(b.Note)
+		data, err := json.MarshalIndent(out, "    ", "  ")
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n    ")
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+		step()
+	}
+
+	if len(blocks) > 0 {
+		bw.WriteString("\n  ")
+	}
+	fmt.Fprintf(bw, "],\n  \"count\": %d\n}\n", len(blocks))
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	finish()
+	return nil
+}
+
+// ExportBlocksCSV writes blocks as CSV rows with a header.
+func ExportBlocksCSV(w io.Writer, blocks []*model.Block, opts ExportOptions) error {
+	redact, err := opts.noteRedactor()
+	if err != nil {
+		return err
+	}
+
+	clock := "15:04"
+	if opts.TimeLayout != "" {
+		if err := validateTimeLayout(opts.TimeLayout); err != nil {
+			return err
+		}
+		clock = opts.TimeLayout
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	writer := csv.NewWriter(w)
+	seconds := opts.DurationUnit == DurationUnitSeconds || opts.DurationUnit == DurationUnitBoth
+	hours := opts.DurationUnit != DurationUnitSeconds
+
+	// Write header
+	header := []string{"date", "project", "start", "end"}
+	if seconds {
+		header = append(header, "duration_seconds")
+	}
+	if hours {
+		header = append(header, "duration_hours")
+	}
+	header = append(header, "note", "tags")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	step, finish := opts.progressReporter(len(blocks))
+
+	// Write rows
+	for _, b := range blocks {
+		start := b.TimestampStart.In(loc)
+		endStr := ""
+		if !b.TimestampEnd.IsZero() {
+			endStr = b.TimestampEnd.In(loc).Format(clock)
+		}
+
+		row := []string{
+			start.Format("2006-01-02"),
+			b.ProjectSID,
+			start.Format(clock),
+			endStr,
+		}
+		if seconds {
+			row = append(row, strconv.FormatInt(b.DurationSeconds(), 10))
+		}
+		if hours {
+			row = append(row, formatHours(b.Duration()))
+		}
+		row = append(row, redact(b.Note), strings.Join(b.Tags, ","))
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		step()
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	finish()
+	return nil
+}
+
+// ErrTimeclockOverlap is returned by ExportBlocksTimeclock for blocks that
+// share time, which timeclock cannot represent.
+var ErrTimeclockOverlap = errors.New("overlapping blocks cannot be exported as timeclock")
+
+// ExportBlocksTimeclock writes blocks as hledger timeclock entries in
+// chronological order: an "i" line clocking in to project:task (or just the
+// project) with the note as description, then an "o" line clocking out.
+// Active blocks only get the "i" line. Times are in opts.Location, which
+// defaults to local time. Overlapping blocks, including any block starting
+// while an active one runs, return ErrTimeclockOverlap before anything is
+// written.
+func ExportBlocksTimeclock(w io.Writer, blocks []*model.Block, opts ExportOptions) error {
+	redact, err := opts.noteRedactor()
+	if err != nil {
+		return err
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	sorted := append([]*model.Block(nil), blocks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TimestampStart.Before(sorted[j].TimestampStart)
+	})
+
+	// Each clock-in must follow the previous clock-out
+	var last *model.Block
+	var lastEnd time.Time
+	now := time.Now()
+	for _, b := range sorted {
+		if last != nil && b.TimestampStart.Before(lastEnd) {
+			return fmt.Errorf("%w: %s starts before %s ends", ErrTimeclockOverlap, b.Key, last.Key)
+		}
+		if end := b.EndAt(now); last == nil || end.After(lastEnd) {
+			last, lastEnd = b, end
+		}
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	step, finish := opts.progressReporter(len(sorted))
+	for _, b := range sorted {
+		account := b.ProjectSID
+		if b.TaskSID != "" {
+			account += ":" + b.TaskSID
+		}
+		line := "i " + b.TimestampStart.In(loc).Format(layout) + " " + account
+		// Descriptions are separated from the account by two spaces
+		if note := strings.Join(strings.Fields(redact(b.Note)), " "); note != "" {
+			line += "  " + note
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+		if !b.TimestampEnd.IsZero() {
+			if _, err := fmt.Fprintln(w, "o "+b.TimestampEnd.In(loc).Format(layout)); err != nil {
+				return err
+			}
+		}
+		step()
+	}
+
+	finish()
+	return nil
+}
+
+// AggregateExportOptions configures an aggregated (totals-only) export.
+type AggregateExportOptions struct {
+	// ByDay adds per-day totals, broken down by project.
+	ByDay bool
+	// Location determines day boundaries. Defaults to local time.
+	Location *time.Location
+	// DailyCap, when non-zero, clamps each day's total in by-day exports,
+	// scaling the day's per-project times down to match. The overall
+	// per-project totals are not capped.
+	DailyCap time.Duration
+}
+
+// ProjectTotalOutput is a per-project total in an aggregated export.
+type ProjectTotalOutput struct {
+	ProjectSID      string `json:"project_sid"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	BlockCount      int    `json:"block_count"`
+}
+
+// DayTotalOutput is a per-day total in an aggregated export.
+type DayTotalOutput struct {
+	Date            string                `json:"date"`
+	DurationSeconds int64                 `json:"duration_seconds"`
+	BlockCount      int                   `json:"block_count"`
+	Projects        []*ProjectTotalOutput `json:"projects"`
+}
+
+// ExportAggregateJSON writes per-project (and optionally per-day) totals as JSON.
+// Individual blocks, and therefore their notes, are never included.
+func ExportAggregateJSON(w io.Writer, blocks []*model.Block, opts AggregateExportOptions) error {
+	data := struct {
+		Version              string                `json:"version"`
+		ExportedAt           string                `json:"exported_at"`
+		TotalDurationSeconds int64                 `json:"total_duration_seconds"`
+		Projects             []*ProjectTotalOutput `json:"projects"`
+		Days                 []*DayTotalOutput     `json:"days,omitempty"`
+	}{
+		Version:              ExportVersion,
+		ExportedAt:           time.Now().Format(time.RFC3339),
+		TotalDurationSeconds: int64(TotalDuration(blocks).Seconds()),
+		Projects:             projectTotals(AggregateByProject(blocks)),
+	}
+
+	if opts.ByDay {
+		loc := opts.Location
+		if loc == nil {
+			loc = time.Local
+		}
+		byDay := groupBlocksByDay(blocks, loc)
+		for _, day := range CapDays(AggregateByDay(blocks, loc), opts.DailyCap) {
+			data.Days = append(data.Days, &DayTotalOutput{
+				Date:            day.Date.Format("2006-01-02"),
+				DurationSeconds: int64(day.Duration.Seconds()),
+				BlockCount:      day.BlockCount,
+				Projects:        projectTotals(cappedProjectAggregates(byDay[day.Date], opts.DailyCap)),
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// ExportAggregateCSV writes per-project totals as CSV. With ByDay set, one row
+// is written per day and project instead.
+func ExportAggregateCSV(w io.Writer, blocks []*model.Block, opts AggregateExportOptions) error {
+	writer := csv.NewWriter(w)
+
+	if !opts.ByDay {
+		if err := writer.Write([]string{"project", "duration_hours", "block_count"}); err != nil {
+			return err
+		}
+		for _, agg := range AggregateByProject(blocks) {
+			if err := writer.Write([]string{
+				agg.ProjectSID,
+				formatHours(agg.Duration),
+				strconv.Itoa(agg.BlockCount),
+			}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if err := writer.Write([]string{"date", "project", "duration_hours", "block_count"}); err != nil {
+		return err
+	}
+	byDay := groupBlocksByDay(blocks, loc)
+	for _, day := range AggregateByDay(blocks, loc) {
+		for _, agg := range cappedProjectAggregates(byDay[day.Date], opts.DailyCap) {
+			if err := writer.Write([]string{
+				day.Date.Format("2006-01-02"),
+				agg.ProjectSID,
+				formatHours(agg.Duration),
+				strconv.Itoa(agg.BlockCount),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportBlock converts a block to its export representation.
+func exportBlock(b *model.Block, opts ExportOptions) *ExportedBlock {
+	out := &ExportedBlock{
+		Key:            b.Key,
+		ProjectSID:     b.ProjectSID,
+		Note:           b.Note,
+		Kind:           b.Kind,
+		TimestampStart: b.TimestampStart.Format(time.RFC3339),
+		IsActive:       b.IsActive(),
+	}
+	if !b.TimestampEnd.IsZero() {
+		out.TimestampEnd = b.TimestampEnd.Format(time.RFC3339)
+	}
+	if opts.DurationUnit != DurationUnitHours {
+		seconds := b.DurationSeconds()
+		out.DurationSeconds = &seconds
+	}
+	if opts.DurationUnit == DurationUnitHours || opts.DurationUnit == DurationUnitBoth {
+		out.DurationHours = json.Number(formatHours(b.Duration()))
+	}
+	return out
+}
+
+// projectTotals converts project aggregates to their export representation.
+func projectTotals(aggs []ProjectAggregate) []*ProjectTotalOutput {
+	totals := make([]*ProjectTotalOutput, len(aggs))
+	for i, agg := range aggs {
+		totals[i] = &ProjectTotalOutput{
+			ProjectSID:      agg.ProjectSID,
+			DurationSeconds: int64(agg.Duration.Seconds()),
+			BlockCount:      agg.BlockCount,
+		}
+	}
+	return totals
+}
+
+// cappedProjectAggregates aggregates one day's blocks by project. If the
+// day's total exceeds limit, every project's time is scaled down so the day
+// adds up to limit, matching CapDays. A limit of zero or less caps nothing.
+func cappedProjectAggregates(blocks []*model.Block, limit time.Duration) []ProjectAggregate {
+	aggs := AggregateByProject(blocks)
+	total := TotalDuration(blocks)
+	if limit <= 0 || total <= limit {
+		return aggs
+	}
+	scale := float64(limit) / float64(total)
+	for i := range aggs {
+		aggs[i].Duration = time.Duration(float64(aggs[i].Duration) * scale)
+	}
+	return aggs
+}
+
+// groupBlocksByDay groups blocks by the local calendar day they start on.
+func groupBlocksByDay(blocks []*model.Block, loc *time.Location) map[time.Time][]*model.Block {
+	groups := make(map[time.Time][]*model.Block)
+	for _, b := range blocks {
+		day := startOfDay(b.TimestampStart, loc)
+		groups[day] = append(groups[day], b)
+	}
+	return groups
+}
+
+// formatHours formats a duration as decimal hours with two places.
+func formatHours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
@@ -0,0 +1,466 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportTestBlocks returns completed blocks across two projects and two days.
+func exportTestBlocks() []*model.Block {
+	day1 := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	return []*model.Block{
+		{Key: "block:1", ProjectSID: "alpha", Note: "secret client call", TimestampStart: day1, TimestampEnd: day1.Add(90 * time.Minute)},
+		{Key: "block:2", ProjectSID: "beta", Note: "confidential review", TimestampStart: day1.Add(2 * time.Hour), TimestampEnd: day1.Add(3 * time.Hour)},
+		{Key: "block:3", ProjectSID: "alpha", Note: "private notes", TimestampStart: day2, TimestampEnd: day2.Add(30 * time.Minute)},
+	}
+}
+
+// =============================================================================
+// Block Export Tests
+// =============================================================================
+
+func TestExportBlocksJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportBlocksJSON(&buf, exportTestBlocks(), ExportOptions{}))
+
+	var doc struct {
+		Version string `json:"version"`
+		Count   int    `json:"count"`
+		Blocks  []struct {
+			Key             string `json:"key"`
+			DurationSeconds int64  `json:"duration_seconds"`
+		} `json:"blocks"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, ExportVersion, doc.Version)
+	assert.Equal(t, 3, doc.Count)
+	assert.Equal(t, int64(5400), doc.Blocks[0].DurationSeconds)
+}
+
+func TestExportBlocksCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ExportBlocksCSV(&buf, exportTestBlocks(), ExportOptions{}))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "duration_hours", records[0][4])
+	assert.Equal(t, "1.50", records[1][4])
+}
+
+func TestExportBlocksTimeclock(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	start := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	completed := model.NewBlock("", "clientwork", "api", "fix login\nand tests", start)
+	completed.TimestampEnd = start.Add(90 * time.Minute)
+	earlier := model.NewBlock("", "admin", "", "", start.Add(-2*time.Hour))
+	earlier.TimestampEnd = start.Add(-time.Hour)
+	active := model.NewBlock("", "clientwork", "", "", start.Add(3*time.Hour))
+
+	var buf bytes.Buffer
+	opts := ExportOptions{Location: loc}
+	require.NoError(t, ExportBlocksTimeclock(&buf, []*model.Block{active, completed, earlier}, opts))
+
+	assert.Equal(t, []string{
+		"i 2025-03-10 07:00:00 admin",
+		"o 2025-03-10 08:00:00",
+		"i 2025-03-10 09:00:00 clientwork:api  fix login and tests",
+		"o 2025-03-10 10:30:00",
+		"i 2025-03-10 12:00:00 clientwork",
+	}, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+
+	t.Run("redaction", func(t *testing.T) {
+		var buf bytes.Buffer
+		opts := ExportOptions{Location: loc, Redact: []string{"login"}}
+		require.NoError(t, ExportBlocksTimeclock(&buf, []*model.Block{completed}, opts))
+		assert.Contains(t, buf.String(), "clientwork:api  fix "+RedactedText+" and tests")
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		overlapping := model.NewBlock("", "admin", "", "", start.Add(time.Hour))
+		overlapping.TimestampEnd = start.Add(2 * time.Hour)
+
+		var buf bytes.Buffer
+		err := ExportBlocksTimeclock(&buf, []*model.Block{completed, overlapping}, opts)
+		assert.ErrorIs(t, err, ErrTimeclockOverlap)
+		assert.Empty(t, buf.String())
+
+		// Nothing may start while a block is still active
+		later := model.NewBlock("", "admin", "", "", start.Add(4*time.Hour))
+		later.TimestampEnd = start.Add(5 * time.Hour)
+		err = ExportBlocksTimeclock(&buf, []*model.Block{active, later}, opts)
+		assert.ErrorIs(t, err, ErrTimeclockOverlap)
+
+		// Touching blocks are fine
+		touching := model.NewBlock("", "admin", "", "", completed.TimestampEnd)
+		touching.TimestampEnd = touching.TimestampStart.Add(time.Hour)
+		assert.NoError(t, ExportBlocksTimeclock(&buf, []*model.Block{completed, touching}, opts))
+	})
+}
+
+func TestExportActiveBlock(t *testing.T) {
+	db := setupTestDB(t)
+	blockRepo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	exportCount := func(t *testing.T) (jsonCount, csvRows int) {
+		blocks, err := activeRepo.ActiveBlocks(blockRepo)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, blocks, ExportOptions{}))
+		var doc struct {
+			Count  int               `json:"count"`
+			Blocks []json.RawMessage `json:"blocks"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		require.Len(t, doc.Blocks, doc.Count)
+
+		buf.Reset()
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{}))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		return doc.Count, len(records) - 1
+	}
+
+	t.Run("idle", func(t *testing.T) {
+		jsonCount, csvRows := exportCount(t)
+		assert.Zero(t, jsonCount)
+		assert.Zero(t, csvRows)
+	})
+
+	done := model.NewBlock("", "alpha", "", "", time.Now().Add(-2*time.Hour))
+	done.TimestampEnd = done.TimestampStart.Add(time.Hour)
+	require.NoError(t, blockRepo.Create(done))
+	active := model.NewBlock("", "alpha", "", "", time.Now().Add(-time.Hour))
+	require.NoError(t, blockRepo.Create(active))
+	require.NoError(t, activeRepo.SetActive(active.Key))
+
+	t.Run("tracking", func(t *testing.T) {
+		jsonCount, csvRows := exportCount(t)
+		assert.Equal(t, 1, jsonCount)
+		assert.Equal(t, 1, csvRows)
+	})
+}
+
+func TestExportDurationUnit(t *testing.T) {
+	blocks := exportTestBlocks()[:1] // 90 minutes
+
+	decode := func(t *testing.T, unit DurationUnit) (string, map[string]json.RawMessage) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, blocks, ExportOptions{DurationUnit: unit}))
+		var doc struct {
+			Blocks []map[string]json.RawMessage `json:"blocks"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		return buf.String(), doc.Blocks[0]
+	}
+
+	t.Run("json_hours_instead_of_seconds", func(t *testing.T) {
+		raw, block := decode(t, DurationUnitHours)
+		assert.Contains(t, raw, `"duration_hours": 1.50`)
+		assert.NotContains(t, block, "duration_seconds")
+	})
+
+	t.Run("json_both", func(t *testing.T) {
+		_, block := decode(t, DurationUnitBoth)
+		assert.Equal(t, "1.50", string(block["duration_hours"]))
+		assert.Equal(t, "5400", string(block["duration_seconds"]))
+	})
+
+	t.Run("json_default_is_seconds", func(t *testing.T) {
+		_, block := decode(t, DurationUnitDefault)
+		assert.Equal(t, "5400", string(block["duration_seconds"]))
+		assert.NotContains(t, block, "duration_hours")
+	})
+
+	t.Run("csv_both", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{DurationUnit: DurationUnitBoth}))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "duration_seconds", records[0][4])
+		assert.Equal(t, "duration_hours", records[0][5])
+		assert.Equal(t, "5400", records[1][4])
+		assert.Equal(t, "1.50", records[1][5])
+	})
+
+	t.Run("csv_seconds_only", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{DurationUnit: DurationUnitSeconds}))
+		assert.NotContains(t, buf.String(), "duration_hours")
+	})
+}
+
+func TestExportCSVTimeLayout(t *testing.T) {
+	blocks := exportTestBlocks()[:1] // 09:00-10:30 UTC on 2025-03-10
+
+	t.Run("custom_layout", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{TimeLayout: "2006-01-02 15:04", Location: time.UTC}))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "2025-03-10 09:00", records[1][2])
+		assert.Equal(t, "2025-03-10 10:30", records[1][3])
+	})
+
+	t.Run("converted_to_location", func(t *testing.T) {
+		loc := time.FixedZone("UTC+2", 2*60*60)
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{TimeLayout: "2006-01-02 15:04", Location: loc}))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "2025-03-10 11:00", records[1][2])
+	})
+
+	t.Run("default_clock_time", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{Location: time.UTC}))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "09:00", records[1][2])
+	})
+
+	t.Run("invalid_layout", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ExportBlocksCSV(&buf, blocks, ExportOptions{TimeLayout: "YYYY-MM-DD"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid time layout")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestExportRedaction(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock("", "consulting", "", "Call with Acme Corp about ACME rollout", start)
+	block.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(block))
+
+	blocks, err := repo.List()
+	require.NoError(t, err)
+	opts := ExportOptions{Redact: []string{"acme( corp)?"}}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, blocks, opts))
+		assert.NotContains(t, strings.ToLower(buf.String()), "acme")
+		assert.Contains(t, buf.String(), "Call with [redacted] about [redacted] rollout")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, opts))
+		assert.NotContains(t, strings.ToLower(buf.String()), "acme")
+	})
+
+	t.Run("stored_data_unchanged", func(t *testing.T) {
+		stored, err := repo.Get(block.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "Call with Acme Corp about ACME rollout", stored.Note)
+		assert.Equal(t, "Call with Acme Corp about ACME rollout", blocks[0].Note)
+	})
+
+	t.Run("invalid_pattern", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Error(t, ExportBlocksJSON(&buf, blocks, ExportOptions{Redact: []string{"("}}))
+	})
+}
+
+// =============================================================================
+// Aggregate Export Tests
+// =============================================================================
+
+func TestExportAggregateJSON(t *testing.T) {
+	blocks := exportTestBlocks()
+
+	var raw bytes.Buffer
+	require.NoError(t, ExportBlocksJSON(&raw, blocks, ExportOptions{}))
+	var rawDoc struct {
+		Blocks []struct {
+			ProjectSID      string `json:"project_sid"`
+			DurationSeconds int64  `json:"duration_seconds"`
+		} `json:"blocks"`
+	}
+	require.NoError(t, json.Unmarshal(raw.Bytes(), &rawDoc))
+	rawSums := make(map[string]int64)
+	var rawTotal int64
+	for _, b := range rawDoc.Blocks {
+		rawSums[b.ProjectSID] += b.DurationSeconds
+		rawTotal += b.DurationSeconds
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportAggregateJSON(&buf, blocks, AggregateExportOptions{ByDay: true, Location: time.UTC}))
+
+	t.Run("notes_never_appear", func(t *testing.T) {
+		for _, b := range blocks {
+			assert.NotContains(t, buf.String(), b.Note)
+		}
+	})
+
+	var doc struct {
+		TotalDurationSeconds int64                 `json:"total_duration_seconds"`
+		Projects             []*ProjectTotalOutput `json:"projects"`
+		Days                 []*DayTotalOutput     `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	t.Run("totals_match_raw_export", func(t *testing.T) {
+		assert.Equal(t, rawTotal, doc.TotalDurationSeconds)
+		require.Len(t, doc.Projects, 2)
+		for _, p := range doc.Projects {
+			assert.Equal(t, rawSums[p.ProjectSID], p.DurationSeconds, p.ProjectSID)
+		}
+	})
+
+	t.Run("per_day_totals", func(t *testing.T) {
+		require.Len(t, doc.Days, 2)
+		assert.Equal(t, "2025-03-10", doc.Days[0].Date)
+		assert.Equal(t, int64(9000), doc.Days[0].DurationSeconds)
+		assert.Len(t, doc.Days[0].Projects, 2)
+		assert.Equal(t, "2025-03-11", doc.Days[1].Date)
+		assert.Equal(t, int64(1800), doc.Days[1].DurationSeconds)
+	})
+
+	t.Run("days_omitted_without_by_day", func(t *testing.T) {
+		var plain bytes.Buffer
+		require.NoError(t, ExportAggregateJSON(&plain, blocks, AggregateExportOptions{}))
+		assert.NotContains(t, plain.String(), `"days"`)
+	})
+
+	t.Run("daily_cap", func(t *testing.T) {
+		var capped bytes.Buffer
+		opts := AggregateExportOptions{ByDay: true, Location: time.UTC, DailyCap: time.Hour}
+		require.NoError(t, ExportAggregateJSON(&capped, blocks, opts))
+		var cappedDoc struct {
+			Days []*DayTotalOutput `json:"days"`
+		}
+		require.NoError(t, json.Unmarshal(capped.Bytes(), &cappedDoc))
+		require.Len(t, cappedDoc.Days, 2)
+		assert.Equal(t, int64(3600), cappedDoc.Days[0].DurationSeconds)
+		assert.Equal(t, int64(1800), cappedDoc.Days[1].DurationSeconds)
+
+		// The day's projects are scaled to add up to the cap
+		require.Len(t, cappedDoc.Days[0].Projects, 2)
+		assert.Equal(t, int64(2160), cappedDoc.Days[0].Projects[0].DurationSeconds)
+		assert.Equal(t, int64(1440), cappedDoc.Days[0].Projects[1].DurationSeconds)
+	})
+}
+
+func TestExportAggregateCSV(t *testing.T) {
+	blocks := exportTestBlocks()
+
+	t.Run("per_project", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportAggregateCSV(&buf, blocks, AggregateExportOptions{}))
+		for _, b := range blocks {
+			assert.NotContains(t, buf.String(), b.Note)
+		}
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, []string{"project", "duration_hours", "block_count"}, records[0])
+		assert.Equal(t, []string{"alpha", "2.00", "2"}, records[1])
+		assert.Equal(t, []string{"beta", "1.00", "1"}, records[2])
+	})
+
+	t.Run("per_day", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportAggregateCSV(&buf, blocks, AggregateExportOptions{ByDay: true, Location: time.UTC}))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, []string{"2025-03-10", "alpha", "1.50", "1"}, records[1])
+		assert.Equal(t, []string{"2025-03-10", "beta", "1.00", "1"}, records[2])
+		assert.Equal(t, []string{"2025-03-11", "alpha", "0.50", "1"}, records[3])
+	})
+
+	t.Run("per_day_daily_cap", func(t *testing.T) {
+		var buf bytes.Buffer
+		opts := AggregateExportOptions{ByDay: true, Location: time.UTC, DailyCap: time.Hour}
+		require.NoError(t, ExportAggregateCSV(&buf, blocks, opts))
+
+		// Matches the capped JSON day totals
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, []string{"2025-03-10", "alpha", "0.60", "1"}, records[1])
+		assert.Equal(t, []string{"2025-03-10", "beta", "0.40", "1"}, records[2])
+		assert.Equal(t, []string{"2025-03-11", "alpha", "0.50", "1"}, records[3])
+	})
+}
+
+func TestExportProgress(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	blocks := make([]*model.Block, 25)
+	for i := range blocks {
+		b := model.NewBlock("", "alpha", "", "note", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		blocks[i] = b
+	}
+
+	type call struct{ written, total int }
+	record := func(calls *[]call) ExportProgressFunc {
+		return func(written, total int) {
+			*calls = append(*calls, call{written, total})
+		}
+	}
+
+	t.Run("json_streams_valid_output", func(t *testing.T) {
+		var calls []call
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, blocks, ExportOptions{Progress: record(&calls), ProgressEvery: 10}))
+		assert.Equal(t, []call{{10, 25}, {20, 25}, {25, 25}}, calls)
+
+		var doc struct {
+			Count  int              `json:"count"`
+			Blocks []*ExportedBlock `json:"blocks"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, 25, doc.Count)
+		require.Len(t, doc.Blocks, 25)
+		assert.Equal(t, blocks[24].Key, doc.Blocks[24].Key)
+	})
+
+	t.Run("exact_multiple_not_repeated", func(t *testing.T) {
+		var calls []call
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, blocks[:20], ExportOptions{Progress: record(&calls), ProgressEvery: 10}))
+		assert.Equal(t, []call{{10, 20}, {20, 20}}, calls)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var calls []call
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksCSV(&buf, blocks, ExportOptions{Progress: record(&calls), ProgressEvery: 10}))
+		assert.Len(t, calls, 3)
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, records, 26)
+	})
+
+	t.Run("empty_json_is_valid", func(t *testing.T) {
+		var calls []call
+		var buf bytes.Buffer
+		require.NoError(t, ExportBlocksJSON(&buf, nil, ExportOptions{Progress: record(&calls)}))
+		assert.Empty(t, calls)
+		assert.True(t, json.Valid(buf.Bytes()))
+		assert.Contains(t, buf.String(), `"blocks": []`)
+	})
+}
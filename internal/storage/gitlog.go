@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// GitLogFormat is the git log format ParseGitLog expects: author name,
+// strict ISO 8601 author date, and subject, separated by tabs.
+//
+//	git log --format='%an%x09%aI%x09%s'
+const GitLogFormat = "%an%x09%aI%x09%s"
+
+const (
+	// DefaultGitSessionGap is the longest pause between commits that still
+	// counts as the same work session.
+	DefaultGitSessionGap = 2 * time.Hour
+	// DefaultGitLeadIn is the time assumed to be spent before the first
+	// commit of a session.
+	DefaultGitLeadIn = 30 * time.Minute
+)
+
+// GitCommit is a single commit parsed from git log output.
+type GitCommit struct {
+	Author  string
+	Time    time.Time
+	Message string
+}
+
+// GitLogOptions configures how commits are grouped into blocks.
+type GitLogOptions struct {
+	// Gap is the maximum pause between commits in one session.
+	// Defaults to DefaultGitSessionGap.
+	Gap time.Duration
+	// LeadIn is subtracted from the first commit of each session to
+	// approximate when work began. Defaults to DefaultGitLeadIn; negative
+	// values disable it.
+	LeadIn time.Duration
+	// Author, if set, only keeps commits by this author (case-insensitive).
+	Author string
+}
+
+// ParseGitLog reads commits in GitLogFormat. Blank lines are ignored.
+func ParseGitLog(r io.Reader) ([]GitCommit, error) {
+	var commits []GitCommit
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("line %d: expected author, date and subject separated by tabs", lineNum)
+		}
+		ts, err := time.Parse(time.RFC3339, strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid commit date: %w", lineNum, err)
+		}
+
+		commit := GitCommit{Author: strings.TrimSpace(parts[0]), Time: ts}
+		if len(parts) == 3 {
+			commit.Message = strings.TrimSpace(parts[2])
+		}
+		commits = append(commits, commit)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// GitCommitsToBlocks groups commits into work sessions and returns one
+// completed block per session under projectSID (typically derived from the
+// repository name).
+// Commits less than Gap apart share a session; each block runs from the
+// first commit minus LeadIn to the last commit, and its note lists the
+// commit subjects in order. Blocks are returned oldest first.
+func GitCommitsToBlocks(commits []GitCommit, projectSID string, opts GitLogOptions) ([]*model.Block, error) {
+	if projectSID == "" {
+		return nil, fmt.Errorf("project is required for git import")
+	}
+	if opts.Gap <= 0 {
+		opts.Gap = DefaultGitSessionGap
+	}
+	if opts.LeadIn == 0 {
+		opts.LeadIn = DefaultGitLeadIn
+	} else if opts.LeadIn < 0 {
+		opts.LeadIn = 0
+	}
+
+	var kept []GitCommit
+	for _, c := range commits {
+		if opts.Author == "" || strings.EqualFold(c.Author, opts.Author) {
+			kept = append(kept, c)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Time.Before(kept[j].Time)
+	})
+
+	var blocks []*model.Block
+	var session []GitCommit
+	flush := func() {
+		if len(session) == 0 {
+			return
+		}
+		start := session[0].Time.Add(-opts.LeadIn)
+		// Don't let the lead-in reach back into the previous session
+		if n := len(blocks); n > 0 && start.Before(blocks[n-1].TimestampEnd) {
+			start = blocks[n-1].TimestampEnd
+		}
+
+		var messages []string
+		for _, c := range session {
+			if c.Message != "" {
+				messages = append(messages, c.Message)
+			}
+		}
+
+		block := model.NewBlock("", projectSID, "", strings.Join(messages, "; "), start)
+		block.TimestampEnd = session[len(session)-1].Time
+		block.Tags = []string{"git"}
+		blocks = append(blocks, block)
+		session = nil
+	}
+
+	for _, c := range kept {
+		if n := len(session); n > 0 && c.Time.Sub(session[n-1].Time) > opts.Gap {
+			flush()
+		}
+		session = append(session, c)
+	}
+	flush()
+
+	return blocks, nil
+}
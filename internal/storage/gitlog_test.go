@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleGitLog = `Ada Lovelace	2025-03-10T09:00:00Z	Add parser
+Ada Lovelace	2025-03-10T09:45:00Z	Fix parser edge case
+Ada Lovelace	2025-03-10T11:30:00Z	Add tests
+
+Ada Lovelace	2025-03-10T15:00:00Z	Refactor storage
+Grace Hopper	2025-03-10T15:10:00Z	Review feedback
+Ada Lovelace	2025-03-11T10:00:00Z	Release v1.0
+`
+
+// =============================================================================
+// Git Log Import Tests
+// =============================================================================
+
+func TestParseGitLog(t *testing.T) {
+	commits, err := ParseGitLog(strings.NewReader(sampleGitLog))
+	require.NoError(t, err)
+	require.Len(t, commits, 6)
+	assert.Equal(t, "Ada Lovelace", commits[0].Author)
+	assert.Equal(t, time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), commits[0].Time.UTC())
+	assert.Equal(t, "Add parser", commits[0].Message)
+
+	t.Run("invalid_date", func(t *testing.T) {
+		_, err := ParseGitLog(strings.NewReader("Ada\tyesterday\tmsg\n"))
+		assert.ErrorContains(t, err, "line 1")
+	})
+}
+
+func TestGitCommitsToBlocks(t *testing.T) {
+	commits, err := ParseGitLog(strings.NewReader(sampleGitLog))
+	require.NoError(t, err)
+
+	t.Run("groups_within_gap", func(t *testing.T) {
+		blocks, err := GitCommitsToBlocks(commits, "my-repo", GitLogOptions{})
+		require.NoError(t, err)
+		// 09:00-11:30, 15:00-15:10, next day 10:00
+		require.Len(t, blocks, 3)
+
+		first := blocks[0]
+		assert.Equal(t, "my-repo", first.ProjectSID)
+		assert.Equal(t, time.Date(2025, 3, 10, 8, 30, 0, 0, time.UTC), first.TimestampStart.UTC())
+		assert.Equal(t, time.Date(2025, 3, 10, 11, 30, 0, 0, time.UTC), first.TimestampEnd.UTC())
+		assert.Equal(t, "Add parser; Fix parser edge case; Add tests", first.Note)
+		assert.True(t, first.HasTag("git"))
+		for _, b := range blocks {
+			assert.NoError(t, b.Validate())
+		}
+	})
+
+	t.Run("smaller_gap_splits_sessions", func(t *testing.T) {
+		blocks, err := GitCommitsToBlocks(commits, "repo", GitLogOptions{Gap: time.Hour})
+		require.NoError(t, err)
+		assert.Len(t, blocks, 4)
+	})
+
+	t.Run("author_filter", func(t *testing.T) {
+		blocks, err := GitCommitsToBlocks(commits, "repo", GitLogOptions{Author: "grace hopper"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		assert.Equal(t, "Review feedback", blocks[0].Note)
+	})
+
+	t.Run("lead_in_does_not_overlap_previous_session", func(t *testing.T) {
+		blocks, err := GitCommitsToBlocks(commits, "repo", GitLogOptions{Gap: time.Hour, LeadIn: 3 * time.Hour})
+		require.NoError(t, err)
+		for i := 1; i < len(blocks); i++ {
+			assert.False(t, blocks[i].TimestampStart.Before(blocks[i-1].TimestampEnd))
+		}
+	})
+
+	t.Run("project_required", func(t *testing.T) {
+		_, err := GitCommitsToBlocks(commits, "", GitLogOptions{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// OverlapPolicy says what an import does with a block that overlaps a
+// stored one.
+type OverlapPolicy string
+
+const (
+	// OverlapAllow imports overlapping blocks anyway.
+	OverlapAllow OverlapPolicy = "allow"
+	// OverlapSkip leaves overlapping blocks out of the import.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapError fails the import at the first overlapping block.
+	OverlapError OverlapPolicy = "error"
+)
+
+// OverlapPolicies lists the valid overlap policies.
+var OverlapPolicies = []OverlapPolicy{OverlapAllow, OverlapSkip, OverlapError}
+
+// ErrImportOverlap is returned under OverlapError for a block that overlaps
+// a stored one.
+var ErrImportOverlap = errors.New("block overlaps an existing block")
+
+// ParseOverlapPolicy parses an overlap policy name. Empty means OverlapAllow.
+func ParseOverlapPolicy(name string) (OverlapPolicy, error) {
+	if name == "" {
+		return OverlapAllow, nil
+	}
+	for _, p := range OverlapPolicies {
+		if OverlapPolicy(strings.ToLower(name)) == p {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown overlap policy %q (must be allow, skip or error)", name)
+}
+
+// FindOverlaps returns the stored blocks that share time with block, as
+// decided by model.Block.OverlapsWith. A stored block with the same key is
+// the block itself and is not reported.
+func (r *BlockRepo) FindOverlaps(block *model.Block) ([]*model.Block, error) {
+	candidates, err := r.ListByTimeRange(block.TimestampStart, block.EndAt(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	var overlaps []*model.Block
+	for _, b := range candidates {
+		if b.Key != block.Key && block.OverlapsWith(b) {
+			overlaps = append(overlaps, b)
+		}
+	}
+	return overlaps, nil
+}
+
+// CheckImportOverlap applies policy to a block about to be imported. It
+// reports whether the block should be skipped, and under OverlapError
+// returns an error wrapping ErrImportOverlap if the block overlaps a stored
+// one.
+func (r *BlockRepo) CheckImportOverlap(block *model.Block, policy OverlapPolicy) (bool, error) {
+	if policy == OverlapAllow || policy == "" {
+		return false, nil
+	}
+	overlaps, err := r.FindOverlaps(block)
+	if err != nil || len(overlaps) == 0 {
+		return false, err
+	}
+	if policy == OverlapSkip {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: %s on %s overlaps %s on %s",
+		ErrImportOverlap,
+		block.TimestampStart.Format(time.RFC3339), block.ProjectSID,
+		overlaps[0].TimestampStart.Format(time.RFC3339), overlaps[0].ProjectSID)
+}
+
+// FilterImportOverlaps applies policy to blocks about to be imported and
+// returns those to write, along with how many were skipped. Under
+// OverlapError the first overlap fails the whole set, before anything is
+// written. Only stored blocks are considered, not overlaps within blocks.
+func (r *BlockRepo) FilterImportOverlaps(blocks []*model.Block, policy OverlapPolicy) ([]*model.Block, int, error) {
+	if policy == OverlapAllow || policy == "" {
+		return blocks, 0, nil
+	}
+
+	kept := make([]*model.Block, 0, len(blocks))
+	skipped := 0
+	for _, b := range blocks {
+		skip, err := r.CheckImportOverlap(b, policy)
+		if err != nil {
+			return nil, 0, err
+		}
+		if skip {
+			skipped++
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return kept, skipped, nil
+}
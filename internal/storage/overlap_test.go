@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Import Overlap Tests
+// =============================================================================
+
+var overlapBase = time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+// overlapBlock returns a closed block on project from start to end hours
+// after overlapBase.
+func overlapBlock(project string, start, end float64) *model.Block {
+	b := model.NewBlock("", project, "", "", overlapBase.Add(time.Duration(start*float64(time.Hour))))
+	b.TimestampEnd = overlapBase.Add(time.Duration(end * float64(time.Hour)))
+	return b
+}
+
+// setupOverlapRepo returns a repo holding blocks from 9:00 to 10:00 and
+// from 12:00 to 13:00.
+func setupOverlapRepo(t *testing.T) *BlockRepo {
+	repo := NewBlockRepo(setupTestDB(t))
+	require.NoError(t, repo.Create(overlapBlock("existing", 0, 1)))
+	require.NoError(t, repo.Create(overlapBlock("existing", 3, 4)))
+	return repo
+}
+
+// overlapImport returns blocks to import: two overlapping the stored ones,
+// one touching a stored one and one clear of them.
+func overlapImport() []*model.Block {
+	return []*model.Block{
+		overlapBlock("a", 0.5, 1.5),
+		overlapBlock("b", 1, 2),
+		overlapBlock("c", 2, 3.5),
+		overlapBlock("d", 5, 6),
+	}
+}
+
+func TestFilterImportOverlapsAllow(t *testing.T) {
+	repo := setupOverlapRepo(t)
+
+	kept, skipped, err := repo.FilterImportOverlaps(overlapImport(), OverlapAllow)
+	require.NoError(t, err)
+	assert.Len(t, kept, 4)
+	assert.Equal(t, 0, skipped)
+}
+
+func TestFilterImportOverlapsSkip(t *testing.T) {
+	repo := setupOverlapRepo(t)
+
+	kept, skipped, err := repo.FilterImportOverlaps(overlapImport(), OverlapSkip)
+	require.NoError(t, err)
+	assert.Equal(t, 2, skipped)
+	var projects []string
+	for _, b := range kept {
+		projects = append(projects, b.ProjectSID)
+	}
+	assert.Equal(t, []string{"b", "d"}, projects)
+}
+
+func TestFilterImportOverlapsError(t *testing.T) {
+	repo := setupOverlapRepo(t)
+
+	kept, _, err := repo.FilterImportOverlaps(overlapImport(), OverlapError)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrImportOverlap))
+	assert.Nil(t, kept)
+
+	kept, skipped, err := repo.FilterImportOverlaps([]*model.Block{overlapBlock("d", 5, 6)}, OverlapError)
+	require.NoError(t, err)
+	assert.Len(t, kept, 1)
+	assert.Equal(t, 0, skipped)
+}
+
+func TestBlockRepoFindOverlaps(t *testing.T) {
+	repo := setupOverlapRepo(t)
+
+	overlaps, err := repo.FindOverlaps(overlapBlock("x", 0.5, 3.5))
+	require.NoError(t, err)
+	assert.Len(t, overlaps, 2)
+
+	// Touching blocks do not overlap
+	overlaps, err = repo.FindOverlaps(overlapBlock("x", 1, 3))
+	require.NoError(t, err)
+	assert.Empty(t, overlaps)
+
+	// A stored block does not overlap itself
+	stored, err := repo.List()
+	require.NoError(t, err)
+	require.NotEmpty(t, stored)
+	overlaps, err = repo.FindOverlaps(stored[0])
+	require.NoError(t, err)
+	assert.Empty(t, overlaps)
+}
+
+func TestParseOverlapPolicy(t *testing.T) {
+	for name, want := range map[string]OverlapPolicy{
+		"":      OverlapAllow,
+		"allow": OverlapAllow,
+		"Skip":  OverlapSkip,
+		"error": OverlapError,
+	} {
+		got, err := ParseOverlapPolicy(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseOverlapPolicy("merge")
+	assert.Error(t, err)
+}
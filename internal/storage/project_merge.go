@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// SuggestProjectMerges groups projects that look like duplicates of each
+// other, such as "myproject" and "my-project". Names are compared ignoring
+// case and punctuation; two projects are similar when their SIDs or display
+// names have a similarity of at least threshold, where similarity is one
+// minus the edit distance over the longer name's length. Similarity is
+// transitive within a group. Returns groups of two or more SIDs, each sorted,
+// ordered by their first SID. The result is advisory; nothing is merged.
+func SuggestProjectMerges(projects []*model.Project, threshold float64) [][]string {
+	parent := make([]int, len(projects))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range projects {
+		for j := i + 1; j < len(projects); j++ {
+			if projectSimilarity(projects[i], projects[j]) >= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]string)
+	for i, p := range projects {
+		root := find(i)
+		groups[root] = append(groups[root], p.SID)
+	}
+
+	var result [][]string
+	for _, sids := range groups {
+		if len(sids) < 2 {
+			continue
+		}
+		sort.Strings(sids)
+		result = append(result, sids)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0] < result[j][0]
+	})
+	return result
+}
+
+// projectSimilarity returns the higher of the SID and display name
+// similarities of two projects.
+func projectSimilarity(a, b *model.Project) float64 {
+	best := nameSimilarity(a.SID, b.SID)
+	if a.DisplayName != "" && b.DisplayName != "" {
+		if s := nameSimilarity(a.DisplayName, b.DisplayName); s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// nameSimilarity compares two names ignoring case and anything but letters
+// and digits, returning 1 for equal names and 0 for entirely different ones.
+func nameSimilarity(a, b string) float64 {
+	ra, rb := normalizeName(a), normalizeName(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// normalizeName lowercases a name and drops everything but letters and
+// digits.
+func normalizeName(s string) []rune {
+	var out []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
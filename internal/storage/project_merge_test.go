@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// =============================================================================
+// Project Merge Suggestion Tests
+// =============================================================================
+
+func TestSuggestProjectMerges(t *testing.T) {
+	projects := []*model.Project{
+		model.NewProject("myproject", "My Project", ""),
+		model.NewProject("my-project", "my-project", ""),
+		model.NewProject("clientwork", "Client Work", ""),
+		model.NewProject("clientwrok", "Client Wrok", ""),
+		model.NewProject("personal", "Personal", ""),
+		model.NewProject("website", "Website", ""),
+	}
+
+	t.Run("near_duplicates_group", func(t *testing.T) {
+		assert.Equal(t, [][]string{
+			{"clientwork", "clientwrok"},
+			{"my-project", "myproject"},
+		}, SuggestProjectMerges(projects, 0.8))
+	})
+
+	t.Run("distinct_names_stay_apart", func(t *testing.T) {
+		groups := SuggestProjectMerges(projects, 0.8)
+		for _, group := range groups {
+			assert.NotContains(t, group, "personal")
+			assert.NotContains(t, group, "website")
+		}
+	})
+
+	t.Run("strict_threshold_keeps_only_exact_matches", func(t *testing.T) {
+		assert.Equal(t, [][]string{{"my-project", "myproject"}}, SuggestProjectMerges(projects, 1))
+	})
+
+	t.Run("display_names_count", func(t *testing.T) {
+		groups := SuggestProjectMerges([]*model.Project{
+			model.NewProject("acme", "Acme Corp", ""),
+			model.NewProject("acmecorp2", "ACME corp", ""),
+		}, 0.9)
+		assert.Equal(t, [][]string{{"acme", "acmecorp2"}}, groups)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, SuggestProjectMerges(nil, 0.8))
+	})
+}
@@ -1,6 +1,11 @@
 package storage
 
 import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/logging"
 	"github.com/manav03panchal/humantime/internal/model"
 )
 
@@ -31,13 +36,14 @@ func (r *ProjectRepo) Get(sid string) (*model.Project, error) {
 }
 
 // GetOrCreate retrieves a project by SID, creating it if it doesn't exist.
+// New projects are given the next color from the configured palette.
 // This operation is atomic to prevent race conditions.
 func (r *ProjectRepo) GetOrCreate(sid, displayName string) (*model.Project, bool, error) {
 	key := model.GenerateProjectKey(sid)
 	existing := &model.Project{}
 
 	result, created, err := r.db.GetOrCreate(key, existing, func() model.Model {
-		return model.NewProject(sid, displayName, "")
+		return model.NewProject(sid, displayName, r.nextPaletteColor())
 	})
 	if err != nil {
 		return nil, false, err
@@ -46,6 +52,29 @@ func (r *ProjectRepo) GetOrCreate(sid, displayName string) (*model.Project, bool
 	return result.(*model.Project), created, nil
 }
 
+// Ensure retrieves a project by SID, creating it with GetOrCreate when the
+// config allows auto-creating projects. Otherwise a missing project yields
+// ErrKeyNotFound. An empty displayName is derived from the SID as the
+// config's ProjectDisplayName says.
+func (r *ProjectRepo) Ensure(sid, displayName string) (*model.Project, bool, error) {
+	config, err := NewConfigRepo(r.db).Get()
+	if err != nil {
+		return nil, false, err
+	}
+	if config.AutoCreateProjects() {
+		if displayName == "" {
+			displayName = config.ProjectDisplayName(sid)
+		}
+		return r.GetOrCreate(sid, displayName)
+	}
+
+	project, err := r.Get(sid)
+	if err != nil {
+		return nil, false, err
+	}
+	return project, false, nil
+}
+
 // Update updates an existing project.
 func (r *ProjectRepo) Update(project *model.Project) error {
 	return r.db.Set(project)
@@ -69,3 +98,131 @@ func (r *ProjectRepo) Exists(sid string) (bool, error) {
 	key := model.GenerateProjectKey(sid)
 	return r.db.Exists(key)
 }
+
+// nextPaletteColor returns the palette color used by the fewest projects,
+// preferring earlier colors on ties, so colors are handed out round-robin.
+// Returns "" if the palette or existing projects cannot be read.
+func (r *ProjectRepo) nextPaletteColor() string {
+	config, err := NewConfigRepo(r.db).Get()
+	if err != nil {
+		logging.Warn("failed to read config for project color", logging.KeyError, err)
+		return ""
+	}
+	palette := config.Palette()
+
+	projects, err := r.List()
+	if err != nil {
+		logging.Warn("failed to list projects for project color", logging.KeyError, err)
+		return ""
+	}
+
+	used := make(map[string]int)
+	for _, p := range projects {
+		used[strings.ToUpper(p.Color)]++
+	}
+
+	best := ""
+	bestCount := -1
+	for _, color := range palette {
+		count := used[strings.ToUpper(color)]
+		if bestCount < 0 || count < bestCount {
+			best, bestCount = color, count
+		}
+	}
+	return best
+}
+
+// FindEmptyProjects returns the SIDs of projects that have no blocks, sorted.
+// Blocks in the trash count, so restoring one never leaves it without a project.
+func FindEmptyProjects(projectRepo *ProjectRepo, blockRepo *BlockRepo) ([]string, error) {
+	projects, err := projectRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := blockRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := blockRepo.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, b := range blocks {
+		used[b.ProjectSID] = true
+	}
+	for _, t := range trashed {
+		if t.Block != nil {
+			used[t.Block.ProjectSID] = true
+		}
+	}
+
+	var empty []string
+	for _, p := range projects {
+		if !used[p.SID] {
+			empty = append(empty, p.SID)
+		}
+	}
+	sort.Strings(empty)
+	return empty, nil
+}
+
+// PruneEmptyProjects deletes projects that have no blocks and returns their
+// SIDs. Use FindEmptyProjects for a dry run.
+func PruneEmptyProjects(projectRepo *ProjectRepo, blockRepo *BlockRepo) ([]string, error) {
+	empty, err := FindEmptyProjects(projectRepo, blockRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]string, 0, len(empty))
+	for _, sid := range empty {
+		if err := projectRepo.Delete(sid); err != nil {
+			return removed, err
+		}
+		removed = append(removed, sid)
+	}
+	return removed, nil
+}
+
+// ProjectActivity pairs a project with the start of its latest block.
+type ProjectActivity struct {
+	Project      *model.Project
+	LastActivity time.Time // Zero if the project has no blocks
+}
+
+// ProjectsWithActivity returns every project with its last activity, most
+// recent first. Projects without blocks come last, ordered by SID.
+func ProjectsWithActivity(projectRepo *ProjectRepo, blockRepo *BlockRepo) ([]ProjectActivity, error) {
+	projects, err := projectRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := blockRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]time.Time)
+	for _, b := range blocks {
+		if b.TimestampStart.After(latest[b.ProjectSID]) {
+			latest[b.ProjectSID] = b.TimestampStart
+		}
+	}
+
+	activity := make([]ProjectActivity, 0, len(projects))
+	for _, p := range projects {
+		activity = append(activity, ProjectActivity{Project: p, LastActivity: latest[p.SID]})
+	}
+	sort.Slice(activity, func(i, j int) bool {
+		a, b := activity[i].LastActivity, activity[j].LastActivity
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return activity[i].Project.SID < activity[j].Project.SID
+	})
+	return activity, nil
+}
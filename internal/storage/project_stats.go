@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// TaskStats holds the tracked totals for one task of a project. Blocks
+// without a task are counted under the empty TaskSID.
+type TaskStats struct {
+	TaskSID    string
+	Duration   time.Duration
+	BlockCount int
+}
+
+// ProjectStatsReport summarizes everything tracked on a project.
+type ProjectStatsReport struct {
+	ProjectSID    string
+	TotalDuration time.Duration
+	BlockCount    int
+	Tasks         []TaskStats // Most tracked first
+	FirstActivity time.Time   // Earliest block start; zero without blocks
+	LastActivity  time.Time   // Latest block end, or now while active
+	// CurrentStreak is the number of consecutive days in loc with tracked
+	// time, ending today, or yesterday if nothing is tracked yet today.
+	CurrentStreak int
+}
+
+// ProjectStats gathers the totals, task breakdown, activity span and current
+// streak of a project. Active blocks count up to now. Days are counted in
+// the project's time zone if it has one, otherwise in loc. A project without
+// blocks yields a report with zero values.
+func ProjectStats(projectSID string, blockRepo *BlockRepo, now time.Time, loc *time.Location) (ProjectStatsReport, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	report := ProjectStatsReport{ProjectSID: projectSID}
+
+	project, err := NewProjectRepo(blockRepo.db).Get(projectSID)
+	if err != nil && !IsErrKeyNotFound(err) {
+		return report, err
+	}
+	if project != nil {
+		loc = project.Location(loc)
+	}
+
+	blocks, err := blockRepo.ListByProject(projectSID)
+	if err != nil {
+		return report, err
+	}
+
+	tasks := make(map[string]*TaskStats)
+	for _, b := range blocks {
+		end := b.EndAt(now)
+		d := b.DurationAt(now)
+
+		report.TotalDuration += d
+		report.BlockCount++
+		if report.FirstActivity.IsZero() || b.TimestampStart.Before(report.FirstActivity) {
+			report.FirstActivity = b.TimestampStart
+		}
+		if end.After(report.LastActivity) {
+			report.LastActivity = end
+		}
+
+		if _, ok := tasks[b.TaskSID]; !ok {
+			tasks[b.TaskSID] = &TaskStats{TaskSID: b.TaskSID}
+		}
+		tasks[b.TaskSID].Duration += d
+		tasks[b.TaskSID].BlockCount++
+	}
+
+	for _, t := range tasks {
+		report.Tasks = append(report.Tasks, *t)
+	}
+	sort.Slice(report.Tasks, func(i, j int) bool {
+		if report.Tasks[i].Duration != report.Tasks[j].Duration {
+			return report.Tasks[i].Duration > report.Tasks[j].Duration
+		}
+		return report.Tasks[i].TaskSID < report.Tasks[j].TaskSID
+	})
+
+	report.CurrentStreak = currentStreak(blocks, now, loc)
+	return report, nil
+}
+
+// currentStreak counts the consecutive days in loc with tracked time, ending
+// today or, if nothing is tracked today, yesterday. Active blocks run to now.
+func currentStreak(blocks []*model.Block, now time.Time, loc *time.Location) int {
+	closed := make([]*model.Block, len(blocks))
+	for i, b := range blocks {
+		closed[i] = b
+		if b.IsActive() {
+			c := *b
+			c.TimestampEnd = now
+			closed[i] = &c
+		}
+	}
+
+	active := make(map[time.Time]bool)
+	for _, day := range AggregateByDay(SplitBlocksByDay(closed, loc), loc) {
+		active[day.Date] = true
+	}
+
+	day := startOfDay(now, loc)
+	if !active[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for active[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
@@ -181,6 +181,9 @@ func ExportSalvageableData(db *DB, exportPath string) (int, error) {
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
 			key := string(item.Key())
+			if isBlockIndexKey(key) {
+				continue
+			}
 
 			err := item.Value(func(val []byte) error {
 				// Try to decode as JSON
@@ -313,3 +316,151 @@ func equalFold(a, b string) bool {
 	}
 	return true
 }
+
+// RecoveryReport describes the outcome of RecoverDatabase.
+type RecoveryReport struct {
+	BackupPath     string    `json:"backup_path"`
+	QuarantinePath string    `json:"quarantine_path,omitempty"`
+	KeysSalvaged   int       `json:"keys_salvaged"`
+	KeysLost       int       `json:"keys_lost"`
+	LostKeys       []string  `json:"lost_keys,omitempty"`
+	RecoveredAt    time.Time `json:"recovered_at"`
+}
+
+// quarantinedEntry records an entry that could not be read during recovery.
+type quarantinedEntry struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// RecoverDatabase rebuilds a damaged database from whatever entries can still
+// be read. The original directory is backed up first; entries whose values
+// fail checksum verification are listed in a quarantine file next to the
+// backup. On success the rebuilt database is returned open.
+func RecoverDatabase(dbPath string) (*DB, *RecoveryReport, error) {
+	backupPath, err := CreateBackup(dbPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &RecoveryReport{
+		BackupPath:  backupPath,
+		RecoveredAt: time.Now(),
+	}
+
+	salvaged, quarantined, err := salvageEntries(dbPath)
+	if err != nil {
+		return nil, report, errors.NewSystemError("database could not be opened for recovery", err)
+	}
+
+	report.KeysSalvaged = len(salvaged)
+	report.KeysLost = len(quarantined)
+	for _, q := range quarantined {
+		report.LostKeys = append(report.LostKeys, q.Key)
+	}
+
+	if len(quarantined) > 0 {
+		report.QuarantinePath = backupPath + "-quarantine.json"
+		data, err := json.MarshalIndent(quarantined, "", "  ")
+		if err != nil {
+			return nil, report, err
+		}
+		if err := os.WriteFile(report.QuarantinePath, data, 0600); err != nil {
+			return nil, report, fmt.Errorf("failed to write quarantine file: %w", err)
+		}
+	}
+
+	// Rebuild from the salvaged entries; the original is preserved in the backup.
+	if err := os.RemoveAll(dbPath); err != nil {
+		return nil, report, fmt.Errorf("failed to remove damaged database: %w", err)
+	}
+
+	db, err := Open(Options{Path: dbPath})
+	if err != nil {
+		return nil, report, err
+	}
+
+	wb := db.db.NewWriteBatch()
+	defer wb.Cancel()
+	for key, val := range salvaged {
+		if err := wb.Set([]byte(key), val); err != nil {
+			db.Close()
+			return nil, report, err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		db.Close()
+		return nil, report, err
+	}
+	if err := rebuildBlockIndexes(db); err != nil {
+		db.Close()
+		return nil, report, err
+	}
+
+	logging.Info("database recovered",
+		"backup_path", backupPath,
+		"salvaged", report.KeysSalvaged,
+		"lost", report.KeysLost)
+
+	return db, report, nil
+}
+
+// salvageEntries reads every entry of the database at path, verifying value
+// checksums. Readable entries are returned by key; the rest are quarantined.
+// If the database cannot be opened normally it is retried read-only, which
+// skips value log replay.
+func salvageEntries(path string) (map[string][]byte, []quarantinedEntry, error) {
+	opts := badger.DefaultOptions(path).
+		WithLoggingLevel(badger.ERROR).
+		WithVerifyValueChecksum(true).
+		WithBypassLockGuard(true)
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		logging.Warn("retrying recovery read-only", logging.KeyError, err)
+		db, err = badger.Open(opts.WithReadOnly(true))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	defer db.Close()
+
+	salvaged := make(map[string][]byte)
+	var quarantined []quarantinedEntry
+
+	err = db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := string(item.KeyCopy(nil))
+			if isBlockIndexKey(key) {
+				// Rebuilt from the blocks once they are restored
+				continue
+			}
+
+			val, err := item.ValueCopy(nil)
+			switch {
+			case err != nil:
+			case len(val) == 0 && item.ValueSize() > 0:
+				// Badger logs value log read failures and returns no value
+				err = fmt.Errorf("value could not be read")
+			case !json.Valid(val):
+				err = fmt.Errorf("value is not valid JSON")
+			default:
+				salvaged[key] = val
+				continue
+			}
+
+			logging.Warn("quarantining unreadable entry", "key", key, logging.KeyError, err)
+			quarantined = append(quarantined, quarantinedEntry{Key: key, Error: err.Error()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return salvaged, quarantined, nil
+}
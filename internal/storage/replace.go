@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// ErrReplaceNotConfirmed is returned by ReplaceAll unless the caller
+// confirmed the replacement.
+var ErrReplaceNotConfirmed = errors.New("replacing all blocks was not confirmed")
+
+// ReplaceOptions configures ReplaceAll.
+type ReplaceOptions struct {
+	// Confirmed must be set once the user has agreed to lose every stored
+	// block; ReplaceAll refuses to run without it.
+	Confirmed bool
+}
+
+// ReplaceAll makes the stored blocks exactly the given ones, for mirroring
+// an authoritative snapshot. In a single transaction every stored block is
+// deleted and the new blocks are written under their own keys, or new keys
+// if they have none. Trashed blocks are left alone, and the active pointer
+// is cleared unless it names an active block of the new set.
+//
+// All blocks are validated first. Nothing changes if any is invalid, two
+// share a key, or the write fails, including when the set is too large for
+// one transaction (badger.ErrTxnTooBig). This is destructive, so it fails
+// with ErrReplaceNotConfirmed unless opts.Confirmed is set. A delete event is
+// published for every removed block and a create event for every new one.
+func (r *BlockRepo) ReplaceAll(blocks []*model.Block, opts ReplaceOptions) error {
+	if !opts.Confirmed {
+		return ErrReplaceNotConfirmed
+	}
+
+	noteLimit, err := r.noteLimit()
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]bool, len(blocks))
+	for i, b := range blocks {
+		if err := b.ValidateWithNoteLimit(noteLimit); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if b.Key == "" {
+			id, err := uuid.NewV7()
+			if err != nil {
+				return err
+			}
+			b.Key = model.GenerateBlockKey(id.String())
+		}
+		if !strings.HasPrefix(b.Key, model.PrefixBlock+":") {
+			return fmt.Errorf("block %d: invalid key %q", i, b.Key)
+		}
+		if keys[b.Key] {
+			return fmt.Errorf("block %d: duplicate key %q", i, b.Key)
+		}
+		keys[b.Key] = true
+	}
+
+	for attempt := 1; ; attempt++ {
+		var removed []*model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			existing, err := allBlocksTxn(txn)
+			if err != nil {
+				return err
+			}
+			removed = existing
+			for _, b := range existing {
+				if err := deleteBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+			for _, b := range blocks {
+				if err := putBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+
+			active := model.NewActiveBlock()
+			if err := getTxn(txn, model.KeyActiveBlock, active); err != nil {
+				if IsErrKeyNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			if active.ActiveBlockKey == "" || activeIn(blocks, active.ActiveBlockKey) {
+				return nil
+			}
+			active.Key = model.KeyActiveBlock
+			active.ClearActive()
+			active.Revision++
+			return setTxn(txn, active)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, b := range removed {
+			r.db.events.Publish(Event{Type: EventDelete, Block: b})
+		}
+		for _, b := range blocks {
+			r.db.events.Publish(Event{Type: EventCreate, Block: b})
+		}
+		return nil
+	}
+}
+
+// allBlocksTxn returns every stored block.
+func allBlocksTxn(txn *badger.Txn) ([]*model.Block, error) {
+	var blocks []*model.Block
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	prefix := []byte(model.PrefixBlock + ":")
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		err := item.Value(func(val []byte) error {
+			b := &model.Block{}
+			if err := json.Unmarshal(val, b); err != nil {
+				return err
+			}
+			b.SetKey(string(item.Key()))
+			blocks = append(blocks, b)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// activeIn reports whether blocks holds an active block with the key.
+func activeIn(blocks []*model.Block, key string) bool {
+	for _, b := range blocks {
+		if b.Key == key {
+			return b.IsActive()
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	errs "github.com/manav03panchal/humantime/internal/errors"
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// ReplaceAll Tests
+// =============================================================================
+
+// confirmed lets ReplaceAll run in tests.
+var confirmed = ReplaceOptions{Confirmed: true}
+
+func TestBlockRepoReplaceAll(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		b := model.NewBlock("", "old", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		b.Tags = []string{"stale"}
+		require.NoError(t, repo.Create(b))
+	}
+	running := model.NewBlock("", "old", "", "", start.Add(5*time.Hour))
+	_, err := repo.StartExclusive(running)
+	require.NoError(t, err)
+
+	snapshot := []*model.Block{
+		model.NewBlock("", "alpha", "", "first", start),
+		model.NewBlock("", "beta", "", "second", start.Add(time.Hour)),
+	}
+	snapshot[0].Key = "block:0192f3a4-0000-7000-8000-000000000001"
+	snapshot[0].TimestampEnd = start.Add(45 * time.Minute)
+	snapshot[1].TimestampEnd = start.Add(2 * time.Hour)
+
+	require.NoError(t, repo.ReplaceAll(snapshot, confirmed))
+
+	blocks, err := repo.List()
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	notes := map[string]string{}
+	for _, b := range blocks {
+		notes[b.Key] = b.Note
+	}
+	assert.Equal(t, map[string]string{snapshot[0].Key: "first", snapshot[1].Key: "second"}, notes)
+
+	// Indexes follow the new set
+	old, err := repo.ListByProject("old")
+	require.NoError(t, err)
+	assert.Empty(t, old)
+	stale, err := repo.ListByTag("stale")
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// The replaced active block is no longer tracked
+	active, err := activeRepo.Get()
+	require.NoError(t, err)
+	assert.False(t, active.IsTracking())
+
+	t.Run("keeps_active_in_new_set", func(t *testing.T) {
+		now := model.NewBlock("", "alpha", "", "", start.Add(3*time.Hour))
+		_, err := repo.StartExclusive(now)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.ReplaceAll([]*model.Block{now}, confirmed))
+		got, err := activeRepo.GetActiveBlock(repo)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, now.Key, got.Key)
+	})
+
+	t.Run("invalid_set_changes_nothing", func(t *testing.T) {
+		dup := model.NewBlock("", "alpha", "", "", start)
+		dup.TimestampEnd = start.Add(time.Hour)
+		dup.Key = "block:dup"
+		other := *dup
+
+		err := repo.ReplaceAll([]*model.Block{dup, &other}, confirmed)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate key")
+
+		blocks, err := repo.List()
+		require.NoError(t, err)
+		assert.Len(t, blocks, 1)
+	})
+
+	t.Run("empty_set_clears", func(t *testing.T) {
+		require.NoError(t, repo.ReplaceAll(nil, confirmed))
+		blocks, err := repo.List()
+		require.NoError(t, err)
+		assert.Empty(t, blocks)
+	})
+}
+
+func TestBlockRepoReplaceAllGuards(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	stored := model.NewBlock("", "old", "", "", start)
+	stored.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(stored))
+
+	replacement := func(note string) []*model.Block {
+		b := model.NewBlock("", "new", "", note, start)
+		b.TimestampEnd = start.Add(time.Hour)
+		return []*model.Block{b}
+	}
+	storedKeys := func() []string {
+		blocks, err := repo.List()
+		require.NoError(t, err)
+		keys := make([]string, len(blocks))
+		for i, b := range blocks {
+			keys[i] = b.Key
+		}
+		return keys
+	}
+
+	t.Run("requires_confirmation", func(t *testing.T) {
+		err := repo.ReplaceAll(replacement(""), ReplaceOptions{})
+		assert.ErrorIs(t, err, ErrReplaceNotConfirmed)
+		assert.Equal(t, []string{stored.Key}, storedKeys())
+	})
+
+	t.Run("uses_configured_note_limit", func(t *testing.T) {
+		configRepo := NewConfigRepo(db)
+		config, err := configRepo.Get()
+		require.NoError(t, err)
+		config.MaxNoteLength = 5
+		require.NoError(t, configRepo.Save(config))
+
+		err = repo.ReplaceAll(replacement("too long"), confirmed)
+		assert.ErrorIs(t, err, errs.ErrNoteTooLong)
+		assert.Equal(t, []string{stored.Key}, storedKeys())
+	})
+
+	t.Run("publishes_events", func(t *testing.T) {
+		var events []string
+		db.Events().Subscribe(func(e Event) {
+			events = append(events, string(e.Type)+":"+e.Block.ProjectSID)
+		})
+
+		require.NoError(t, repo.ReplaceAll(replacement("ok"), confirmed))
+		assert.Equal(t, []string{"delete:old", "create:new"}, events)
+	})
+}
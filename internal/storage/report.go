@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// PeriodRange is a half-open time range [Start, End).
+type PeriodRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlap returns how much of the block falls within the period.
+// Active blocks are treated as ending now.
+func (p PeriodRange) Overlap(b *model.Block) time.Duration {
+	end := b.EndAt(time.Now())
+	start := b.TimestampStart
+	if start.Before(p.Start) {
+		start = p.Start
+	}
+	if end.After(p.End) {
+		end = p.End
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// ProjectComparison holds one project's totals across two periods.
+type ProjectComparison struct {
+	ProjectSID    string
+	DurationA     time.Duration // Baseline period
+	DurationB     time.Duration // Compared period
+	Delta         time.Duration // DurationB - DurationA
+	PercentChange float64       // Delta relative to DurationA; 0 when New
+	New           bool          // No time in the baseline period
+}
+
+// ComparisonReport compares tracked time between two periods.
+type ComparisonReport struct {
+	A        PeriodRange
+	B        PeriodRange
+	Projects []ProjectComparison
+	TotalA   time.Duration
+	TotalB   time.Duration
+	Delta    time.Duration
+}
+
+// ComparePeriods compares per-project durations between a baseline period a
+// and a second period b. Blocks are clipped to each period, so a block that
+// straddles a boundary only counts the part inside. Projects tracked in only
+// one of the periods are included with a zero duration for the other.
+// Period bounds in the report are expressed in loc.
+func ComparePeriods(blocks []*model.Block, a, b PeriodRange, loc *time.Location) ComparisonReport {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	report := ComparisonReport{
+		A: PeriodRange{Start: a.Start.In(loc), End: a.End.In(loc)},
+		B: PeriodRange{Start: b.Start.In(loc), End: b.End.In(loc)},
+	}
+
+	byProject := make(map[string]*ProjectComparison)
+	for _, block := range blocks {
+		inA := a.Overlap(block)
+		inB := b.Overlap(block)
+		if inA == 0 && inB == 0 {
+			continue
+		}
+
+		cmp, ok := byProject[block.ProjectSID]
+		if !ok {
+			cmp = &ProjectComparison{ProjectSID: block.ProjectSID}
+			byProject[block.ProjectSID] = cmp
+		}
+		cmp.DurationA += inA
+		cmp.DurationB += inB
+	}
+
+	for _, cmp := range byProject {
+		cmp.Delta = cmp.DurationB - cmp.DurationA
+		if cmp.DurationA == 0 {
+			cmp.New = true
+		} else {
+			cmp.PercentChange = float64(cmp.Delta) / float64(cmp.DurationA) * 100
+		}
+		report.TotalA += cmp.DurationA
+		report.TotalB += cmp.DurationB
+		report.Projects = append(report.Projects, *cmp)
+	}
+	report.Delta = report.TotalB - report.TotalA
+
+	sort.Slice(report.Projects, func(i, j int) bool {
+		return report.Projects[i].ProjectSID < report.Projects[j].ProjectSID
+	})
+
+	return report
+}
+
+// FocusFactor returns the fraction of the window [windowStart, windowEnd)
+// covered by tracked time, in the range [0, 1]. Blocks are clipped to the
+// window and overlapping blocks are merged, so time tracked twice counts once.
+// Active blocks are treated as ending now.
+func FocusFactor(blocks []*model.Block, windowStart, windowEnd time.Time) float64 {
+	window := PeriodRange{Start: windowStart, End: windowEnd}
+	if !windowEnd.After(windowStart) {
+		return 0
+	}
+
+	var spans []PeriodRange
+	for _, b := range blocks {
+		if window.Overlap(b) == 0 {
+			continue
+		}
+		span := PeriodRange{Start: b.TimestampStart, End: b.EndAt(time.Now())}
+		if span.Start.Before(windowStart) {
+			span.Start = windowStart
+		}
+		if span.End.After(windowEnd) {
+			span.End = windowEnd
+		}
+		spans = append(spans, span)
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Start.Before(spans[j].Start)
+	})
+
+	var tracked time.Duration
+	var current PeriodRange
+	for i, span := range spans {
+		if i > 0 && !span.Start.After(current.End) {
+			if span.End.After(current.End) {
+				current.End = span.End
+			}
+			continue
+		}
+		tracked += current.End.Sub(current.Start)
+		current = span
+	}
+	tracked += current.End.Sub(current.Start)
+
+	factor := float64(tracked) / float64(windowEnd.Sub(windowStart))
+	if factor > 1 {
+		return 1
+	}
+	return factor
+}
+
+// IdleGap returns the untracked time between the end of previous and
+// resumeAt if it is at least idleAfter, or zero otherwise. A zero idleAfter
+// disables detection, and an active previous block has no gap.
+func IdleGap(previous *model.Block, resumeAt time.Time, idleAfter time.Duration) time.Duration {
+	if previous == nil || idleAfter <= 0 || previous.IsActive() {
+		return 0
+	}
+	gap := resumeAt.Sub(previous.TimestampEnd)
+	if gap < idleAfter {
+		return 0
+	}
+	return gap
+}
+
+// ReallocateIdleGap attributes an idle gap to block by moving its start back
+// by gap.
+func ReallocateIdleGap(block *model.Block, gap time.Duration) {
+	if gap > 0 {
+		block.TimestampStart = block.TimestampStart.Add(-gap)
+	}
+}
+
+// InsightsReport holds headline facts about a set of blocks.
+type InsightsReport struct {
+	LongestBlock       *model.Block // nil when there are no blocks
+	LongestDuration    time.Duration
+	BusiestDay         time.Time // Midnight at the start of the day
+	BusiestDayDuration time.Duration
+	TopProjectSID      string
+	TopProjectDuration time.Duration
+	DaysActive         int
+}
+
+// Insights finds the longest block, the calendar day in loc with the
+// most tracked time, the most tracked project and the number of days with any
+// tracked time. Ties go to the earliest block or day and the lowest project
+// SID. Empty input yields zero values.
+func Insights(blocks []*model.Block, loc *time.Location) InsightsReport {
+	var insights InsightsReport
+
+	for _, b := range blocks {
+		d := b.Duration()
+		if insights.LongestBlock == nil || d > insights.LongestDuration ||
+			(d == insights.LongestDuration && b.TimestampStart.Before(insights.LongestBlock.TimestampStart)) {
+			insights.LongestBlock = b
+			insights.LongestDuration = d
+		}
+	}
+
+	days := AggregateByDay(blocks, loc)
+	insights.DaysActive = len(days)
+	for _, day := range days {
+		// Days are chronological, so strict comparison keeps the earliest
+		if day.Duration > insights.BusiestDayDuration {
+			insights.BusiestDay = day.Date
+			insights.BusiestDayDuration = day.Duration
+		}
+	}
+
+	for _, p := range AggregateByProject(blocks) {
+		if p.Duration > insights.TopProjectDuration ||
+			(p.Duration == insights.TopProjectDuration && p.ProjectSID < insights.TopProjectSID) {
+			insights.TopProjectSID = p.ProjectSID
+			insights.TopProjectDuration = p.Duration
+		}
+	}
+
+	return insights
+}
+
+// BreakTag marks blocks that record an intentional break.
+const BreakTag = "break"
+
+// FindGaps returns the untracked periods of at least minGap between blocks,
+// oldest first. Overlapping blocks are merged, and active blocks are treated
+// as ending at now. A negative minGap, such as UseSessionGap, uses the
+// configured session gap; zero returns every gap.
+func (r *BlockRepo) FindGaps(blocks []*model.Block, minGap time.Duration, now time.Time) ([]PeriodRange, error) {
+	if minGap < 0 {
+		config, err := NewConfigRepo(r.db).Get()
+		if err != nil {
+			return nil, err
+		}
+		minGap = config.SessionThreshold()
+	}
+	return findGaps(blocks, minGap, now), nil
+}
+
+// findGaps returns the gaps of at least minGap between blocks, as FindGaps.
+func findGaps(blocks []*model.Block, minGap time.Duration, now time.Time) []PeriodRange {
+	sorted := append([]*model.Block(nil), blocks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].TimestampStart.Before(sorted[j].TimestampStart)
+	})
+
+	var gaps []PeriodRange
+	var covered time.Time
+	for i, b := range sorted {
+		end := b.EndAt(now)
+		if i > 0 {
+			if gap := b.TimestampStart.Sub(covered); gap > 0 && gap >= minGap {
+				gaps = append(gaps, PeriodRange{Start: covered, End: b.TimestampStart})
+			}
+		}
+		if end.After(covered) {
+			covered = end
+		}
+	}
+	return gaps
+}
+
+// SuspiciousGaps returns the gaps that look like time someone forgot to
+// track: those that neither touch nor overlap a block tagged BreakTag. Blocks
+// without the tag are ignored, so all blocks of the period may be passed.
+// Active breaks are treated as ending at now.
+func SuspiciousGaps(gaps []PeriodRange, blocks []*model.Block, now time.Time) []PeriodRange {
+	var breaks []PeriodRange
+	for _, b := range blocks {
+		if !b.HasTag(BreakTag) {
+			continue
+		}
+		breaks = append(breaks, PeriodRange{Start: b.TimestampStart, End: b.EndAt(now)})
+	}
+
+	var suspicious []PeriodRange
+	for _, gap := range gaps {
+		adjacent := false
+		for _, br := range breaks {
+			if !br.End.Before(gap.Start) && !br.Start.After(gap.End) {
+				adjacent = true
+				break
+			}
+		}
+		if !adjacent {
+			suspicious = append(suspicious, gap)
+		}
+	}
+	return suspicious
+}
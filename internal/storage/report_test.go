@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// ComparePeriods Tests
+// =============================================================================
+
+func TestComparePeriods(t *testing.T) {
+	lastWeek := PeriodRange{
+		Start: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+	}
+	thisWeek := PeriodRange{
+		Start: lastWeek.End,
+		End:   time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC),
+	}
+
+	at := func(p PeriodRange, hours int) time.Time {
+		return p.Start.Add(time.Duration(hours) * time.Hour)
+	}
+	blocks := []*model.Block{
+		// grew: 2h -> 4h
+		{ProjectSID: "grew", TimestampStart: at(lastWeek, 10), TimestampEnd: at(lastWeek, 12)},
+		{ProjectSID: "grew", TimestampStart: at(thisWeek, 10), TimestampEnd: at(thisWeek, 14)},
+		// shrank: 4h -> 1h
+		{ProjectSID: "shrank", TimestampStart: at(lastWeek, 20), TimestampEnd: at(lastWeek, 24)},
+		{ProjectSID: "shrank", TimestampStart: at(thisWeek, 20), TimestampEnd: at(thisWeek, 21)},
+		// new: 0h -> 3h
+		{ProjectSID: "new", TimestampStart: at(thisWeek, 30), TimestampEnd: at(thisWeek, 33)},
+		// outside both periods
+		{ProjectSID: "old", TimestampStart: at(lastWeek, -48), TimestampEnd: at(lastWeek, -47)},
+	}
+
+	report := ComparePeriods(blocks, lastWeek, thisWeek, time.UTC)
+	require.Len(t, report.Projects, 3)
+
+	byProject := make(map[string]ProjectComparison)
+	for _, p := range report.Projects {
+		byProject[p.ProjectSID] = p
+	}
+
+	t.Run("grew", func(t *testing.T) {
+		p := byProject["grew"]
+		assert.Equal(t, 2*time.Hour, p.DurationA)
+		assert.Equal(t, 4*time.Hour, p.DurationB)
+		assert.Equal(t, 2*time.Hour, p.Delta)
+		assert.InDelta(t, 100.0, p.PercentChange, 0.001)
+		assert.False(t, p.New)
+	})
+
+	t.Run("shrank", func(t *testing.T) {
+		p := byProject["shrank"]
+		assert.Equal(t, -3*time.Hour, p.Delta)
+		assert.InDelta(t, -75.0, p.PercentChange, 0.001)
+	})
+
+	t.Run("new_project_has_zero_baseline", func(t *testing.T) {
+		p := byProject["new"]
+		assert.Zero(t, p.DurationA)
+		assert.Equal(t, 3*time.Hour, p.DurationB)
+		assert.True(t, p.New)
+		assert.Zero(t, p.PercentChange)
+	})
+
+	t.Run("totals", func(t *testing.T) {
+		assert.Equal(t, 6*time.Hour, report.TotalA)
+		assert.Equal(t, 8*time.Hour, report.TotalB)
+		assert.Equal(t, 2*time.Hour, report.Delta)
+	})
+}
+
+func TestComparePeriodsClipsStraddlingBlocks(t *testing.T) {
+	boundary := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	a := PeriodRange{Start: boundary.AddDate(0, 0, -7), End: boundary}
+	b := PeriodRange{Start: boundary, End: boundary.AddDate(0, 0, 7)}
+
+	blocks := []*model.Block{
+		{ProjectSID: "p", TimestampStart: boundary.Add(-time.Hour), TimestampEnd: boundary.Add(2 * time.Hour)},
+	}
+
+	report := ComparePeriods(blocks, a, b, time.UTC)
+	require.Len(t, report.Projects, 1)
+	assert.Equal(t, time.Hour, report.Projects[0].DurationA)
+	assert.Equal(t, 2*time.Hour, report.Projects[0].DurationB)
+}
+
+// =============================================================================
+// FocusFactor Tests
+// =============================================================================
+
+func TestFocusFactor(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(8 * time.Hour)
+	span := func(from, to float64) *model.Block {
+		return &model.Block{
+			ProjectSID:     "work",
+			TimestampStart: start.Add(time.Duration(from * float64(time.Hour))),
+			TimestampEnd:   start.Add(time.Duration(to * float64(time.Hour))),
+		}
+	}
+
+	t.Run("half_tracked", func(t *testing.T) {
+		blocks := []*model.Block{span(0, 2), span(4, 6)}
+		assert.InDelta(t, 0.5, FocusFactor(blocks, start, end), 1e-9)
+	})
+
+	t.Run("overlaps_are_merged", func(t *testing.T) {
+		blocks := []*model.Block{span(0, 3), span(1, 2), span(2, 4)}
+		assert.InDelta(t, 0.5, FocusFactor(blocks, start, end), 1e-9)
+	})
+
+	t.Run("over_covered_window_clamped", func(t *testing.T) {
+		blocks := []*model.Block{span(-1, 5), span(3, 9), span(0, 8)}
+		assert.Equal(t, 1.0, FocusFactor(blocks, start, end))
+	})
+
+	t.Run("blocks_outside_window_ignored", func(t *testing.T) {
+		blocks := []*model.Block{span(-3, -1), span(9, 10)}
+		assert.Equal(t, 0.0, FocusFactor(blocks, start, end))
+	})
+
+	t.Run("empty_window", func(t *testing.T) {
+		assert.Equal(t, 0.0, FocusFactor([]*model.Block{span(0, 1)}, start, start))
+	})
+}
+
+// =============================================================================
+// Idle Gap Tests
+// =============================================================================
+
+func TestIdleGap(t *testing.T) {
+	stopped := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	previous := &model.Block{ProjectSID: "work", TimestampStart: stopped.Add(-time.Hour), TimestampEnd: stopped}
+	resumeAt := stopped.Add(45 * time.Minute)
+
+	t.Run("gap_detected", func(t *testing.T) {
+		assert.Equal(t, 45*time.Minute, IdleGap(previous, resumeAt, 15*time.Minute))
+	})
+
+	t.Run("short_gap_ignored", func(t *testing.T) {
+		assert.Zero(t, IdleGap(previous, resumeAt, time.Hour))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Zero(t, IdleGap(previous, resumeAt, 0))
+		assert.Zero(t, IdleGap(nil, resumeAt, time.Minute))
+		assert.Zero(t, IdleGap(&model.Block{TimestampStart: stopped}, resumeAt, time.Minute))
+	})
+
+	t.Run("reallocated_to_resumed_block", func(t *testing.T) {
+		block := model.NewBlock("", "work", "", "", resumeAt)
+		ReallocateIdleGap(block, IdleGap(previous, resumeAt, 15*time.Minute))
+		assert.Equal(t, stopped, block.TimestampStart)
+	})
+
+	t.Run("left_as_gap", func(t *testing.T) {
+		block := model.NewBlock("", "work", "", "", resumeAt)
+		ReallocateIdleGap(block, 0)
+		assert.Equal(t, resumeAt, block.TimestampStart)
+	})
+}
+
+// =============================================================================
+// Insights Tests
+// =============================================================================
+
+func TestInsights(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	span := func(project string, dayOffset, startHour, hours int) *model.Block {
+		start := day.AddDate(0, 0, dayOffset).Add(time.Duration(startHour) * time.Hour)
+		return &model.Block{
+			ProjectSID:     project,
+			TimestampStart: start,
+			TimestampEnd:   start.Add(time.Duration(hours) * time.Hour),
+		}
+	}
+
+	longest := span("beta", 2, 9, 4)
+	blocks := []*model.Block{
+		span("alpha", 0, 9, 2),
+		span("alpha", 0, 13, 3), // Monday totals 5h
+		longest,                 // Wednesday totals 4h
+		span("alpha", 4, 9, 1),
+		span("gamma", 4, 11, 1),
+	}
+
+	insights := Insights(blocks, time.UTC)
+	assert.Same(t, longest, insights.LongestBlock)
+	assert.Equal(t, 4*time.Hour, insights.LongestDuration)
+	assert.Equal(t, day, insights.BusiestDay)
+	assert.Equal(t, 5*time.Hour, insights.BusiestDayDuration)
+	assert.Equal(t, "alpha", insights.TopProjectSID)
+	assert.Equal(t, 6*time.Hour, insights.TopProjectDuration)
+	assert.Equal(t, 3, insights.DaysActive)
+
+	t.Run("day_in_location", func(t *testing.T) {
+		// 23:00 UTC on Sunday is Monday in UTC+2
+		late := span("alpha", -1, 23, 1)
+		insights := Insights([]*model.Block{late}, time.FixedZone("UTC+2", 2*60*60))
+		assert.Equal(t, 10, insights.BusiestDay.Day())
+	})
+
+	t.Run("ties_prefer_earliest_and_lowest_sid", func(t *testing.T) {
+		insights := Insights([]*model.Block{span("zeta", 1, 9, 2), span("eta", 0, 9, 2)}, time.UTC)
+		assert.Equal(t, day.Add(9*time.Hour), insights.LongestBlock.TimestampStart)
+		assert.Equal(t, day, insights.BusiestDay)
+		assert.Equal(t, "eta", insights.TopProjectSID)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, InsightsReport{}, Insights(nil, time.UTC))
+	})
+}
+
+// =============================================================================
+// Gap Tests
+// =============================================================================
+
+func TestFindGaps(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := base.Add(24 * time.Hour)
+	gapsOf := func(blocks []*model.Block, minGap time.Duration) []PeriodRange {
+		gaps, err := repo.FindGaps(blocks, minGap, now)
+		require.NoError(t, err)
+		return gaps
+	}
+	block := func(from, to time.Duration) *model.Block {
+		return &model.Block{ProjectSID: "work", TimestampStart: base.Add(from), TimestampEnd: base.Add(to)}
+	}
+	blocks := []*model.Block{
+		block(4*time.Hour, 5*time.Hour),
+		block(0, time.Hour),
+		// Overlaps the first block, so no gap in between
+		block(30*time.Minute, 2*time.Hour),
+		block(2*time.Hour+3*time.Minute, 3*time.Hour),
+	}
+
+	gaps := gapsOf(blocks, 5*time.Minute)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, PeriodRange{Start: base.Add(3 * time.Hour), End: base.Add(4 * time.Hour)}, gaps[0])
+
+	assert.Len(t, gapsOf(blocks, 0), 2)
+	assert.Empty(t, gapsOf(nil, 0))
+
+	t.Run("session_gap", func(t *testing.T) {
+		// The 3 minute gap is below the default session gap of 5 minutes
+		assert.Len(t, gapsOf(blocks, UseSessionGap), 1)
+
+		configRepo := NewConfigRepo(db)
+		config, err := configRepo.Get()
+		require.NoError(t, err)
+		config.SessionGap = 2 * time.Minute
+		require.NoError(t, configRepo.Save(config))
+		assert.Len(t, gapsOf(blocks, UseSessionGap), 2)
+	})
+
+	t.Run("active_block_ends_at_now", func(t *testing.T) {
+		active := &model.Block{ProjectSID: "work", TimestampStart: base.Add(6 * time.Hour)}
+		later := block(7*time.Hour, 8*time.Hour)
+		gaps := gapsOf([]*model.Block{active, later}, 0)
+		assert.Empty(t, gaps, "the active block covers the time up to now")
+
+		early := base.Add(6*time.Hour + 30*time.Minute)
+		gaps, err := repo.FindGaps([]*model.Block{active, later}, 0, early)
+		require.NoError(t, err)
+		assert.Equal(t, []PeriodRange{{Start: early, End: base.Add(7 * time.Hour)}}, gaps)
+	})
+}
+
+func TestSuspiciousGaps(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	now := base.Add(24 * time.Hour)
+	block := func(from, to time.Duration, tags ...string) *model.Block {
+		return &model.Block{ProjectSID: "work", TimestampStart: base.Add(from), TimestampEnd: base.Add(to), Tags: tags}
+	}
+	blocks := []*model.Block{
+		block(0, 2*time.Hour),
+		// Lunch, then a gap before work resumes
+		block(2*time.Hour, 3*time.Hour, "Break"),
+		block(3*time.Hour+20*time.Minute, 5*time.Hour),
+		// A gap nowhere near a break
+		block(6*time.Hour, 8*time.Hour),
+	}
+
+	gaps := findGaps(blocks, 5*time.Minute, now)
+	require.Len(t, gaps, 2)
+
+	suspicious := SuspiciousGaps(gaps, blocks, now)
+	require.Len(t, suspicious, 1)
+	assert.Equal(t, PeriodRange{Start: base.Add(5 * time.Hour), End: base.Add(6 * time.Hour)}, suspicious[0])
+
+	t.Run("no_breaks", func(t *testing.T) {
+		assert.Equal(t, gaps, SuspiciousGaps(gaps, nil, now))
+	})
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// safetyBackupPrefix and safetyBackupSuffix frame the names of the files
+// SafetyBackup writes.
+const (
+	safetyBackupPrefix = "safety-backup-"
+	safetyBackupSuffix = ".json.gz"
+)
+
+// safetyBackupDir returns the directory safety backups of the database at
+// dbPath are kept in, alongside the copies made by CreateBackup.
+func safetyBackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// SafetyBackup writes a compressed backup of the database before a
+// destructive operation and then removes all but the newest retention
+// safety backups. The backup is the same format as "export --backup", so it
+// can be restored with import. Copying the database directory instead, as
+// CreateBackup does, is unsuitable while the database is open, as Badger
+// preallocates its files. In-memory databases are not backed up and yield
+// an empty path.
+func (d *DB) SafetyBackup(retention int) (string, error) {
+	if d.path == "" {
+		return "", nil
+	}
+
+	backup, err := NewBackup(d)
+	if err != nil {
+		return "", err
+	}
+	data, err := backup.Encode()
+	if err != nil {
+		return "", err
+	}
+	data, err = Compress(data)
+	if err != nil {
+		return "", err
+	}
+
+	dir := safetyBackupDir(d.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// The sequence number keeps backups taken in the same second apart and
+	// in order, even after older ones were pruned
+	timestamp := time.Now().Format("20060102-150405")
+	existing, err := ListSafetyBackups(d.path)
+	if err != nil {
+		return "", err
+	}
+	seq := 1
+	for _, p := range existing {
+		var n int
+		name := strings.TrimPrefix(filepath.Base(p), safetyBackupPrefix+timestamp+"-")
+		if _, err := fmt.Sscanf(name, "%03d", &n); err == nil && n >= seq {
+			seq = n + 1
+		}
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s%s-%03d%s", safetyBackupPrefix, timestamp, seq, safetyBackupSuffix))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	if _, err := PruneSafetyBackups(d.path, retention); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// ListSafetyBackups returns the paths of the safety backups of the database
+// at dbPath, oldest first.
+func ListSafetyBackups(dbPath string) ([]string, error) {
+	dir := safetyBackupDir(dbPath)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, safetyBackupPrefix) && strings.HasSuffix(name, safetyBackupSuffix) {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	// Names carry the creation time, so they sort oldest first
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// PruneSafetyBackups removes all but the newest keep safety backups of the
+// database at dbPath and returns the removed paths.
+func PruneSafetyBackups(dbPath string, keep int) ([]string, error) {
+	backups, err := ListSafetyBackups(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	if len(backups) <= keep {
+		return nil, nil
+	}
+
+	removed := backups[:len(backups)-keep]
+	for _, path := range removed {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Safety Backup Tests
+// =============================================================================
+
+func TestSafetyBackup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	db, err := Open(Options{Path: dbPath})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock("", "work", "", "", start)
+	block.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, NewBlockRepo(db).Create(block))
+
+	t.Run("one_backup_per_call", func(t *testing.T) {
+		path, err := db.SafetyBackup(3)
+		require.NoError(t, err)
+
+		backups, err := ListSafetyBackups(dbPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{path}, backups)
+
+		// The backup restores with import
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, IsCompressed(data))
+		data, err = Decompress(data)
+		require.NoError(t, err)
+		counts, err := backupCounts(data)
+		require.NoError(t, err)
+		assert.Equal(t, 1, counts.Blocks)
+	})
+
+	t.Run("retention_prunes_oldest", func(t *testing.T) {
+		var paths []string
+		for i := 0; i < 4; i++ {
+			path, err := db.SafetyBackup(3)
+			require.NoError(t, err)
+			paths = append(paths, path)
+		}
+
+		backups, err := ListSafetyBackups(dbPath)
+		require.NoError(t, err)
+		assert.Equal(t, paths[1:], backups)
+	})
+
+	t.Run("in_memory_skipped", func(t *testing.T) {
+		path, err := setupTestDB(t).SafetyBackup(3)
+		require.NoError(t, err)
+		assert.Empty(t, path)
+	})
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/google/uuid"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// StartExclusive creates a block and makes it the active block, stopping
+// every block that is still running at the new block's start. The active
+// pointer is read, the running blocks are stopped and the new block is
+// written in a single transaction, so concurrent starts cannot leave more
+// than one block active. If the new block already has an end time, it is
+// stored as a completed block and the active pointer is cleared.
+// Stopped blocks end exactly where the new block starts; they are not
+// rounded to the minimum tracking unit, as that would make them overlap it.
+// Returns the blocks that were stopped, oldest first.
+func (r *BlockRepo) StartExclusive(block *model.Block) ([]*model.Block, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	key := model.GenerateBlockKey(id.String())
+
+	for attempt := 1; ; attempt++ {
+		var stopped []*model.Block
+		err := r.db.db.Update(func(txn *badger.Txn) error {
+			// Reading the pointer makes concurrent starts conflict, as each
+			// of them writes it
+			active := model.NewActiveBlock()
+			if err := getTxn(txn, model.KeyActiveBlock, active); err != nil && !IsErrKeyNotFound(err) {
+				return err
+			}
+
+			running, err := runningBlocksTxn(txn, active.ActiveBlockKey)
+			if err != nil {
+				return err
+			}
+			for _, b := range running {
+				b.TimestampEnd = block.TimestampStart
+				if b.TimestampEnd.Before(b.TimestampStart) {
+					// A backdated start cannot end a block before it began
+					b.TimestampEnd = b.TimestampStart
+				}
+				if err := putBlockTxn(txn, b); err != nil {
+					return err
+				}
+			}
+			stopped = running
+
+			block.Key = key
+			if err := putBlockTxn(txn, block); err != nil {
+				return err
+			}
+
+			if block.IsActive() {
+				active.SetActive(block.Key)
+			} else {
+				active.ClearActive()
+			}
+			active.Key = model.KeyActiveBlock
+			active.Revision++
+			return setTxn(txn, active)
+		})
+		if errors.Is(err, badger.ErrConflict) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range stopped {
+			r.db.events.Publish(Event{Type: EventStop, Block: b})
+		}
+		r.db.events.Publish(Event{Type: EventCreate, Block: block})
+		return stopped, nil
+	}
+}
+
+// runningBlocksTxn returns the block named by the active pointer together
+// with any other block still listed as open, ordered by start time.
+func runningBlocksTxn(txn *badger.Txn, activeKey string) ([]*model.Block, error) {
+	keys := make(map[string]bool)
+	if activeKey != "" {
+		keys[activeKey] = true
+	}
+
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	prefix := []byte(dayIndexOpenBucket)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		keys[string(it.Item().Key()[len(prefix):])] = true
+	}
+	it.Close()
+
+	var running []*model.Block
+	for key := range keys {
+		b := &model.Block{}
+		if err := getTxn(txn, key, b); err != nil {
+			if IsErrKeyNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if b.IsActive() {
+			running = append(running, b)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		if !running[i].TimestampStart.Equal(running[j].TimestampStart) {
+			return running[i].TimestampStart.Before(running[j].TimestampStart)
+		}
+		return running[i].Key < running[j].Key
+	})
+	return running, nil
+}
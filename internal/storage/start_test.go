@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// StartExclusive Tests
+// =============================================================================
+
+func TestStartExclusive(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	first := model.NewBlock("", "work", "", "", start)
+	stopped, err := repo.StartExclusive(first)
+	require.NoError(t, err)
+	assert.Empty(t, stopped)
+
+	active, err := activeRepo.GetActiveBlock(repo)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, first.Key, active.Key)
+
+	t.Run("stops_active_block", func(t *testing.T) {
+		second := model.NewBlock("", "side", "", "", start.Add(time.Hour))
+		stopped, err := repo.StartExclusive(second)
+		require.NoError(t, err)
+		require.Len(t, stopped, 1)
+		assert.Equal(t, first.Key, stopped[0].Key)
+
+		got, err := repo.Get(first.Key)
+		require.NoError(t, err)
+		assert.Equal(t, start.Add(time.Hour), got.TimestampEnd)
+
+		active, err := activeRepo.Get()
+		require.NoError(t, err)
+		assert.Equal(t, second.Key, active.ActiveBlockKey)
+		assert.Equal(t, first.Key, active.PreviousBlockKey)
+	})
+
+	t.Run("stops_unreferenced_open_blocks", func(t *testing.T) {
+		// An active block the pointer does not know about
+		stray := model.NewBlock("", "stray", "", "", start.Add(90*time.Minute))
+		require.NoError(t, repo.Create(stray))
+
+		third := model.NewBlock("", "work", "", "", start.Add(2*time.Hour))
+		stopped, err := repo.StartExclusive(third)
+		require.NoError(t, err)
+		require.Len(t, stopped, 2)
+		assert.Equal(t, "side", stopped[0].ProjectSID)
+		assert.Equal(t, stray.Key, stopped[1].Key)
+		assert.Equal(t, 1, countActiveBlocks(t, repo))
+	})
+
+	t.Run("completed_block_clears_pointer", func(t *testing.T) {
+		done := model.NewBlock("", "work", "", "", start.Add(3*time.Hour))
+		done.TimestampEnd = start.Add(4 * time.Hour)
+		stopped, err := repo.StartExclusive(done)
+		require.NoError(t, err)
+		assert.Len(t, stopped, 1)
+		assert.Equal(t, 0, countActiveBlocks(t, repo))
+
+		active, err := activeRepo.Get()
+		require.NoError(t, err)
+		assert.False(t, active.IsTracking())
+	})
+}
+
+func TestStartExclusiveMinTrackUnit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	configRepo := NewConfigRepo(db)
+
+	config, err := configRepo.Get()
+	require.NoError(t, err)
+	config.MinTrackUnit = 15 * time.Minute
+	require.NoError(t, configRepo.Save(config))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	first := model.NewBlock("", "work", "", "", start)
+	_, err = repo.StartExclusive(first)
+	require.NoError(t, err)
+
+	// Switching after 7 minutes would round the first block to 15
+	second := model.NewBlock("", "side", "", "", start.Add(7*time.Minute))
+	_, err = repo.StartExclusive(second)
+	require.NoError(t, err)
+
+	got, err := repo.Get(first.Key)
+	require.NoError(t, err)
+	assert.Equal(t, second.TimestampStart, got.TimestampEnd)
+	second.TimestampEnd = start.Add(time.Hour)
+	assert.False(t, got.OverlapsWith(second))
+}
+
+func TestStartExclusiveConcurrent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	activeRepo := NewActiveBlockRepo(db)
+
+	const workers = 16
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			block := model.NewBlock("", fmt.Sprintf("project%d", i), "", "", start.Add(time.Duration(i)*time.Minute))
+			_, err := repo.StartExclusive(block)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	all, err := repo.List()
+	require.NoError(t, err)
+	assert.Len(t, all, workers)
+	assert.Equal(t, 1, countActiveBlocks(t, repo))
+
+	// The pointer names the one block left running
+	active, err := activeRepo.GetActiveBlock(repo)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.True(t, active.IsActive())
+}
+
+// countActiveBlocks returns how many stored blocks have no end time.
+func countActiveBlocks(t *testing.T, repo *BlockRepo) int {
+	t.Helper()
+	blocks, err := repo.List()
+	require.NoError(t, err)
+	count := 0
+	for _, b := range blocks {
+		if b.IsActive() {
+			count++
+		}
+	}
+	return count
+}
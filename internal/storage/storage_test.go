@@ -1,12 +1,19 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	badger "github.com/dgraph-io/badger/v4"
+	errs "github.com/manav03panchal/humantime/internal/errors"
 	"github.com/manav03panchal/humantime/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -74,6 +81,29 @@ func TestOpenWithIntegrityCheck(t *testing.T) {
 	assert.NotNil(t, db)
 }
 
+func TestOpenLogLevel(t *testing.T) {
+	openClose := func(t *testing.T, level LogLevel) string {
+		var buf bytes.Buffer
+		db, err := Open(Options{Path: t.TempDir(), LogLevel: level, LogOutput: &buf})
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+		return buf.String()
+	}
+
+	t.Run("silent_writes_nothing", func(t *testing.T) {
+		assert.Empty(t, openClose(t, LogLevelSilent))
+	})
+
+	t.Run("info_writes_to_output", func(t *testing.T) {
+		assert.Contains(t, openClose(t, LogLevelInfo), "INFO:")
+	})
+
+	t.Run("invalid_level", func(t *testing.T) {
+		_, err := Open(Options{InMemory: true, LogLevel: "verbose"})
+		assert.Error(t, err)
+	})
+}
+
 func TestDefaultPath(t *testing.T) {
 	path := DefaultPath()
 	assert.Contains(t, path, "humantime")
@@ -136,6 +166,116 @@ func TestProjectRepoGetOrCreate(t *testing.T) {
 	assert.Equal(t, project.SID, project2.SID)
 }
 
+func TestProjectRepoEnsure(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProjectRepo(db)
+	configRepo := NewConfigRepo(db)
+
+	t.Run("auto_create_on_by_default", func(t *testing.T) {
+		project, created, err := repo.Ensure("typo", "typo")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "typo", project.SID)
+	})
+
+	config, err := configRepo.Get()
+	require.NoError(t, err)
+	config.NoAutoCreateProjects = true
+	require.NoError(t, configRepo.Save(config))
+
+	t.Run("auto_create_off_rejects_unknown", func(t *testing.T) {
+		_, _, err := repo.Ensure("missing", "missing")
+		assert.True(t, IsErrKeyNotFound(err))
+
+		exists, err := repo.Exists("missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("auto_create_off_finds_existing", func(t *testing.T) {
+		project, created, err := repo.Ensure("typo", "typo")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "typo", project.SID)
+	})
+}
+
+func TestProjectRepoEnsureDisplayName(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProjectRepo(db)
+	configRepo := NewConfigRepo(db)
+
+	t.Run("title_cased_by_default", func(t *testing.T) {
+		project, created, err := repo.Ensure("my-client_work", "")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "my-client_work", project.SID)
+		assert.Equal(t, "My Client Work", project.DisplayName)
+	})
+
+	t.Run("explicit_name_kept", func(t *testing.T) {
+		project, _, err := repo.Ensure("acme", "ACME Corp")
+		require.NoError(t, err)
+		assert.Equal(t, "ACME Corp", project.DisplayName)
+	})
+
+	t.Run("disabled_passes_sid_through", func(t *testing.T) {
+		config, err := configRepo.Get()
+		require.NoError(t, err)
+		config.RawProjectNames = true
+		require.NoError(t, configRepo.Save(config))
+
+		project, created, err := repo.Ensure("side-project", "")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "side-project", project.DisplayName)
+	})
+}
+
+func TestProjectRepoGetOrCreatePaletteColors(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProjectRepo(db)
+
+	palette := []string{"#111111", "#222222", "#333333"}
+	config, err := NewConfigRepo(db).Get()
+	require.NoError(t, err)
+	config.ProjectPalette = palette
+	require.NoError(t, NewConfigRepo(db).Save(config))
+
+	t.Run("distinct_colors", func(t *testing.T) {
+		var colors []string
+		for _, sid := range []string{"alpha", "beta", "gamma"} {
+			project, created, err := repo.GetOrCreate(sid, sid)
+			require.NoError(t, err)
+			require.True(t, created)
+			colors = append(colors, project.Color)
+		}
+		assert.Equal(t, palette, colors)
+	})
+
+	t.Run("wraps_around", func(t *testing.T) {
+		project, _, err := repo.GetOrCreate("delta", "delta")
+		require.NoError(t, err)
+		assert.Equal(t, "#111111", project.Color)
+	})
+
+	t.Run("explicit_color_kept", func(t *testing.T) {
+		require.NoError(t, repo.Create(model.NewProject("custom", "Custom", "#ABCDEF")))
+		project, created, err := repo.GetOrCreate("custom", "Custom")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, "#ABCDEF", project.Color)
+	})
+
+	t.Run("skips_colors_in_use", func(t *testing.T) {
+		// alpha and delta share #111111; beta and gamma hold the others
+		require.NoError(t, repo.Create(model.NewProject("manual", "Manual", "#222222")))
+		project, _, err := repo.GetOrCreate("epsilon", "epsilon")
+		require.NoError(t, err)
+		assert.Equal(t, "#333333", project.Color)
+	})
+}
+
 func TestProjectRepoUpdate(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewProjectRepo(db)
@@ -205,6 +345,83 @@ func TestProjectRepoExists(t *testing.T) {
 	assert.False(t, exists)
 }
 
+func TestPruneEmptyProjects(t *testing.T) {
+	db := setupTestDB(t)
+	projectRepo := NewProjectRepo(db)
+	blockRepo := NewBlockRepo(db)
+
+	for _, sid := range []string{"used", "bare", "typo", "trashed"} {
+		require.NoError(t, projectRepo.Create(model.NewProject(sid, sid, "")))
+	}
+	start := time.Now().Add(-time.Hour)
+	require.NoError(t, blockRepo.Create(model.NewBlock("", "used", "", "", start)))
+	deleted := model.NewBlock("", "trashed", "", "", start)
+	require.NoError(t, blockRepo.Create(deleted))
+	require.NoError(t, blockRepo.Delete(deleted.Key))
+
+	t.Run("dry_run", func(t *testing.T) {
+		empty, err := FindEmptyProjects(projectRepo, blockRepo)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bare", "typo"}, empty)
+
+		projects, err := projectRepo.List()
+		require.NoError(t, err)
+		assert.Len(t, projects, 4)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		removed, err := PruneEmptyProjects(projectRepo, blockRepo)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bare", "typo"}, removed)
+
+		for sid, want := range map[string]bool{"used": true, "trashed": true, "bare": false, "typo": false} {
+			exists, err := projectRepo.Exists(sid)
+			require.NoError(t, err)
+			assert.Equal(t, want, exists, sid)
+		}
+	})
+}
+
+func TestProjectsWithActivity(t *testing.T) {
+	db := setupTestDB(t)
+	projectRepo := NewProjectRepo(db)
+	blockRepo := NewBlockRepo(db)
+
+	t.Run("no_projects", func(t *testing.T) {
+		activity, err := ProjectsWithActivity(projectRepo, blockRepo)
+		require.NoError(t, err)
+		assert.Empty(t, activity)
+	})
+
+	for _, sid := range []string{"old", "recent", "unused", "idle"} {
+		require.NoError(t, projectRepo.Create(model.NewProject(sid, sid, "")))
+	}
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for _, b := range []*model.Block{
+		model.NewBlock("", "old", "", "", base),
+		model.NewBlock("", "recent", "", "", base.Add(time.Hour)),
+		model.NewBlock("", "old", "", "", base.Add(2*time.Hour)),
+		model.NewBlock("", "recent", "", "", base.Add(3*time.Hour)),
+	} {
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, blockRepo.Create(b))
+	}
+
+	activity, err := ProjectsWithActivity(projectRepo, blockRepo)
+	require.NoError(t, err)
+	require.Len(t, activity, 4)
+
+	var sids []string
+	for _, a := range activity {
+		sids = append(sids, a.Project.SID)
+	}
+	assert.Equal(t, []string{"recent", "old", "idle", "unused"}, sids)
+	assert.Equal(t, base.Add(3*time.Hour), activity[0].LastActivity.UTC())
+	assert.Equal(t, base.Add(2*time.Hour), activity[1].LastActivity.UTC())
+	assert.True(t, activity[2].LastActivity.IsZero())
+	assert.True(t, activity[3].LastActivity.IsZero())
+}
+
 // =============================================================================
 // BlockRepo Tests
 // =============================================================================
@@ -222,6 +439,55 @@ func TestBlockRepoCreate(t *testing.T) {
 	assert.NotEmpty(t, block.Key)
 }
 
+func TestBlockRepoCreateFromDuration(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := model.NewBlock("", "work", "api", "design review", start)
+	require.NoError(t, repo.CreateFromDuration(block, 2*time.Hour))
+	assert.Equal(t, time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC), block.TimestampEnd)
+
+	stored, err := repo.Get(block.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "api", stored.TaskSID)
+	assert.Equal(t, "design review", stored.Note)
+	assert.Equal(t, 2*time.Hour, stored.Duration())
+
+	for _, d := range []time.Duration{0, -time.Hour} {
+		err := repo.CreateFromDuration(model.NewBlock("", "work", "", "", start), d)
+		assert.ErrorIs(t, err, ErrNonPositiveDuration, "duration %s", d)
+	}
+
+	blocks, err := repo.List()
+	require.NoError(t, err)
+	assert.Len(t, blocks, 1)
+}
+
+func TestBlockRepoConfiguredNoteLimit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	configRepo := NewConfigRepo(db)
+
+	config, err := configRepo.Get()
+	require.NoError(t, err)
+	config.MaxNoteLength = 10
+	require.NoError(t, configRepo.Save(config))
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	long := "a note over ten characters"
+
+	err = repo.CreateFromDuration(model.NewBlock("", "work", "", long, start), time.Hour)
+	assert.ErrorIs(t, err, errs.ErrNoteTooLong)
+
+	b := model.NewBlock("", "work", "", long, start)
+	b.TimestampEnd = start.Add(time.Hour)
+	assert.ErrorIs(t, repo.CreateBatch([]*model.Block{b}), errs.ErrNoteTooLong)
+
+	err = repo.CreateFromDuration(model.NewBlock("", "work", "", "short", start), time.Hour)
+	assert.NoError(t, err)
+}
+
 func TestBlockRepoGet(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBlockRepo(db)
@@ -258,6 +524,126 @@ func TestBlockRepoUpdate(t *testing.T) {
 	assert.Equal(t, "Updated note", retrieved.Note)
 }
 
+func TestBlockRepoUpdatePartial(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	block := model.NewBlock("", "work", "", "", time.Now().Add(-time.Hour))
+	require.NoError(t, repo.Create(block))
+
+	t.Run("concurrent_appends_are_kept", func(t *testing.T) {
+		const workers = 16
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(note string) {
+				defer wg.Done()
+				errs <- repo.UpdatePartial(block.Key, func(b *model.Block) error {
+					return b.AppendNote(note, 0, false)
+				})
+			}(fmt.Sprintf("note-%d", i))
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		stored, err := repo.Get(block.Key)
+		require.NoError(t, err)
+		for i := 0; i < workers; i++ {
+			assert.Contains(t, stored.Note, fmt.Sprintf("note-%d", i))
+		}
+	})
+
+	t.Run("mutation_error_aborts", func(t *testing.T) {
+		errStop := errors.New("stop")
+		err := repo.UpdatePartial(block.Key, func(b *model.Block) error {
+			b.ProjectSID = "other"
+			return errStop
+		})
+		assert.ErrorIs(t, err, errStop)
+
+		stored, err := repo.Get(block.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "work", stored.ProjectSID)
+	})
+
+	t.Run("missing_block", func(t *testing.T) {
+		err := repo.UpdatePartial("block:missing", func(*model.Block) error { return nil })
+		assert.True(t, IsErrKeyNotFound(err))
+	})
+
+	t.Run("keeps_indexes_in_sync", func(t *testing.T) {
+		require.NoError(t, repo.UpdatePartial(block.Key, func(b *model.Block) error {
+			b.AddTag("reviewed")
+			return nil
+		}))
+		tagged, err := repo.ListByTag("reviewed")
+		require.NoError(t, err)
+		assert.Len(t, tagged, 1)
+	})
+}
+
+func TestBlockRepoHeartbeat(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	active := model.NewBlock("", "work", "", "", start)
+	require.NoError(t, repo.Create(active))
+	done := model.NewBlock("", "work", "", "", start.Add(-2*time.Hour))
+	done.TimestampEnd = start.Add(-time.Hour)
+	require.NoError(t, repo.Create(done))
+
+	beat := start.Add(20 * time.Minute)
+	require.NoError(t, repo.Heartbeat(active.Key, beat))
+	require.NoError(t, repo.Heartbeat(done.Key, beat))
+
+	got, err := repo.Get(active.Key)
+	require.NoError(t, err)
+	assert.True(t, beat.Equal(got.Heartbeat))
+
+	got, err = repo.Get(done.Key)
+	require.NoError(t, err)
+	assert.True(t, got.Heartbeat.IsZero(), "completed blocks keep no heartbeat")
+
+	// A crash leaves the heartbeat behind; closing at it recovers the block
+	got, err = repo.Get(active.Key)
+	require.NoError(t, err)
+	interval := 5 * time.Minute
+	require.True(t, got.StaleHeartbeat(beat.Add(time.Hour), interval))
+	got.TimestampEnd = got.Heartbeat
+	require.NoError(t, repo.Stop(got))
+
+	got, err = repo.Get(active.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, got.Duration())
+	assert.False(t, got.StaleHeartbeat(beat.Add(time.Hour), interval))
+}
+
+func TestBlockRepoExists(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	block := model.NewBlock("", "proj", "", "", time.Now())
+	require.NoError(t, repo.Create(block))
+
+	exists, err := repo.Exists(block.Key)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.Exists("block:missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, repo.Delete(block.Key))
+	exists, err = repo.Exists(block.Key)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestBlockRepoDelete(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBlockRepo(db)
@@ -295,6 +681,52 @@ func TestBlockRepoList(t *testing.T) {
 	assert.Len(t, blocks, 3)
 }
 
+func TestBlockRepoSample(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		b := model.NewBlock("", "work", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, repo.Create(b))
+	}
+
+	keys := func(blocks []*model.Block) []string {
+		out := make([]string, len(blocks))
+		for i, b := range blocks {
+			out[i] = b.Key
+		}
+		return out
+	}
+	sample := func(n int, seed int64) []*model.Block {
+		t.Helper()
+		blocks, err := repo.Sample(n, seed)
+		require.NoError(t, err)
+		return blocks
+	}
+
+	t.Run("same_seed_same_sample", func(t *testing.T) {
+		first := sample(5, 42)
+		require.Len(t, first, 5)
+		assert.Equal(t, keys(first), keys(sample(5, 42)))
+		assert.IsIncreasing(t, keys(first))
+	})
+
+	t.Run("different_seeds_differ", func(t *testing.T) {
+		distinct := make(map[string]bool)
+		for seed := int64(0); seed < 10; seed++ {
+			distinct[strings.Join(keys(sample(5, seed)), ",")] = true
+		}
+		assert.Greater(t, len(distinct), 1)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		assert.Len(t, sample(100, 1), 50)
+		assert.Empty(t, sample(0, 1))
+	})
+}
+
 func TestBlockRepoListByProject(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBlockRepo(db)
@@ -325,6 +757,48 @@ func TestBlockRepoListByProject(t *testing.T) {
 	assert.Len(t, blocks, 1)
 }
 
+func TestBlockRepoListWithoutTask(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 4, 7, 9, 0, 0, 0, time.UTC)
+	for i, b := range []struct{ project, task string }{
+		{"client", "design"},
+		{"client", ""},
+		{"client", "review"},
+		{"client", ""},
+		{"other", ""},
+	} {
+		block := model.NewBlock("", b.project, b.task, "", start.Add(time.Duration(i)*time.Hour))
+		block.TimestampEnd = block.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, repo.Create(block))
+	}
+
+	blocks, err := repo.ListWithoutTask("client")
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	for _, b := range blocks {
+		assert.Equal(t, "client", b.ProjectSID)
+		assert.Empty(t, b.TaskSID)
+	}
+
+	t.Run("filter_has_task", func(t *testing.T) {
+		hasTask := true
+		blocks, err := repo.ListFiltered(BlockFilter{ProjectSID: "client", HasTask: &hasTask, Limit: NoLimit})
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+		for _, b := range blocks {
+			assert.NotEmpty(t, b.TaskSID)
+		}
+	})
+
+	t.Run("unknown_project", func(t *testing.T) {
+		blocks, err := repo.ListWithoutTask("missing")
+		require.NoError(t, err)
+		assert.Empty(t, blocks)
+	})
+}
+
 func TestBlockRepoListByTimeRange(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBlockRepo(db)
@@ -355,6 +829,57 @@ func TestBlockRepoListByTimeRange(t *testing.T) {
 	assert.Len(t, blocks, 1)
 }
 
+func TestBlockRepoNextAfterPreviousBefore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	mk := func(key string, start time.Time) {
+		b := model.NewBlock("", "proj", "", "", start)
+		b.Key = key
+		b.TimestampEnd = start.Add(30 * time.Minute)
+		require.NoError(t, db.Set(b))
+	}
+	// Inserted out of order; c and b tie on start time
+	mk("block:d", base.Add(3*time.Hour))
+	mk("block:a", base)
+	mk("block:c", base.Add(time.Hour))
+	mk("block:b", base.Add(time.Hour))
+
+	want := []string{"block:a", "block:b", "block:c", "block:d"}
+
+	t.Run("forward", func(t *testing.T) {
+		got := []string{"block:a"}
+		for {
+			next, err := repo.NextAfter(got[len(got)-1])
+			if errors.Is(err, ErrNoAdjacentBlock) {
+				break
+			}
+			require.NoError(t, err)
+			got = append(got, next.Key)
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("backward", func(t *testing.T) {
+		got := []string{"block:d"}
+		for {
+			prev, err := repo.PreviousBefore(got[len(got)-1])
+			if errors.Is(err, ErrNoAdjacentBlock) {
+				break
+			}
+			require.NoError(t, err)
+			got = append(got, prev.Key)
+		}
+		assert.Equal(t, []string{"block:d", "block:c", "block:b", "block:a"}, got)
+	})
+
+	t.Run("unknown_key", func(t *testing.T) {
+		_, err := repo.NextAfter("block:missing")
+		assert.True(t, IsErrKeyNotFound(err))
+	})
+}
+
 func TestBlockRepoListFiltered(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewBlockRepo(db)
@@ -381,61 +906,368 @@ func TestBlockRepoListFiltered(t *testing.T) {
 	assert.Len(t, blocks, 3)
 }
 
-// =============================================================================
-// Aggregate Tests
-// =============================================================================
+func TestBlockRepoListFilteredDefaultLimit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
 
-func TestTotalDuration(t *testing.T) {
-	now := time.Now()
-	blocks := []*model.Block{
-		{TimestampStart: now.Add(-2 * time.Hour), TimestampEnd: now.Add(-1 * time.Hour)},
-		{TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		b := model.NewBlock("", "work", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, repo.Create(b))
 	}
 
-	total := TotalDuration(blocks)
-	assert.Equal(t, 2*time.Hour, total)
-}
-
-func TestAggregateByProject(t *testing.T) {
-	now := time.Now()
-	blocks := []*model.Block{
-		{ProjectSID: "project-a", TimestampStart: now.Add(-2 * time.Hour), TimestampEnd: now.Add(-1 * time.Hour)},
-		{ProjectSID: "project-a", TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
-		{ProjectSID: "project-b", TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+	list := func(filter BlockFilter) []*model.Block {
+		t.Helper()
+		blocks, err := repo.ListFiltered(filter)
+		require.NoError(t, err)
+		return blocks
 	}
 
-	agg := AggregateByProject(blocks)
-	assert.Len(t, agg, 2)
-
-	// Project A should have 2 hours, Project B should have 1 hour
-	// Sorted by duration, A should be first
-	assert.Equal(t, "project-a", agg[0].ProjectSID)
-	assert.Equal(t, 2, agg[0].BlockCount)
-	assert.Equal(t, 2*time.Hour, agg[0].Duration)
-}
-
-// =============================================================================
-// Safety Tests
-// =============================================================================
+	t.Run("unset_default_returns_everything", func(t *testing.T) {
+		assert.Len(t, list(BlockFilter{}), 5)
+	})
 
-func TestDiskSpaceInfo(t *testing.T) {
-	t.Run("free_percent_zero_total", func(t *testing.T) {
-		info := &DiskSpaceInfo{TotalBytes: 0, FreeBytes: 100}
-		assert.Equal(t, 0.0, info.FreePercent())
+	configRepo := NewConfigRepo(db)
+	config, err := configRepo.Get()
+	require.NoError(t, err)
+	config.DefaultListLimit = 2
+	require.NoError(t, configRepo.Save(config))
+
+	t.Run("omitted_limit_uses_default", func(t *testing.T) {
+		blocks := list(BlockFilter{})
+		require.Len(t, blocks, 2)
+		assert.Equal(t, start.Add(4*time.Hour), blocks[0].TimestampStart)
+		assert.Len(t, list(BlockFilter{ProjectSID: "work"}), 2)
 	})
 
-	t.Run("free_percent_calculation", func(t *testing.T) {
-		info := &DiskSpaceInfo{TotalBytes: 1000, FreeBytes: 250}
-		assert.Equal(t, 25.0, info.FreePercent())
+	t.Run("explicit_limit_wins", func(t *testing.T) {
+		assert.Len(t, list(BlockFilter{Limit: 4}), 4)
 	})
-}
 
-func TestGetDiskSpace(t *testing.T) {
-	t.Run("current_directory", func(t *testing.T) {
-		info, err := GetDiskSpace(".")
-		require.NoError(t, err)
-		assert.NotNil(t, info)
-		assert.Greater(t, info.TotalBytes, uint64(0))
+	t.Run("no_limit_opts_into_everything", func(t *testing.T) {
+		assert.Len(t, list(BlockFilter{Limit: NoLimit}), 5)
+		assert.Len(t, list(BlockFilter{ProjectSID: "work", Limit: NoLimit}), 5)
+	})
+}
+
+func TestBlockRepoListFilteredUpdatedAfter(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	old := model.NewBlock("", "work", "", "", start)
+	old.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(old))
+	fresh := model.NewBlock("", "work", "", "", start.Add(2*time.Hour))
+	fresh.TimestampEnd = start.Add(3 * time.Hour)
+	require.NoError(t, repo.Create(fresh))
+
+	stored, err := repo.Get(old.Key)
+	require.NoError(t, err)
+	assert.False(t, stored.UpdatedAt.IsZero())
+	since := stored.UpdatedAt
+
+	fresh.Note = "edited"
+	require.NoError(t, repo.Update(fresh))
+
+	blocks, err := repo.ListFiltered(BlockFilter{UpdatedAfter: since})
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, fresh.Key, blocks[0].Key)
+	assert.Equal(t, "edited", blocks[0].Note)
+
+	t.Run("unstamped_blocks_never_match", func(t *testing.T) {
+		legacy := model.NewBlock("", "work", "", "", start)
+		legacy.Key = "block:legacy"
+		legacy.TimestampEnd = start.Add(time.Hour)
+		require.NoError(t, db.Set(legacy))
+
+		blocks, err := repo.ListFiltered(BlockFilter{UpdatedAfter: time.Unix(1, 0)})
+		require.NoError(t, err)
+		assert.Len(t, blocks, 2)
+	})
+}
+
+func TestBlockRepoListFilteredProjectSIDs(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	now := time.Now()
+	for i, project := range []string{"alpha", "beta", "gamma", "alpha", "beta", "gamma"} {
+		block := model.NewBlock("", project, "", "", now.Add(time.Duration(-i-1)*time.Hour))
+		block.TimestampEnd = block.TimestampStart.Add(30 * time.Minute)
+		if i < 3 {
+			block.AddTag("billable")
+		}
+		require.NoError(t, repo.Create(block))
+	}
+
+	projectsOf := func(blocks []*model.Block) map[string]int {
+		counts := make(map[string]int)
+		for _, b := range blocks {
+			counts[b.ProjectSID]++
+		}
+		return counts
+	}
+
+	t.Run("any_of_two", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{ProjectSIDs: []string{"alpha", "gamma"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"alpha": 2, "gamma": 2}, projectsOf(blocks))
+	})
+
+	t.Run("singular_shorthand_combined", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{ProjectSID: "beta", ProjectSIDs: []string{"alpha"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"alpha": 2, "beta": 2}, projectsOf(blocks))
+	})
+
+	t.Run("combined_with_tag", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{ProjectSIDs: []string{"alpha", "gamma"}, Tag: "billable"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"alpha": 1, "gamma": 1}, projectsOf(blocks))
+	})
+}
+
+func TestBlockRepoListFilteredWeekdays(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	// Monday 2025-03-10 through Sunday 2025-03-16, one block per day
+	monday := time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		block := model.NewBlock("", "work", "", "", monday.AddDate(0, 0, i))
+		block.TimestampEnd = block.TimestampStart.Add(time.Hour)
+		require.NoError(t, repo.Create(block))
+	}
+
+	weekdaysOf := func(blocks []*model.Block) []time.Weekday {
+		var days []time.Weekday
+		for _, b := range blocks {
+			days = append(days, b.TimestampStart.UTC().Weekday())
+		}
+		return days
+	}
+
+	t.Run("weekend_only", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{
+			Weekdays: []time.Weekday{time.Saturday, time.Sunday},
+			Location: time.UTC,
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []time.Weekday{time.Saturday, time.Sunday}, weekdaysOf(blocks))
+	})
+
+	t.Run("empty_matches_all", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{Location: time.UTC})
+		require.NoError(t, err)
+		assert.Len(t, blocks, 7)
+	})
+
+	t.Run("uses_location", func(t *testing.T) {
+		// 10:00 UTC on Sunday is already Monday in UTC+14
+		kiribati := time.FixedZone("UTC+14", 14*60*60)
+		blocks, err := repo.ListFiltered(BlockFilter{
+			Weekdays: []time.Weekday{time.Monday},
+			Location: kiribati,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []time.Weekday{time.Sunday}, weekdaysOf(blocks))
+	})
+}
+
+// =============================================================================
+// Aggregate Tests
+// =============================================================================
+
+func TestTotalDuration(t *testing.T) {
+	now := time.Now()
+	blocks := []*model.Block{
+		{TimestampStart: now.Add(-2 * time.Hour), TimestampEnd: now.Add(-1 * time.Hour)},
+		{TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+	}
+
+	total := TotalDuration(blocks)
+	assert.Equal(t, 2*time.Hour, total)
+}
+
+func TestAggregateByProject(t *testing.T) {
+	now := time.Now()
+	blocks := []*model.Block{
+		{ProjectSID: "project-a", TimestampStart: now.Add(-2 * time.Hour), TimestampEnd: now.Add(-1 * time.Hour)},
+		{ProjectSID: "project-a", TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+		{ProjectSID: "project-b", TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+	}
+
+	agg := AggregateByProject(blocks)
+	assert.Len(t, agg, 2)
+
+	// Project A should have 2 hours, Project B should have 1 hour
+	// Sorted by duration, A should be first
+	assert.Equal(t, "project-a", agg[0].ProjectSID)
+	assert.Equal(t, 2, agg[0].BlockCount)
+	assert.Equal(t, 2*time.Hour, agg[0].Duration)
+}
+
+func TestTopProjects(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	block := func(project string, hours int) *model.Block {
+		return &model.Block{ProjectSID: project, TimestampStart: start, TimestampEnd: start.Add(time.Duration(hours) * time.Hour)}
+	}
+	blocks := []*model.Block{
+		block("a", 4),
+		block("b", 3),
+		block("c", 2),
+		block("c", 3),
+		block("d", 1),
+	}
+
+	t.Run("n_smaller_than_project_count", func(t *testing.T) {
+		top := TopProjects(blocks, 2)
+		require.Len(t, top, 3)
+		assert.Equal(t, "c", top[0].ProjectSID)
+		assert.Equal(t, "a", top[1].ProjectSID)
+		assert.Equal(t, ProjectAggregate{ProjectSID: OtherProjects, Duration: 4 * time.Hour, BlockCount: 2}, top[2])
+	})
+
+	t.Run("n_equal_to_project_count", func(t *testing.T) {
+		top := TopProjects(blocks, 4)
+		require.Len(t, top, 4)
+		for _, p := range top {
+			assert.NotEqual(t, OtherProjects, p.ProjectSID)
+		}
+		assert.Equal(t, "d", top[3].ProjectSID)
+	})
+
+	t.Run("n_larger_than_project_count", func(t *testing.T) {
+		top := TopProjects(blocks, 10)
+		require.Len(t, top, 4)
+		assert.Equal(t, []string{"c", "a", "b", "d"}, []string{top[0].ProjectSID, top[1].ProjectSID, top[2].ProjectSID, top[3].ProjectSID})
+	})
+
+	t.Run("zero_is_all_other", func(t *testing.T) {
+		top := TopProjects(blocks, 0)
+		require.Len(t, top, 1)
+		assert.Equal(t, 13*time.Hour, top[0].Duration)
+	})
+}
+
+func TestAggregateByOwner(t *testing.T) {
+	now := time.Now()
+	blocks := []*model.Block{
+		{OwnerKey: "user:alice", ProjectSID: "a", TimestampStart: now.Add(-3 * time.Hour), TimestampEnd: now.Add(-2 * time.Hour)},
+		{OwnerKey: "user:bob", ProjectSID: "a", TimestampStart: now.Add(-3 * time.Hour), TimestampEnd: now.Add(-1 * time.Hour)},
+		{OwnerKey: "user:bob", ProjectSID: "b", TimestampStart: now.Add(-1 * time.Hour), TimestampEnd: now},
+		{OwnerKey: "user:alice", ProjectSID: "b", TimestampStart: now.Add(-30 * time.Minute), TimestampEnd: now},
+	}
+
+	agg := AggregateByOwner(blocks)
+	require.Len(t, agg, 2)
+
+	assert.Equal(t, OwnerAggregate{OwnerKey: "user:bob", Duration: 3 * time.Hour, BlockCount: 2}, agg[0])
+	assert.Equal(t, OwnerAggregate{OwnerKey: "user:alice", Duration: 90 * time.Minute, BlockCount: 2}, agg[1])
+	assert.Equal(t, TotalDuration(blocks), agg[0].Duration+agg[1].Duration)
+}
+
+func TestAggregateByNotePattern(t *testing.T) {
+	now := time.Now()
+	block := func(note string, d time.Duration) *model.Block {
+		return &model.Block{ProjectSID: "work", Note: note, TimestampStart: now.Add(-d), TimestampEnd: now}
+	}
+	blocks := []*model.Block{
+		block("TICKET-12 login bug", time.Hour),
+		block("review for TICKET-7", 30*time.Minute),
+		block("more on TICKET-12", 2*time.Hour),
+		block("standup", 15*time.Minute),
+		block("", 45*time.Minute),
+	}
+
+	t.Run("capture_group", func(t *testing.T) {
+		agg := AggregateByNotePattern(blocks, regexp.MustCompile(`TICKET-(\d+)`))
+		assert.Equal(t, []NoteGroupAggregate{
+			{Group: "12", Duration: 3 * time.Hour, BlockCount: 2},
+			{Group: "7", Duration: 30 * time.Minute, BlockCount: 1},
+			{Group: UnmatchedNoteGroup, Duration: time.Hour, BlockCount: 2},
+		}, agg)
+	})
+
+	t.Run("whole_match_without_groups", func(t *testing.T) {
+		agg := AggregateByNotePattern(blocks, regexp.MustCompile(`TICKET-\d+`))
+		require.Len(t, agg, 3)
+		assert.Equal(t, "TICKET-12", agg[0].Group)
+		assert.Equal(t, "TICKET-7", agg[1].Group)
+		assert.Equal(t, UnmatchedNoteGroup, agg[2].Group)
+	})
+}
+
+func TestBlockKind(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i, kind := range []string{"meeting", "deep-work", "meeting", "", "Break"} {
+		block := model.NewBlock("", "work", "", "", start.Add(time.Duration(i)*time.Hour))
+		block.TimestampEnd = block.TimestampStart.Add(time.Duration(i+1) * 10 * time.Minute)
+		block.Kind = kind
+		require.NoError(t, repo.Create(block))
+	}
+
+	t.Run("round_trip", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{Kind: "deep-work"})
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+
+		stored, err := repo.Get(blocks[0].Key)
+		require.NoError(t, err)
+		assert.Equal(t, "deep-work", stored.Kind)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		blocks, err := repo.ListFiltered(BlockFilter{Kind: "meeting"})
+		require.NoError(t, err)
+		assert.Len(t, blocks, 2)
+
+		blocks, err = repo.ListFiltered(BlockFilter{Kind: "break"})
+		require.NoError(t, err)
+		assert.Len(t, blocks, 1)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		blocks, err := repo.List()
+		require.NoError(t, err)
+
+		assert.Equal(t, []KindAggregate{
+			{Kind: "break", Duration: 50 * time.Minute, BlockCount: 1},
+			{Kind: "", Duration: 40 * time.Minute, BlockCount: 1},
+			{Kind: "meeting", Duration: 40 * time.Minute, BlockCount: 2},
+			{Kind: "deep-work", Duration: 20 * time.Minute, BlockCount: 1},
+		}, AggregateByKind(blocks))
+	})
+}
+
+// =============================================================================
+// Safety Tests
+// =============================================================================
+
+func TestDiskSpaceInfo(t *testing.T) {
+	t.Run("free_percent_zero_total", func(t *testing.T) {
+		info := &DiskSpaceInfo{TotalBytes: 0, FreeBytes: 100}
+		assert.Equal(t, 0.0, info.FreePercent())
+	})
+
+	t.Run("free_percent_calculation", func(t *testing.T) {
+		info := &DiskSpaceInfo{TotalBytes: 1000, FreeBytes: 250}
+		assert.Equal(t, 25.0, info.FreePercent())
+	})
+}
+
+func TestGetDiskSpace(t *testing.T) {
+	t.Run("current_directory", func(t *testing.T) {
+		info, err := GetDiskSpace(".")
+		require.NoError(t, err)
+		assert.NotNil(t, info)
+		assert.Greater(t, info.TotalBytes, uint64(0))
 	})
 
 	t.Run("nonexistent_uses_parent", func(t *testing.T) {
@@ -563,6 +1395,73 @@ func TestCreateBackupEmptyPath(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRecoverDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+
+	// Write directly with a low value threshold so the large value lands in
+	// the value log, where it can be corrupted without breaking the tables.
+	large := `"` + strings.Repeat("x", 512) + `"`
+	bdb, err := badger.Open(badger.DefaultOptions(dbPath).
+		WithValueThreshold(64).
+		WithLoggingLevel(badger.ERROR))
+	require.NoError(t, err)
+	require.NoError(t, bdb.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte("project:small"), []byte(`{"sid":"small"}`)); err != nil {
+			return err
+		}
+		return txn.Set([]byte("project:large"), []byte(large))
+	}))
+	require.NoError(t, bdb.Close())
+
+	// Flip one byte inside the large value
+	vlogs, err := filepath.Glob(filepath.Join(dbPath, "*.vlog"))
+	require.NoError(t, err)
+	require.NotEmpty(t, vlogs)
+	data, err := os.ReadFile(vlogs[0])
+	require.NoError(t, err)
+	i := strings.Index(string(data), strings.Repeat("x", 64))
+	require.GreaterOrEqual(t, i, 0)
+	data[i+10] = 'y'
+	require.NoError(t, os.WriteFile(vlogs[0], data, 0644))
+
+	db, report, err := RecoverDatabase(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, 1, report.KeysSalvaged)
+	assert.Equal(t, 1, report.KeysLost)
+	assert.Equal(t, []string{"project:large"}, report.LostKeys)
+	assert.DirExists(t, report.BackupPath)
+	assert.FileExists(t, report.QuarantinePath)
+
+	exists, err := db.Exists("project:small")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = db.Exists("project:large")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRecoverDatabaseHealthy(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "db")
+	db, err := Open(Options{Path: dbPath})
+	require.NoError(t, err)
+	require.NoError(t, NewProjectRepo(db).Create(model.NewProject("alpha", "Alpha", "")))
+	require.NoError(t, db.Close())
+
+	db, report, err := RecoverDatabase(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, 1, report.KeysSalvaged)
+	assert.Zero(t, report.KeysLost)
+	assert.Empty(t, report.QuarantinePath)
+
+	project, err := NewProjectRepo(db).Get("alpha")
+	require.NoError(t, err)
+	assert.Equal(t, "Alpha", project.DisplayName)
+}
+
 // =============================================================================
 // UndoRepo Tests
 // =============================================================================
@@ -654,6 +1553,11 @@ func TestUndoRepoSaveUndoDelete(t *testing.T) {
 		Tags:           []string{"tag1", "tag2"},
 		TimestampStart: time.Now().Add(-2 * time.Hour),
 		TimestampEnd:   time.Now().Add(-1 * time.Hour),
+		Kind:           "meeting",
+		ExternalID:     "toggl:42",
+		ContinuedFrom:  "block:123",
+		Heartbeat:      time.Now().Add(-90 * time.Minute),
+		UpdatedAt:      time.Now().Add(-time.Hour),
 	}
 	err := repo.SaveUndoDelete(block)
 	require.NoError(t, err)
@@ -666,6 +1570,11 @@ func TestUndoRepoSaveUndoDelete(t *testing.T) {
 	assert.Equal(t, "myproject", state.BlockSnapshot.ProjectSID)
 	assert.Equal(t, "mytask", state.BlockSnapshot.TaskSID)
 	assert.Equal(t, "Some note", state.BlockSnapshot.Note)
+	assert.Equal(t, "meeting", state.BlockSnapshot.Kind)
+	assert.Equal(t, "toggl:42", state.BlockSnapshot.ExternalID)
+	assert.Equal(t, "block:123", state.BlockSnapshot.ContinuedFrom)
+	assert.True(t, block.Heartbeat.Equal(state.BlockSnapshot.Heartbeat))
+	assert.True(t, block.UpdatedAt.Equal(state.BlockSnapshot.UpdatedAt))
 }
 
 // =============================================================================
@@ -711,3 +1620,844 @@ func TestActiveBlockRepoClearActive(t *testing.T) {
 	assert.False(t, ab.IsTracking())
 	assert.Equal(t, "block:123", ab.PreviousBlockKey)
 }
+
+func TestActiveBlockRepoRecentBlocks(t *testing.T) {
+	db := setupTestDB(t)
+	blockRepo := NewBlockRepo(db)
+	repo := NewActiveBlockRepo(db)
+
+	now := time.Now()
+	var blocks []*model.Block
+	for i, sid := range []string{"alpha", "beta", "gamma"} {
+		block := model.NewBlock("", sid, "", "", now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, blockRepo.Create(block))
+		require.NoError(t, repo.SetActive(block.Key))
+		blocks = append(blocks, block)
+	}
+
+	t.Run("ordered_most_recent_first", func(t *testing.T) {
+		recent, err := repo.RecentBlocks(blockRepo, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 3)
+		assert.Equal(t, "gamma", recent[0].ProjectSID)
+		assert.Equal(t, "beta", recent[1].ProjectSID)
+		assert.Equal(t, "alpha", recent[2].ProjectSID)
+	})
+
+	t.Run("limited_to_n", func(t *testing.T) {
+		recent, err := repo.RecentBlocks(blockRepo, 2)
+		require.NoError(t, err)
+		assert.Len(t, recent, 2)
+	})
+
+	t.Run("dedupes_reactivated_block", func(t *testing.T) {
+		require.NoError(t, repo.SetActive(blocks[0].Key))
+		recent, err := repo.RecentBlocks(blockRepo, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 3)
+		assert.Equal(t, "alpha", recent[0].ProjectSID)
+	})
+
+	t.Run("skips_deleted_blocks", func(t *testing.T) {
+		require.NoError(t, blockRepo.Delete(blocks[1].Key))
+		recent, err := repo.RecentBlocks(blockRepo, 0)
+		require.NoError(t, err)
+		require.Len(t, recent, 2)
+		for _, b := range recent {
+			assert.NotEqual(t, blocks[1].Key, b.Key)
+		}
+	})
+}
+
+func TestAggregateByDay(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	blocks := []*model.Block{
+		{ProjectSID: "a", TimestampStart: day.Add(26 * time.Hour), TimestampEnd: day.Add(27 * time.Hour)},
+		{ProjectSID: "a", TimestampStart: day.Add(9 * time.Hour), TimestampEnd: day.Add(10 * time.Hour)},
+		{ProjectSID: "b", TimestampStart: day.Add(11 * time.Hour), TimestampEnd: day.Add(13 * time.Hour)},
+	}
+
+	agg := AggregateByDay(blocks, time.UTC)
+	require.Len(t, agg, 2)
+
+	// Sorted chronologically
+	assert.Equal(t, day, agg[0].Date)
+	assert.Equal(t, 3*time.Hour, agg[0].Duration)
+	assert.Equal(t, 2, agg[0].BlockCount)
+	assert.Equal(t, day.AddDate(0, 0, 1), agg[1].Date)
+	assert.Equal(t, time.Hour, agg[1].Duration)
+}
+
+func TestAggregateByProjectDay(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	// 20:00-21:00 UTC is 10 March in UTC but 11 March in Tokyo
+	blocks := []*model.Block{
+		{ProjectSID: "remote", TimestampStart: day.Add(20 * time.Hour), TimestampEnd: day.Add(21 * time.Hour)},
+		{ProjectSID: "remote", TimestampStart: day.Add(9 * time.Hour), TimestampEnd: day.Add(10 * time.Hour)},
+		{ProjectSID: "local", TimestampStart: day.Add(20 * time.Hour), TimestampEnd: day.Add(21 * time.Hour)},
+		{ProjectSID: "local", TimestampStart: day.Add(9 * time.Hour), TimestampEnd: day.Add(10 * time.Hour)},
+	}
+	remote := model.NewProject("remote", "Remote", "")
+	remote.Timezone = "Asia/Tokyo"
+	projects := map[string]*model.Project{
+		"remote": remote,
+		"local":  model.NewProject("local", "Local", ""),
+	}
+
+	agg := AggregateByProjectDay(blocks, projects, time.UTC)
+	require.Len(t, agg, 2)
+
+	// Without an override both blocks fall on the same day
+	require.Len(t, agg["local"], 1)
+	assert.Equal(t, day, agg["local"][0].Date)
+	assert.Equal(t, 2, agg["local"][0].BlockCount)
+
+	// In Tokyo the evening block starts the next day
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	require.Len(t, agg["remote"], 2)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, tokyo), agg["remote"][0].Date)
+	assert.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, tokyo), agg["remote"][1].Date)
+
+	t.Run("unknown_project_uses_loc", func(t *testing.T) {
+		agg := AggregateByProjectDay(blocks, nil, time.UTC)
+		require.Len(t, agg["remote"], 1)
+		assert.Equal(t, day, agg["remote"][0].Date)
+	})
+}
+
+func TestCapDays(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	// A timer left running from 03:00 to 23:00 on the first day
+	blocks := []*model.Block{
+		{ProjectSID: "a", TimestampStart: day.Add(3 * time.Hour), TimestampEnd: day.Add(23 * time.Hour)},
+		{ProjectSID: "a", TimestampStart: day.Add(33 * time.Hour), TimestampEnd: day.Add(35 * time.Hour)},
+	}
+	days := AggregateByDay(blocks, time.UTC)
+
+	capped := CapDays(days, 10*time.Hour)
+	require.Len(t, capped, 2)
+	assert.Equal(t, 10*time.Hour, capped[0].Duration)
+	assert.Equal(t, 2*time.Hour, capped[1].Duration)
+
+	// The input and the blocks are untouched
+	assert.Equal(t, 20*time.Hour, days[0].Duration)
+	assert.Equal(t, day.Add(23*time.Hour), blocks[0].TimestampEnd)
+
+	assert.Equal(t, days, CapDays(days, 0))
+}
+
+func TestRounding(t *testing.T) {
+	quarter := 15 * time.Minute
+
+	t.Run("modes", func(t *testing.T) {
+		d := 22 * time.Minute
+		assert.Equal(t, 15*time.Minute, Rounding{Increment: quarter}.Apply(d))
+		assert.Equal(t, 30*time.Minute, Rounding{Increment: quarter, Mode: RoundUp}.Apply(d))
+		assert.Equal(t, 15*time.Minute, Rounding{Increment: quarter, Mode: RoundDown}.Apply(d))
+		assert.Equal(t, 30*time.Minute, Rounding{Increment: quarter}.Apply(22*time.Minute+30*time.Second))
+		assert.Equal(t, d, Rounding{}.Apply(d))
+	})
+
+	t.Run("per_total_not_per_block", func(t *testing.T) {
+		// Four 10-minute blocks: rounding each up to 15m gives 60m, but the
+		// 40-minute total rounds up to 45m
+		start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+		var blocks []*model.Block
+		for i := 0; i < 4; i++ {
+			b := &model.Block{ProjectSID: "a", TimestampStart: start.Add(time.Duration(i) * time.Hour)}
+			b.TimestampEnd = b.TimestampStart.Add(10 * time.Minute)
+			blocks = append(blocks, b)
+		}
+		r := Rounding{Increment: quarter, Mode: RoundUp}
+
+		var perBlock time.Duration
+		for _, b := range blocks {
+			perBlock += r.Apply(b.Duration())
+		}
+		assert.Equal(t, time.Hour, perBlock)
+
+		projects := RoundProjects(AggregateByProject(blocks), r)
+		require.Len(t, projects, 1)
+		assert.Equal(t, 45*time.Minute, projects[0].Duration)
+
+		days := RoundDays(AggregateByDay(blocks, time.UTC), r)
+		require.Len(t, days, 1)
+		assert.Equal(t, 45*time.Minute, days[0].Duration)
+
+		// Blocks are untouched
+		assert.Equal(t, 10*time.Minute, blocks[0].Duration())
+	})
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for name, want := range map[string]RoundingMode{
+		"":        RoundNearest,
+		"nearest": RoundNearest,
+		"Up":      RoundUp,
+		"down":    RoundDown,
+	} {
+		got, err := ParseRoundingMode(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseRoundingMode("ceiling")
+	assert.Error(t, err)
+}
+
+func TestSplitBlocksByDay(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("within_one_day_unchanged", func(t *testing.T) {
+		b := &model.Block{Key: "block:1", TimestampStart: day.Add(9 * time.Hour), TimestampEnd: day.Add(17 * time.Hour)}
+		split := SplitBlocksByDay([]*model.Block{b}, time.UTC)
+		require.Len(t, split, 1)
+		assert.Same(t, b, split[0])
+	})
+
+	t.Run("spans_two_days", func(t *testing.T) {
+		b := &model.Block{
+			Key:            "block:1",
+			ProjectSID:     "a",
+			Tags:           []string{"late"},
+			TimestampStart: day.Add(22 * time.Hour),
+			TimestampEnd:   day.Add(27 * time.Hour),
+		}
+		split := SplitBlocksByDay([]*model.Block{b}, time.UTC)
+		require.Len(t, split, 2)
+
+		assert.Equal(t, "block:1#1", split[0].Key)
+		assert.Equal(t, day.Add(22*time.Hour), split[0].TimestampStart)
+		assert.Equal(t, day.AddDate(0, 0, 1), split[0].TimestampEnd)
+		assert.Equal(t, "block:1#2", split[1].Key)
+		assert.Equal(t, day.AddDate(0, 0, 1), split[1].TimestampStart)
+		assert.Equal(t, day.Add(27*time.Hour), split[1].TimestampEnd)
+		assert.Equal(t, "a", split[1].ProjectSID)
+		assert.Equal(t, []string{"late"}, split[1].Tags)
+
+		// Original untouched
+		assert.Equal(t, "block:1", b.Key)
+		assert.Equal(t, day.Add(27*time.Hour), b.TimestampEnd)
+
+		agg := AggregateByDay(split, time.UTC)
+		require.Len(t, agg, 2)
+		assert.Equal(t, 2*time.Hour, agg[0].Duration)
+		assert.Equal(t, 3*time.Hour, agg[1].Duration)
+	})
+
+	t.Run("spans_three_days", func(t *testing.T) {
+		b := &model.Block{Key: "block:1", TimestampStart: day.Add(20 * time.Hour), TimestampEnd: day.Add(50 * time.Hour)}
+		split := SplitBlocksByDay([]*model.Block{b}, time.UTC)
+		require.Len(t, split, 3)
+		assert.Equal(t, 4*time.Hour, split[0].Duration())
+		assert.Equal(t, 24*time.Hour, split[1].Duration())
+		assert.Equal(t, 2*time.Hour, split[2].Duration())
+		assert.Equal(t, b.Duration(), TotalDuration(split))
+	})
+
+	t.Run("active_block_keeps_last_segment_active", func(t *testing.T) {
+		b := &model.Block{Key: "block:1", TimestampStart: time.Now().Add(-50 * time.Hour)}
+		split := SplitBlocksByDay([]*model.Block{b}, time.UTC)
+		require.GreaterOrEqual(t, len(split), 3)
+		assert.True(t, split[len(split)-1].IsActive())
+		assert.False(t, split[0].IsActive())
+	})
+}
+
+func TestActiveBlockRepoCompareAndSwap(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewActiveBlockRepo(db)
+
+	require.NoError(t, repo.SetActive("block:1"))
+	ab, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ab.Revision)
+
+	t.Run("matching_revision_succeeds", func(t *testing.T) {
+		err := repo.CompareAndSwap(ab.Revision, func(a *model.ActiveBlock) {
+			a.SetActive("block:2")
+		})
+		require.NoError(t, err)
+
+		current, err := repo.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "block:2", current.ActiveBlockKey)
+		assert.Equal(t, int64(2), current.Revision)
+	})
+
+	t.Run("stale_revision_conflicts", func(t *testing.T) {
+		err := repo.CompareAndSwap(ab.Revision, func(a *model.ActiveBlock) {
+			a.SetActive("block:3")
+		})
+		assert.ErrorIs(t, err, ErrActiveBlockConflict)
+		assert.True(t, IsErrConflict(err))
+
+		current, err := repo.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "block:2", current.ActiveBlockKey)
+	})
+}
+
+func TestActiveBlockRepoConcurrentSwitches(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewActiveBlockRepo(db)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for {
+				err := repo.SetActive(key)
+				if IsErrConflict(err) {
+					continue // Retry on conflict
+				}
+				errs <- err
+				return
+			}
+		}(fmt.Sprintf("block:%d", i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	ab, err := repo.Get()
+	require.NoError(t, err)
+
+	// Every switch was applied exactly once
+	assert.Equal(t, int64(workers), ab.Revision)
+	assert.Len(t, ab.RecentBlockKeys, workers)
+
+	// Final state is consistent: the active key is the most recent one
+	assert.Equal(t, ab.RecentBlockKeys[0], ab.ActiveBlockKey)
+	assert.Equal(t, ab.RecentBlockKeys[1], ab.PreviousBlockKey)
+}
+
+func TestActiveBlockRepoDanglingPointer(t *testing.T) {
+	setup := func(t *testing.T) (*BlockRepo, *ActiveBlockRepo, *model.Block, *model.Block) {
+		db := setupTestDB(t)
+		blockRepo := NewBlockRepo(db)
+		repo := NewActiveBlockRepo(db)
+
+		previous := model.NewBlock("", "old", "", "", time.Now().Add(-2*time.Hour))
+		previous.TimestampEnd = time.Now().Add(-time.Hour)
+		current := model.NewBlock("", "current", "", "", time.Now().Add(-time.Minute))
+		for _, b := range []*model.Block{previous, current} {
+			require.NoError(t, blockRepo.Create(b))
+			require.NoError(t, repo.SetActive(b.Key))
+		}
+		return blockRepo, repo, previous, current
+	}
+
+	t.Run("get_active_self_heals", func(t *testing.T) {
+		blockRepo, repo, previous, current := setup(t)
+		require.NoError(t, blockRepo.Delete(current.Key))
+
+		block, err := repo.GetActiveBlock(blockRepo)
+		require.NoError(t, err)
+		assert.Nil(t, block)
+
+		ab, err := repo.Get()
+		require.NoError(t, err)
+		assert.False(t, ab.IsTracking())
+		assert.Equal(t, previous.Key, ab.PreviousBlockKey, "previous pointer is kept")
+		assert.Equal(t, []string{previous.Key}, ab.RecentBlockKeys)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		blockRepo, repo, previous, current := setup(t)
+		require.NoError(t, blockRepo.Delete(current.Key))
+		require.NoError(t, blockRepo.Delete(previous.Key))
+
+		repaired, err := repo.Repair()
+		require.NoError(t, err)
+		assert.True(t, repaired)
+
+		ab, err := repo.Get()
+		require.NoError(t, err)
+		assert.False(t, ab.IsTracking())
+		assert.Empty(t, ab.PreviousBlockKey)
+		assert.Empty(t, ab.RecentBlockKeys)
+
+		prev, err := repo.GetPreviousBlock(blockRepo)
+		require.NoError(t, err)
+		assert.Nil(t, prev)
+
+		repaired, err = repo.Repair()
+		require.NoError(t, err)
+		assert.False(t, repaired, "second repair is a no-op")
+	})
+
+	t.Run("repair_healthy_state", func(t *testing.T) {
+		_, repo, _, current := setup(t)
+		before, err := repo.Get()
+		require.NoError(t, err)
+
+		repaired, err := repo.Repair()
+		require.NoError(t, err)
+		assert.False(t, repaired)
+
+		after, err := repo.Get()
+		require.NoError(t, err)
+		assert.Equal(t, current.Key, after.ActiveBlockKey)
+		assert.Equal(t, before.Revision, after.Revision)
+	})
+}
+
+func TestBlockRepoCreatePreservingKey(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	imported := func(key string) *model.Block {
+		b := model.NewBlock("", "client", "", "imported", start)
+		b.TimestampEnd = start.Add(time.Hour)
+		b.Key = key
+		return b
+	}
+
+	t.Run("keeps_free_key", func(t *testing.T) {
+		b := imported("block:0192f3a4-0000-7000-8000-000000000001")
+		rekeyed, err := repo.CreatePreservingKey(b)
+		require.NoError(t, err)
+		assert.False(t, rekeyed)
+		assert.Equal(t, "block:0192f3a4-0000-7000-8000-000000000001", b.Key)
+
+		got, err := repo.Get(b.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "imported", got.Note)
+	})
+
+	t.Run("collision_gets_new_key", func(t *testing.T) {
+		existing := model.NewBlock("", "internal", "", "existing", start)
+		existing.TimestampEnd = start.Add(time.Hour)
+		require.NoError(t, repo.Create(existing))
+
+		b := imported(existing.Key)
+		rekeyed, err := repo.CreatePreservingKey(b)
+		require.NoError(t, err)
+		assert.True(t, rekeyed)
+		assert.NotEqual(t, existing.Key, b.Key)
+
+		// Both blocks are stored
+		got, err := repo.Get(existing.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "existing", got.Note)
+		got, err = repo.Get(b.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "imported", got.Note)
+	})
+
+	t.Run("trashed_key_collides", func(t *testing.T) {
+		trashed := model.NewBlock("", "client", "", "", start)
+		trashed.TimestampEnd = start.Add(time.Hour)
+		require.NoError(t, repo.Create(trashed))
+		require.NoError(t, repo.Delete(trashed.Key))
+
+		b := imported(trashed.Key)
+		rekeyed, err := repo.CreatePreservingKey(b)
+		require.NoError(t, err)
+		assert.True(t, rekeyed)
+
+		_, err = repo.Restore(trashed.Key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing_key_gets_new_key", func(t *testing.T) {
+		b := imported("")
+		rekeyed, err := repo.CreatePreservingKey(b)
+		require.NoError(t, err)
+		assert.True(t, rekeyed)
+		assert.True(t, strings.HasPrefix(b.Key, model.PrefixBlock+":"))
+	})
+}
+
+func TestBlockRepoCreateBatch(t *testing.T) {
+	now := time.Now()
+
+	t.Run("creates_all_blocks", func(t *testing.T) {
+		db := setupTestDB(t)
+		repo := NewBlockRepo(db)
+
+		var blocks []*model.Block
+		for i := 0; i < 50; i++ {
+			blocks = append(blocks, model.NewBlock("", "batch", "", "", now.Add(time.Duration(i)*time.Minute)))
+		}
+		require.NoError(t, repo.CreateBatch(blocks))
+
+		keys := make(map[string]bool)
+		for _, b := range blocks {
+			assert.NotEmpty(t, b.Key)
+			keys[b.Key] = true
+		}
+		assert.Len(t, keys, 50, "keys should be unique")
+
+		stored, err := repo.List()
+		require.NoError(t, err)
+		assert.Len(t, stored, 50)
+	})
+
+	t.Run("validation_failure_writes_nothing", func(t *testing.T) {
+		db := setupTestDB(t)
+		repo := NewBlockRepo(db)
+
+		blocks := []*model.Block{
+			model.NewBlock("", "batch", "", "", now),
+			model.NewBlock("", "", "", "missing project", now),
+			model.NewBlock("", "batch", "", "", now),
+		}
+		err := repo.CreateBatch(blocks)
+		require.Error(t, err)
+
+		stored, err := repo.List()
+		require.NoError(t, err)
+		assert.Empty(t, stored)
+		for _, b := range blocks {
+			assert.Empty(t, b.Key)
+		}
+	})
+
+	t.Run("empty_batch", func(t *testing.T) {
+		db := setupTestDB(t)
+		repo := NewBlockRepo(db)
+		assert.NoError(t, repo.CreateBatch(nil))
+	})
+}
+
+func TestBlockRepoListFilteredContainsInstant(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	base := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	morning := model.NewBlock("", "morning", "", "", base.Add(-2*time.Hour))
+	morning.TimestampEnd = base
+	afternoon := model.NewBlock("", "afternoon", "", "", base)
+	afternoon.TimestampEnd = base.Add(time.Hour)
+	active := model.NewBlock("", "active", "", "", time.Now().Add(-10*time.Minute))
+	for _, b := range []*model.Block{morning, afternoon, active} {
+		require.NoError(t, repo.Create(b))
+	}
+
+	list := func(instant time.Time) []string {
+		blocks, err := repo.ListFiltered(BlockFilter{ContainsInstant: &instant})
+		require.NoError(t, err)
+		var sids []string
+		for _, b := range blocks {
+			sids = append(sids, b.ProjectSID)
+		}
+		return sids
+	}
+
+	t.Run("start_boundary_is_inclusive", func(t *testing.T) {
+		// 15:00 is the start of afternoon and the end of morning
+		assert.Equal(t, []string{"afternoon"}, list(base))
+	})
+
+	t.Run("end_boundary_is_exclusive", func(t *testing.T) {
+		assert.Empty(t, list(base.Add(time.Hour)))
+	})
+
+	t.Run("inside_interval", func(t *testing.T) {
+		assert.Equal(t, []string{"morning"}, list(base.Add(-time.Hour)))
+	})
+
+	t.Run("active_block_until_now", func(t *testing.T) {
+		assert.Equal(t, []string{"active"}, list(time.Now().Add(-time.Minute)))
+		assert.Empty(t, list(time.Now().Add(time.Hour)))
+	})
+}
+
+func TestBlockRepoStopRoundsToMinTrackUnit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+	configRepo := NewConfigRepo(db)
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	stopAfter := func(d time.Duration) *model.Block {
+		b := model.NewBlock("", "proj", "", "", start)
+		require.NoError(t, repo.Create(b))
+		b.TimestampEnd = start.Add(d)
+		require.NoError(t, repo.Stop(b))
+		stored, err := repo.Get(b.Key)
+		require.NoError(t, err)
+		return stored
+	}
+
+	t.Run("disabled_by_default", func(t *testing.T) {
+		assert.Equal(t, 4*time.Minute, stopAfter(4*time.Minute).Duration())
+	})
+
+	config, err := configRepo.Get()
+	require.NoError(t, err)
+	config.MinTrackUnit = 6 * time.Minute
+	require.NoError(t, configRepo.Save(config))
+
+	t.Run("four_minutes_stores_six", func(t *testing.T) {
+		assert.Equal(t, 6*time.Minute, stopAfter(4*time.Minute).Duration())
+	})
+
+	t.Run("rounds_up_to_next_increment", func(t *testing.T) {
+		assert.Equal(t, 12*time.Minute, stopAfter(6*time.Minute+time.Second).Duration())
+	})
+
+	t.Run("exact_multiple_unchanged", func(t *testing.T) {
+		assert.Equal(t, 12*time.Minute, stopAfter(12*time.Minute).Duration())
+	})
+}
+
+func TestConfigRepo(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewConfigRepo(db)
+
+	config, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, model.KeyConfig, config.Key)
+	assert.Zero(t, config.MinTrackUnit)
+
+	config.MinTrackUnit = 15 * time.Minute
+	require.NoError(t, repo.Save(config))
+
+	loaded, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, loaded.MinTrackUnit)
+}
+
+func TestConfigRepoExportImport(t *testing.T) {
+	src := NewConfigRepo(setupTestDB(t))
+	config, err := src.Get()
+	require.NoError(t, err)
+	config.UserKey = "user:source"
+	config.MinTrackUnit = 6 * time.Minute
+	config.MaxNoteLength = 200
+	config.TruncateNotes = true
+	config.ProjectPalette = []string{"#111111", "#222222"}
+	require.NoError(t, src.Save(config))
+
+	data, err := src.ExportConfig()
+	require.NoError(t, err)
+
+	dstDB := setupTestDB(t)
+	dst := NewConfigRepo(dstDB)
+	local, err := dst.Get()
+	require.NoError(t, err)
+	local.UserKey = "user:local"
+	require.NoError(t, dst.Save(local))
+
+	block := model.NewBlock("", "alpha", "", "keep me", time.Now().Add(-time.Hour))
+	require.NoError(t, NewBlockRepo(dstDB).Create(block))
+	require.NoError(t, NewProjectRepo(dstDB).Create(model.NewProject("alpha", "Alpha", "")))
+
+	_, err = dst.ImportConfig(data)
+	require.NoError(t, err)
+
+	t.Run("round_trip", func(t *testing.T) {
+		imported, err := dst.Get()
+		require.NoError(t, err)
+		assert.Equal(t, 6*time.Minute, imported.MinTrackUnit)
+		assert.Equal(t, 200, imported.MaxNoteLength)
+		assert.True(t, imported.TruncateNotes)
+		assert.Equal(t, []string{"#111111", "#222222"}, imported.ProjectPalette)
+	})
+
+	t.Run("keeps_local_user", func(t *testing.T) {
+		imported, err := dst.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "user:local", imported.UserKey)
+	})
+
+	t.Run("data_untouched", func(t *testing.T) {
+		blocks, err := NewBlockRepo(dstDB).List()
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		assert.Equal(t, "keep me", blocks[0].Note)
+		projects, err := NewProjectRepo(dstDB).List()
+		require.NoError(t, err)
+		assert.Len(t, projects, 1)
+	})
+
+	t.Run("export_contains_no_data", func(t *testing.T) {
+		exported, err := NewConfigRepo(dstDB).ExportConfig()
+		require.NoError(t, err)
+		assert.NotContains(t, string(exported), "keep me")
+		assert.NotContains(t, string(exported), "block:")
+	})
+
+	t.Run("invalid_input", func(t *testing.T) {
+		_, err := dst.ImportConfig([]byte("not json"))
+		assert.Error(t, err)
+		_, err = dst.ImportConfig([]byte(`{"version":"2"}`))
+		assert.Error(t, err)
+		_, err = dst.ImportConfig([]byte(`{"config":{"project_palette":["red"]}}`))
+		assert.Error(t, err)
+		_, err = dst.ImportConfig([]byte(`{"config":{"auto_tag_rules":[{"pattern":"(","tag":"x"}]}}`))
+		assert.Error(t, err)
+		_, err = dst.ImportConfig([]byte(`{"config":{"block_kinds":["meeting","meeting"]}}`))
+		assert.Error(t, err)
+		_, err = dst.ImportConfig([]byte(`{"config":{"resume_snap":"sideways"}}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestBlockRepoFirstAndLast(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	mk := func(project string, start time.Time, dur time.Duration) *model.Block {
+		b := model.NewBlock("", project, "", "", start)
+		b.TimestampEnd = start.Add(dur)
+		require.NoError(t, repo.Create(b))
+		return b
+	}
+
+	mk("proj", base.AddDate(0, 0, 5), time.Hour)
+	earliest := mk("proj", base, time.Hour)
+	// Starts before the latest-starting block but ends after it
+	longest := mk("proj", base.AddDate(0, 0, 9), 48*time.Hour)
+	mk("proj", base.AddDate(0, 0, 10), time.Hour)
+	mk("other", base.AddDate(0, 0, -30), time.Hour)
+
+	t.Run("extremes", func(t *testing.T) {
+		first, last, err := repo.FirstAndLast("proj")
+		require.NoError(t, err)
+		assert.Equal(t, earliest.Key, first.Key)
+		assert.Equal(t, longest.Key, last.Key)
+	})
+
+	t.Run("active_block_is_latest", func(t *testing.T) {
+		active := model.NewBlock("", "proj", "", "", base.AddDate(0, 0, 1))
+		require.NoError(t, repo.Create(active))
+
+		_, last, err := repo.FirstAndLast("proj")
+		require.NoError(t, err)
+		assert.Equal(t, active.Key, last.Key)
+	})
+
+	t.Run("no_blocks", func(t *testing.T) {
+		_, _, err := repo.FirstAndLast("missing")
+		assert.True(t, IsErrKeyNotFound(err))
+	})
+}
+
+func TestBlockRepoNormalizeTags(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Now().Add(-5 * time.Hour)
+	for i, tags := range [][]string{
+		{"Billable", "urgent"},
+		{"billable "},
+		{" BILLABLE", "billable"},
+		{"urgent"},
+	} {
+		b := model.NewBlock("", "client", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		b.Tags = tags
+		require.NoError(t, repo.Create(b))
+	}
+
+	n, err := repo.NormalizeTags()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	blocks, err := repo.ListFiltered(BlockFilter{Limit: NoLimit})
+	require.NoError(t, err)
+	variants := make(map[string]int)
+	for _, b := range blocks {
+		for _, tag := range b.Tags {
+			variants[tag]++
+		}
+	}
+	assert.Equal(t, map[string]int{"billable": 3, "urgent": 2}, variants)
+
+	// The tag index only knows the normalized spelling
+	tagged, err := repo.ListByTag("billable")
+	require.NoError(t, err)
+	assert.Len(t, tagged, 3)
+
+	n, err = repo.NormalizeTags()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	t.Run("preserve_case", func(t *testing.T) {
+		config, err := NewConfigRepo(db).Get()
+		require.NoError(t, err)
+		config.PreserveTagCase = true
+		require.NoError(t, NewConfigRepo(db).Save(config))
+
+		b := model.NewBlock("", "client", "", "", start)
+		b.TimestampEnd = start.Add(time.Hour)
+		b.Tags = []string{" Invoiced", "INVOICED"}
+		require.NoError(t, repo.Create(b))
+
+		n, err := repo.NormalizeTags()
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		got, err := repo.Get(b.Key)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Invoiced"}, got.Tags)
+	})
+}
+
+func TestBlockRepoTagFiltered(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Now().Add(-5 * time.Hour)
+	for i := 0; i < 3; i++ {
+		b := model.NewBlock("", "client", "", "", start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, repo.Create(b))
+	}
+	other := model.NewBlock("", "internal", "", "", start)
+	other.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(other))
+
+	filter := BlockFilter{ProjectSID: "client"}
+
+	t.Run("tags_all_project_blocks", func(t *testing.T) {
+		n, err := repo.AddTagFiltered(filter, "billable")
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		tagged, err := repo.ListFiltered(BlockFilter{Tag: "billable"})
+		require.NoError(t, err)
+		assert.Len(t, tagged, 3)
+		for _, b := range tagged {
+			assert.Equal(t, "client", b.ProjectSID)
+		}
+	})
+
+	t.Run("adding_again_is_noop", func(t *testing.T) {
+		n, err := repo.AddTagFiltered(filter, "Billable")
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		blocks, err := repo.ListByProject("client")
+		require.NoError(t, err)
+		for _, b := range blocks {
+			assert.Equal(t, []string{"billable"}, b.Tags)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		n, err := repo.RemoveTagFiltered(filter, "billable")
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		n, err = repo.RemoveTagFiltered(filter, "billable")
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		tagged, err := repo.ListFiltered(BlockFilter{Tag: "billable"})
+		require.NoError(t, err)
+		assert.Empty(t, tagged)
+	})
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// ErrBlockExists is returned when restoring a block whose key is in use.
+var ErrBlockExists = errors.New("block already exists")
+
+// Delete moves a block to the trash, from where it can be restored.
+// Use HardDelete to remove a block permanently.
+func (r *BlockRepo) Delete(key string) error {
+	var block *model.Block
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		b := &model.Block{}
+		if err := getTxn(txn, key, b); err != nil {
+			if IsErrKeyNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		block = b
+
+		if err := setTxn(txn, model.NewTrashedBlock(b, time.Now())); err != nil {
+			return err
+		}
+		return deleteBlockTxn(txn, b)
+	})
+	if err != nil {
+		return err
+	}
+	if block != nil {
+		r.db.events.Publish(Event{Type: EventDelete, Block: block})
+	}
+	return nil
+}
+
+// HardDelete removes a block permanently, bypassing the trash.
+func (r *BlockRepo) HardDelete(key string) error {
+	var block *model.Block
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		b := &model.Block{}
+		if err := getTxn(txn, key, b); err != nil {
+			if IsErrKeyNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		block = b
+		return deleteBlockTxn(txn, b)
+	})
+	if err != nil {
+		return err
+	}
+	if block != nil {
+		r.db.events.Publish(Event{Type: EventDelete, Block: block})
+	}
+	return nil
+}
+
+// ListTrash retrieves all trashed blocks, most recently deleted first.
+func (r *BlockRepo) ListTrash() ([]*model.TrashedBlock, error) {
+	trashed, err := GetAllByPrefix(r.db, model.PrefixTrash+":", func() *model.TrashedBlock {
+		return &model.TrashedBlock{}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(trashed, func(i, j int) bool {
+		return trashed[i].DeletedAt.After(trashed[j].DeletedAt)
+	})
+	return trashed, nil
+}
+
+// Restore moves a block out of the trash under its original key.
+// Returns ErrKeyNotFound if it is not in the trash, or ErrBlockExists if a
+// block with that key has been created since.
+func (r *BlockRepo) Restore(blockKey string) (*model.Block, error) {
+	var block *model.Block
+	err := r.db.db.Update(func(txn *badger.Txn) error {
+		trashed := &model.TrashedBlock{}
+		trashKey := model.GenerateTrashKey(blockKey)
+		if err := getTxn(txn, trashKey, trashed); err != nil {
+			return err
+		}
+
+		if _, err := txn.Get([]byte(blockKey)); err == nil {
+			return fmt.Errorf("%w: %s", ErrBlockExists, blockKey)
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		block = trashed.Block
+		block.Key = blockKey
+		if err := putBlockTxn(txn, block); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(trashKey))
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.db.events.Publish(Event{Type: EventCreate, Block: block})
+	return block, nil
+}
+
+// EmptyTrash permanently removes trashed blocks deleted more than olderThan
+// ago. An olderThan of zero empties the trash. Returns the number purged.
+func (r *BlockRepo) EmptyTrash(olderThan time.Duration) (int, error) {
+	trashed, err := r.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var keys []string
+	for _, t := range trashed {
+		if olderThan <= 0 || t.DeletedAt.Before(cutoff) {
+			keys = append(keys, t.Key)
+		}
+	}
+
+	if err := r.db.deleteKeys(keys); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Trash Tests
+// =============================================================================
+
+func TestBlockRepoTrash(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	start := time.Now().Add(-2 * time.Hour)
+	block := model.NewBlock("", "proj", "", "oops", start)
+	block.TimestampEnd = start.Add(time.Hour)
+	require.NoError(t, repo.Create(block))
+
+	t.Run("delete_moves_to_trash", func(t *testing.T) {
+		require.NoError(t, repo.Delete(block.Key))
+
+		_, err := repo.Get(block.Key)
+		assert.True(t, IsErrKeyNotFound(err))
+
+		trashed, err := repo.ListTrash()
+		require.NoError(t, err)
+		require.Len(t, trashed, 1)
+		assert.Equal(t, block.Key, trashed[0].BlockKey())
+		assert.Equal(t, "oops", trashed[0].Block.Note)
+		assert.WithinDuration(t, time.Now(), trashed[0].DeletedAt, time.Minute)
+
+		blocks, err := repo.List()
+		require.NoError(t, err)
+		assert.Empty(t, blocks, "trashed blocks are not listed")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		restored, err := repo.Restore(block.Key)
+		require.NoError(t, err)
+		assert.Equal(t, block.Key, restored.Key)
+
+		stored, err := repo.Get(block.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "oops", stored.Note)
+		assert.True(t, stored.TimestampEnd.Equal(block.TimestampEnd))
+
+		trashed, err := repo.ListTrash()
+		require.NoError(t, err)
+		assert.Empty(t, trashed)
+	})
+
+	t.Run("restore_missing", func(t *testing.T) {
+		_, err := repo.Restore("block:missing")
+		assert.True(t, IsErrKeyNotFound(err))
+	})
+
+	t.Run("restore_refuses_to_overwrite", func(t *testing.T) {
+		require.NoError(t, repo.Delete(block.Key))
+		require.NoError(t, db.Set(block))
+
+		_, err := repo.Restore(block.Key)
+		assert.ErrorIs(t, err, ErrBlockExists)
+	})
+
+	t.Run("hard_delete_bypasses_trash", func(t *testing.T) {
+		_, err := repo.EmptyTrash(0)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.HardDelete(block.Key))
+		trashed, err := repo.ListTrash()
+		require.NoError(t, err)
+		assert.Empty(t, trashed)
+		_, err = repo.Get(block.Key)
+		assert.True(t, IsErrKeyNotFound(err))
+	})
+}
+
+func TestBlockRepoEmptyTrash(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewBlockRepo(db)
+
+	now := time.Now()
+	old := model.NewBlock("", "proj", "", "", now.Add(-72*time.Hour))
+	old.Key = "block:old"
+	recent := model.NewBlock("", "proj", "", "", now.Add(-time.Hour))
+	recent.Key = "block:recent"
+	require.NoError(t, db.Set(model.NewTrashedBlock(old, now.Add(-40*24*time.Hour))))
+	require.NoError(t, db.Set(model.NewTrashedBlock(recent, now.Add(-time.Hour))))
+
+	purged, err := repo.EmptyTrash(30 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	trashed, err := repo.ListTrash()
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, "block:recent", trashed[0].BlockKey())
+
+	purged, err = repo.EmptyTrash(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
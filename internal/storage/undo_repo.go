@@ -49,19 +49,19 @@ func (r *UndoRepo) SaveUndoStop(block *model.Block) error {
 	return r.Set(state)
 }
 
+// SaveUndoSplit saves undo state for a split or continue that created the
+// block with key blockKey. Undoing it merges the block back with Merge.
+func (r *UndoRepo) SaveUndoSplit(blockKey string) error {
+	state := model.NewUndoState(model.UndoActionSplit, blockKey, nil)
+	return r.Set(state)
+}
+
 // SaveUndoDelete saves undo state for a delete action with full block snapshot.
 func (r *UndoRepo) SaveUndoDelete(block *model.Block) error {
-	// Create a copy of the block for the snapshot
-	snapshot := &model.Block{
-		Key:            block.Key,
-		OwnerKey:       block.OwnerKey,
-		ProjectSID:     block.ProjectSID,
-		TaskSID:        block.TaskSID,
-		Note:           block.Note,
-		Tags:           block.Tags,
-		TimestampStart: block.TimestampStart,
-		TimestampEnd:   block.TimestampEnd,
-	}
-	state := model.NewUndoState(model.UndoActionDelete, block.Key, snapshot)
+	// Copy the whole block so a permanent delete can be undone without
+	// losing any of its fields
+	snapshot := *block
+	snapshot.Tags = append([]string(nil), block.Tags...)
+	state := model.NewUndoState(model.UndoActionDelete, block.Key, &snapshot)
 	return r.Set(state)
 }
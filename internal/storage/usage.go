@@ -0,0 +1,43 @@
+package storage
+
+import "github.com/manav03panchal/humantime/internal/model"
+
+// UsageReport summarizes how much data the database holds.
+type UsageReport struct {
+	LSMBytes      int64 `json:"lsm_bytes"`
+	ValueLogBytes int64 `json:"value_log_bytes"`
+	TotalBytes    int64 `json:"total_bytes"`
+	Projects      int   `json:"projects"`
+	Blocks        int   `json:"blocks"`
+	TrashedBlocks int   `json:"trashed_blocks"`
+}
+
+// Usage reports the on-disk size of the database along with entity counts.
+func Usage(db *DB) (*UsageReport, error) {
+	lsm, vlog, err := db.SizeOnDisk()
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := db.ListByPrefix(model.PrefixProject + ":")
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := db.ListByPrefix(model.PrefixBlock + ":")
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := db.ListByPrefix(model.PrefixTrash + ":")
+	if err != nil {
+		return nil, err
+	}
+
+	return &UsageReport{
+		LSMBytes:      lsm,
+		ValueLogBytes: vlog,
+		TotalBytes:    lsm + vlog,
+		Projects:      len(projects),
+		Blocks:        len(blocks),
+		TrashedBlocks: len(trashed),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Usage Tests
+// =============================================================================
+
+func TestUsageInMemory(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, NewProjectRepo(db).Create(model.NewProject("alpha", "Alpha", "")))
+
+	lsm, vlog, err := db.SizeOnDisk()
+	require.NoError(t, err)
+	assert.Zero(t, lsm)
+	assert.Zero(t, vlog)
+
+	report, err := Usage(db)
+	require.NoError(t, err)
+	assert.Zero(t, report.TotalBytes)
+	assert.Equal(t, 1, report.Projects)
+}
+
+func TestUsageOnDisk(t *testing.T) {
+	path := t.TempDir()
+	db, err := Open(Options{Path: path})
+	require.NoError(t, err)
+
+	require.NoError(t, NewProjectRepo(db).Create(model.NewProject("alpha", "Alpha", "")))
+	blockRepo := NewBlockRepo(db)
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		b := model.NewBlock("", "alpha", "", strings.Repeat("note ", 20), start.Add(time.Duration(i)*time.Hour))
+		b.TimestampEnd = b.TimestampStart.Add(30 * time.Minute)
+		require.NoError(t, blockRepo.Create(b))
+	}
+	blocks, err := blockRepo.List()
+	require.NoError(t, err)
+	require.NoError(t, blockRepo.Delete(blocks[0].Key))
+
+	// Badger measures sizes on open, so reopen to pick up the writes
+	require.NoError(t, db.Close())
+	db, err = Open(Options{Path: path})
+	require.NoError(t, err)
+	defer db.Close()
+
+	report, err := Usage(db)
+	require.NoError(t, err)
+	assert.Greater(t, report.TotalBytes, int64(0))
+	assert.Equal(t, report.LSMBytes+report.ValueLogBytes, report.TotalBytes)
+	assert.Equal(t, 1, report.Projects)
+	assert.Equal(t, 49, report.Blocks)
+	assert.Equal(t, 1, report.TrashedBlocks)
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+)
+
+// weeklyLabelWidth is the width of the label column in RenderWeeklySummary;
+// project names longer than weeklyNameWidth wrap onto further lines.
+const (
+	weeklyLabelWidth = 26
+	weeklyNameWidth  = weeklyLabelWidth - 2
+)
+
+// WeeklyReport holds the tracked time of one Monday-to-Sunday week.
+type WeeklyReport struct {
+	Week     PeriodRange
+	Days     []DayAggregate     // All seven days, Monday first
+	Projects []ProjectAggregate // Most tracked first, ties by SID
+	Total    time.Duration
+}
+
+// WeekRange returns the Monday-to-Monday week in loc that contains t.
+func WeekRange(t time.Time, loc *time.Location) PeriodRange {
+	if loc == nil {
+		loc = time.Local
+	}
+	day := startOfDay(t, loc)
+	offset := (int(day.Weekday()) + 6) % 7
+	start := day.AddDate(0, 0, -offset)
+	return PeriodRange{Start: start, End: start.AddDate(0, 0, 7)}
+}
+
+// WeeklySummary totals the blocks of the week in loc that contains weekOf,
+// per day and per project. Blocks are split at midnight and only the parts
+// inside the week count. Active blocks count up to now.
+func WeeklySummary(blocks []*model.Block, weekOf, now time.Time, loc *time.Location) WeeklyReport {
+	if loc == nil {
+		loc = time.Local
+	}
+	report := WeeklyReport{Week: WeekRange(weekOf, loc)}
+
+	closed := make([]*model.Block, len(blocks))
+	for i, b := range blocks {
+		closed[i] = b
+		if b.IsActive() {
+			c := *b
+			c.TimestampEnd = b.EndAt(now)
+			closed[i] = &c
+		}
+	}
+
+	var inWeek []*model.Block
+	for _, b := range SplitBlocksByDay(closed, loc) {
+		if !b.TimestampStart.Before(report.Week.Start) && b.TimestampStart.Before(report.Week.End) {
+			inWeek = append(inWeek, b)
+		}
+	}
+
+	byDay := make(map[time.Time]DayAggregate)
+	for _, day := range AggregateByDay(inWeek, loc) {
+		byDay[day.Date] = day
+	}
+	for i := 0; i < 7; i++ {
+		date := report.Week.Start.AddDate(0, 0, i)
+		day, ok := byDay[date]
+		if !ok {
+			day = DayAggregate{Date: date}
+		}
+		report.Days = append(report.Days, day)
+		report.Total += day.Duration
+	}
+
+	report.Projects = AggregateByProject(inWeek)
+	sort.SliceStable(report.Projects, func(i, j int) bool {
+		if report.Projects[i].Duration != report.Projects[j].Duration {
+			return report.Projects[i].Duration > report.Projects[j].Duration
+		}
+		return report.Projects[i].ProjectSID < report.Projects[j].ProjectSID
+	})
+	return report
+}
+
+// RenderWeeklySummary writes the report as plain text suitable for pasting
+// into an email: a line per day, the per-project totals and the week's
+// total, with durations aligned in one column. Long project names wrap.
+func RenderWeeklySummary(report WeeklyReport, w io.Writer) error {
+	var sb strings.Builder
+	line := func(label string, d time.Duration) {
+		fmt.Fprintf(&sb, "%-*s%8s\n", weeklyLabelWidth, label, formatSummaryDuration(d))
+	}
+
+	last := report.Week.End.AddDate(0, 0, -1)
+	title := fmt.Sprintf("Week of %s - %s", report.Week.Start.Format("Mon 2 Jan 2006"), last.Format("Mon 2 Jan 2006"))
+	sb.WriteString(title + "\n")
+	sb.WriteString(strings.Repeat("=", len(title)) + "\n\n")
+
+	for _, day := range report.Days {
+		line(day.Date.Format("Mon 02 Jan"), day.Duration)
+	}
+
+	sb.WriteString("\nProjects\n")
+	if len(report.Projects) == 0 {
+		sb.WriteString("  No time tracked\n")
+	}
+	for _, p := range report.Projects {
+		parts := wrapSummaryName(p.ProjectSID, weeklyNameWidth)
+		for _, part := range parts[:len(parts)-1] {
+			sb.WriteString("  " + part + "\n")
+		}
+		line("  "+parts[len(parts)-1], p.Duration)
+	}
+
+	sb.WriteString("\n")
+	line("Total", report.Total)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatSummaryDuration formats d as hours and minutes, or "-" for no time.
+func formatSummaryDuration(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	if minutes <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+// wrapSummaryName splits name into lines of at most width characters,
+// breaking after a separator where possible.
+func wrapSummaryName(name string, width int) []string {
+	var lines []string
+	for len(name) > width {
+		cut := strings.LastIndexAny(name[:width], "-_/. ") + 1
+		if cut <= 0 {
+			cut = width
+		}
+		lines = append(lines, strings.TrimSpace(name[:cut]))
+		name = strings.TrimSpace(name[cut:])
+	}
+	return append(lines, name)
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Weekly Summary Tests
+// =============================================================================
+
+func TestWeekRange(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{monday, monday.Add(30 * time.Hour), monday.AddDate(0, 0, 6).Add(23 * time.Hour)} {
+		week := WeekRange(at, time.UTC)
+		assert.Equal(t, monday, week.Start, at)
+		assert.Equal(t, monday.AddDate(0, 0, 7), week.End, at)
+	}
+}
+
+func TestRenderWeeklySummary(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	block := func(project string, start, length time.Duration) *model.Block {
+		b := model.NewBlock("", project, "", "", monday.Add(start))
+		b.TimestampEnd = b.TimestampStart.Add(length)
+		return b
+	}
+	blocks := []*model.Block{
+		block("clientwork", 9*time.Hour, 3*time.Hour),
+		block("internal-tooling-and-infrastructure", 13*time.Hour, 90*time.Minute),
+		block("clientwork", 33*time.Hour, 2*time.Hour+15*time.Minute),
+		// Runs past midnight into Wednesday
+		block("admin", 47*time.Hour, 2*time.Hour),
+		// The previous week is left out
+		block("clientwork", -24*time.Hour, time.Hour),
+	}
+	// Still running on Friday
+	active := model.NewBlock("", "admin", "", "", monday.AddDate(0, 0, 4).Add(9*time.Hour))
+	blocks = append(blocks, active)
+	now := active.TimestampStart.Add(45 * time.Minute)
+
+	report := WeeklySummary(blocks, monday.Add(50*time.Hour), now, time.UTC)
+	assert.Equal(t, 9*time.Hour+30*time.Minute, report.Total)
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderWeeklySummary(report, &buf))
+
+	expected := `Week of Mon 10 Mar 2025 - Sun 16 Mar 2025
+=========================================
+
+Mon 10 Mar                  4h 30m
+Tue 11 Mar                  3h 15m
+Wed 12 Mar                  1h 00m
+Thu 13 Mar                       -
+Fri 14 Mar                  0h 45m
+Sat 15 Mar                       -
+Sun 16 Mar                       -
+
+Projects
+  clientwork                5h 15m
+  admin                     2h 45m
+  internal-tooling-and-
+  infrastructure            1h 30m
+
+Total                       9h 30m
+`
+	assert.Equal(t, expected, buf.String())
+
+	// Rendering is deterministic
+	var again bytes.Buffer
+	require.NoError(t, RenderWeeklySummary(WeeklySummary(blocks, monday, now, time.UTC), &again))
+	assert.Equal(t, expected, again.String())
+}
+
+func TestRenderWeeklySummaryEmpty(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	require.NoError(t, RenderWeeklySummary(WeeklySummary(nil, monday, monday, time.UTC), &buf))
+	assert.Contains(t, buf.String(), "  No time tracked\n")
+	assert.Contains(t, buf.String(), "Total                            -\n")
+}
+
+func TestWrapSummaryName(t *testing.T) {
+	assert.Equal(t, []string{"short"}, wrapSummaryName("short", 10))
+	assert.Equal(t, []string{"alpha-", "beta"}, wrapSummaryName("alpha-beta", 8))
+	assert.Equal(t, []string{"abcdefgh", "ij"}, wrapSummaryName("abcdefghij", 8))
+}
@@ -364,6 +364,53 @@ func BenchmarkBlockCreate(b *testing.B) {
 	}
 }
 
+// BenchmarkBlockCreateBatch compares per-block creation against CreateBatch
+// for 100 blocks per iteration.
+func BenchmarkBlockCreateBatch(b *testing.B) {
+	dbDir, err := os.MkdirTemp("", "humantime-bench-db-*")
+	if err != nil {
+		b.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dbDir)
+
+	db, err := storage.Open(storage.Options{
+		Path: filepath.Join(dbDir, "humantime.db"),
+	})
+	if err != nil {
+		b.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	blockRepo := storage.NewBlockRepo(db)
+
+	newBlocks := func() []*model.Block {
+		blocks := make([]*model.Block, 100)
+		for i := range blocks {
+			blocks[i] = model.NewBlock("user1", "benchproject", "task1", "benchmark note",
+				time.Now().Add(time.Duration(i)*time.Minute))
+		}
+		return blocks
+	}
+
+	b.Run("per_block", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, block := range newBlocks() {
+				if err := blockRepo.Create(block); err != nil {
+					b.Fatalf("failed to create block: %v", err)
+				}
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := blockRepo.CreateBatch(newBlocks()); err != nil {
+				b.Fatalf("failed to create batch: %v", err)
+			}
+		}
+	})
+}
+
 // BenchmarkBlockList benchmarks listing all blocks from the database.
 func BenchmarkBlockList(b *testing.B) {
 	dbDir, err := os.MkdirTemp("", "humantime-bench-db-*")
@@ -452,6 +499,161 @@ func BenchmarkBlockListFiltered(b *testing.B) {
 	}
 }
 
+// BenchmarkBlockListByTag benchmarks tag queries through the tag index.
+func BenchmarkBlockListByTag(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := blockRepo.ListByTag("billable"); err != nil {
+			b.Fatalf("failed to list blocks by tag: %v", err)
+		}
+	}
+}
+
+// BenchmarkBlockListByTagScan benchmarks the same query as a full scan, for
+// comparison with BenchmarkBlockListByTag.
+func BenchmarkBlockListByTagScan(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blocks, err := blockRepo.List()
+		if err != nil {
+			b.Fatalf("failed to list blocks: %v", err)
+		}
+		var tagged []*model.Block
+		for _, block := range blocks {
+			if block.HasTag("billable") {
+				tagged = append(tagged, block)
+			}
+		}
+	}
+}
+
+// BenchmarkBlockListByProject benchmarks project queries through the project
+// index.
+func BenchmarkBlockListByProject(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := blockRepo.ListByProject("smallproject"); err != nil {
+			b.Fatalf("failed to list blocks by project: %v", err)
+		}
+	}
+}
+
+// BenchmarkBlockListByProjectScan benchmarks the same query as a full scan,
+// for comparison with BenchmarkBlockListByProject.
+func BenchmarkBlockListByProjectScan(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blocks, err := blockRepo.List()
+		if err != nil {
+			b.Fatalf("failed to list blocks: %v", err)
+		}
+		var matched []*model.Block
+		for _, block := range blocks {
+			if block.ProjectSID == "smallproject" {
+				matched = append(matched, block)
+			}
+		}
+	}
+}
+
+// BenchmarkBlockListByTimeRange benchmarks a one-week range query through the
+// day index.
+func BenchmarkBlockListByTimeRange(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	start := benchHistoryStart.AddDate(0, 0, 100)
+	end := start.AddDate(0, 0, 7)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := blockRepo.ListByTimeRange(start, end); err != nil {
+			b.Fatalf("failed to list blocks by time range: %v", err)
+		}
+	}
+}
+
+// BenchmarkBlockListByTimeRangeScan benchmarks the same query as a full scan,
+// for comparison with BenchmarkBlockListByTimeRange.
+func BenchmarkBlockListByTimeRangeScan(b *testing.B) {
+	blockRepo, cleanup := newTaggedBenchRepo(b)
+	defer cleanup()
+
+	start := benchHistoryStart.AddDate(0, 0, 100)
+	end := start.AddDate(0, 0, 7)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blocks, err := blockRepo.List()
+		if err != nil {
+			b.Fatalf("failed to list blocks: %v", err)
+		}
+		var matched []*model.Block
+		for _, block := range blocks {
+			if block.TimestampStart.Before(end) && block.TimestampEnd.After(start) {
+				matched = append(matched, block)
+			}
+		}
+	}
+}
+
+// benchHistoryStart is when the blocks of newTaggedBenchRepo begin.
+var benchHistoryStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTaggedBenchRepo opens a database holding 1000 one-hour blocks, one every
+// 6 hours, 1 in 20 of them tagged "billable" and 1 in 20 in project
+// "smallproject".
+func newTaggedBenchRepo(b *testing.B) (*storage.BlockRepo, func()) {
+	dbDir, err := os.MkdirTemp("", "humantime-bench-db-*")
+	if err != nil {
+		b.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.Open(storage.Options{
+		Path: filepath.Join(dbDir, "humantime.db"),
+	})
+	if err != nil {
+		os.RemoveAll(dbDir)
+		b.Fatalf("failed to open db: %v", err)
+	}
+
+	blockRepo := storage.NewBlockRepo(db)
+	for i := 0; i < 1000; i++ {
+		block := model.NewBlock(
+			"user1",
+			"benchproject",
+			"task1",
+			"benchmark note",
+			benchHistoryStart.Add(time.Duration(i)*6*time.Hour),
+		)
+		block.TimestampEnd = block.TimestampStart.Add(time.Hour)
+		if i%20 == 0 {
+			block.AddTag("billable")
+		}
+		if i%20 == 10 {
+			block.ProjectSID = "smallproject"
+		}
+		if err := blockRepo.Create(block); err != nil {
+			b.Fatalf("failed to create block: %v", err)
+		}
+	}
+
+	return blockRepo, func() {
+		db.Close()
+		os.RemoveAll(dbDir)
+	}
+}
+
 // ============================================================================
 // Performance Validation Tests
 // ============================================================================
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/manav03panchal/humantime/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Project Stats Tests
+// =============================================================================
+
+func TestProjectStats(t *testing.T) {
+	db := setupTestDB(t)
+	repo := storage.NewBlockRepo(db)
+
+	loc := time.UTC
+	now := time.Date(2025, 3, 14, 15, 0, 0, 0, loc)
+	day := func(offset, hour int) time.Time {
+		return time.Date(2025, 3, 14+offset, hour, 0, 0, 0, loc)
+	}
+
+	// Mar 8 (breaks the streak), then Mar 11-13 and an active block today
+	first := createTestBlock(t, repo, "clientwork", "api", "", day(-6, 9), day(-6, 10))
+	createTestBlock(t, repo, "clientwork", "api", "", day(-3, 9), day(-3, 12))
+	createTestBlock(t, repo, "clientwork", "ui", "", day(-2, 9), day(-2, 11))
+	createTestBlock(t, repo, "clientwork", "", "", day(-1, 22), day(0, 1)) // crosses midnight
+	createTestBlock(t, repo, "clientwork", "ui", "", day(0, 14), time.Time{})
+	createTestBlock(t, repo, "other", "", "", day(0, 9), day(0, 12))
+
+	stats, err := storage.ProjectStats("clientwork", repo, now, loc)
+	require.NoError(t, err)
+
+	t.Run("totals", func(t *testing.T) {
+		assert.Equal(t, "clientwork", stats.ProjectSID)
+		assert.Equal(t, 5, stats.BlockCount)
+		assert.Equal(t, 10*time.Hour, stats.TotalDuration)
+	})
+
+	t.Run("task_breakdown", func(t *testing.T) {
+		require.Len(t, stats.Tasks, 3)
+		assert.Equal(t, storage.TaskStats{TaskSID: "api", Duration: 4 * time.Hour, BlockCount: 2}, stats.Tasks[0])
+		// Ties go to the lowest task SID
+		assert.Equal(t, storage.TaskStats{TaskSID: "", Duration: 3 * time.Hour, BlockCount: 1}, stats.Tasks[1])
+		assert.Equal(t, storage.TaskStats{TaskSID: "ui", Duration: 3 * time.Hour, BlockCount: 2}, stats.Tasks[2])
+	})
+
+	t.Run("activity_span", func(t *testing.T) {
+		assert.Equal(t, first.TimestampStart, stats.FirstActivity)
+		assert.Equal(t, now, stats.LastActivity)
+	})
+
+	t.Run("current_streak", func(t *testing.T) {
+		assert.Equal(t, 4, stats.CurrentStreak)
+
+		// Nothing tracked yet today: the streak runs through yesterday
+		createTestBlock(t, repo, "side", "", "", day(-2, 9), day(-2, 10))
+		createTestBlock(t, repo, "side", "", "", day(-1, 9), day(-1, 10))
+		side, err := storage.ProjectStats("side", repo, now, loc)
+		require.NoError(t, err)
+		assert.Equal(t, 2, side.CurrentStreak)
+
+		// A missed day ends it
+		later, err := storage.ProjectStats("side", repo, day(1, 8), loc)
+		require.NoError(t, err)
+		assert.Zero(t, later.CurrentStreak)
+	})
+
+	t.Run("project_without_blocks", func(t *testing.T) {
+		empty, err := storage.ProjectStats("unknown", repo, now, loc)
+		require.NoError(t, err)
+		assert.Equal(t, storage.ProjectStatsReport{ProjectSID: "unknown"}, empty)
+	})
+}